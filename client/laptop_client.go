@@ -3,6 +3,8 @@ package client
 import (
 	"bufio"
 	"context"
+	"crypto/sha256"
+	"encoding/hex"
 	"fmt"
 	"io"
 	"log"
@@ -81,7 +83,8 @@ func (laptopClient *LaptopClient) SearchLaptop(filter *pb.Filter) {
 	}
 }
 
-// UploadImage calls upload image RPC
+// UploadImage calls upload image RPC.
+// It sends the size and SHA-256 checksum of the image, so that the server only saves the image if it's received intact.
 func (laptopClient *LaptopClient) UploadImage(laptopID string, imagePath string) {
 	file, err := os.Open(imagePath)
 	if err != nil {
@@ -89,6 +92,18 @@ func (laptopClient *LaptopClient) UploadImage(laptopID string, imagePath string)
 	}
 	defer file.Close()
 
+	hash := sha256.New()
+	size, err := io.Copy(hash, file)
+	if err != nil {
+		log.Fatal("cannot compute image checksum: ", err)
+	}
+	checksum := hex.EncodeToString(hash.Sum(nil))
+
+	_, err = file.Seek(0, io.SeekStart)
+	if err != nil {
+		log.Fatal("cannot rewind image file: ", err)
+	}
+
 	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
 	defer cancel()
 
@@ -102,6 +117,8 @@ func (laptopClient *LaptopClient) UploadImage(laptopID string, imagePath string)
 			Info: &pb.ImageInfo{
 				LaptopId:  laptopID,
 				ImageType: filepath.Ext(imagePath),
+				Size:      uint64(size),
+				Checksum:  checksum,
 			},
 		},
 	}
@@ -140,7 +157,56 @@ func (laptopClient *LaptopClient) UploadImage(laptopID string, imagePath string)
 	if err != nil {
 		log.Fatal("cannot receive response: ", err)
 	}
-	log.Printf("image uploaded with id %s, size: %d", res.GetId(), res.GetSize())
+	if res.GetChecksum() != checksum {
+		log.Fatalf("server received image with checksum %s, expected %s", res.GetChecksum(), checksum)
+	}
+	log.Printf("image uploaded with id %s, size: %d, checksum: %s", res.GetId(), res.GetSize(), res.GetChecksum())
+}
+
+// DownloadImage calls download image RPC and writes the image to a file in the given folder.
+// A non-zero rendition downloads the rendition with that long edge instead of the original image.
+// It returns the path of the downloaded image.
+func (laptopClient *LaptopClient) DownloadImage(imageID string, rendition uint32, imageFolder string) (string, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	req := &pb.DownloadImageRequest{ImageId: imageID, Rendition: rendition}
+	stream, err := laptopClient.service.DownloadImage(ctx, req)
+	if err != nil {
+		return "", fmt.Errorf("cannot download image: %v", err)
+	}
+
+	res, err := stream.Recv()
+	if err != nil {
+		return "", fmt.Errorf("cannot receive image info: %v", err)
+	}
+
+	imagePath := filepath.Join(imageFolder, imageID+res.GetInfo().GetImageType())
+	file, err := os.Create(imagePath)
+	if err != nil {
+		return "", fmt.Errorf("cannot create image file: %v", err)
+	}
+	defer file.Close()
+
+	size := 0
+	for {
+		res, err := stream.Recv()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return "", fmt.Errorf("cannot receive chunk data: %v", err)
+		}
+
+		n, err := file.Write(res.GetChunkData())
+		if err != nil {
+			return "", fmt.Errorf("cannot write chunk data: %v", err)
+		}
+		size += n
+	}
+
+	log.Printf("image downloaded to %s, size: %d", imagePath, size)
+	return imagePath, nil
 }
 
 // RateLaptop calls rate laptop RPC
@@ -168,6 +234,14 @@ func (laptopClient *LaptopClient) RateLaptop(laptopIDs []string, scores []float6
 				waitResponseCh <- fmt.Errorf("cannot receive stream response: %v", err)
 				return
 			}
+			if res.GetError() != nil {
+				log.Printf("rate-laptop request rejected: [ id = %s | error = %s ]", res.GetLaptopId(), res.GetError().GetMessage())
+				continue
+			}
+			if res.GetQuarantined() {
+				log.Printf("rate-laptop request quarantined for review: [ id = %s ]", res.GetLaptopId())
+				continue
+			}
 			log.Printf("received a rate-laptop response: [ id = %s | rated_count = %d | avg = %.2f ]", res.GetLaptopId(), res.GetRatedCount(), res.GetAverageScore())
 		}
 	}()
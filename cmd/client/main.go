@@ -80,9 +80,25 @@ func main() {
 func authMethods() map[string]bool {
 	const laptopServicePath = "/brucemig.pcbook.LaptopService/"
 	return map[string]bool{
-		laptopServicePath + "CreateLaptop": true,
-		laptopServicePath + "UploadImage":  true,
-		laptopServicePath + "RateLaptop":   true,
+		laptopServicePath + "CreateLaptop":             true,
+		laptopServicePath + "UploadImage":              true,
+		laptopServicePath + "StartUpload":              true,
+		laptopServicePath + "UploadChunk":              true,
+		laptopServicePath + "GetUploadStatus":          true,
+		laptopServicePath + "CompleteUpload":           true,
+		laptopServicePath + "DeleteImage":              true,
+		laptopServicePath + "SetPrimaryImage":          true,
+		laptopServicePath + "ReorderImages":            true,
+		laptopServicePath + "RateLaptop":               true,
+		laptopServicePath + "RateLaptops":              true,
+		laptopServicePath + "GetMyRatings":             true,
+		laptopServicePath + "SubmitReview":             true,
+		laptopServicePath + "ListPendingReviews":       true,
+		laptopServicePath + "ApproveReview":            true,
+		laptopServicePath + "RejectReview":             true,
+		laptopServicePath + "ListQuarantinedRatings":   true,
+		laptopServicePath + "ReleaseQuarantinedRating": true,
+		laptopServicePath + "DiscardQuarantinedRating": true,
 	}
 }
 
@@ -0,0 +1,80 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"gitlab.com/brucemig/pcbook/serializer"
+	"gitlab.com/brucemig/pcbook/service"
+)
+
+func main() {
+	logFile := flag.String("log", "", "the catalog event log file")
+	until := flag.String("until", "", "replay events until this RFC 3339 timestamp (default: the whole log)")
+	flag.Parse()
+
+	if *logFile == "" {
+		log.Fatal("event log file is not provided")
+	}
+
+	var untilTime time.Time
+	if *until != "" {
+		var err error
+		untilTime, err = time.Parse(time.RFC3339, *until)
+		if err != nil {
+			log.Fatal("cannot parse until timestamp: ", err)
+		}
+	}
+
+	// the log may belong to a running server, so it is only read
+	eventLog, err := service.NewFileEventLogReader(*logFile)
+	if err != nil {
+		log.Fatal("cannot open event log: ", err)
+	}
+
+	catalog, err := service.ReplayCatalog(eventLog, untilTime)
+	if err != nil {
+		log.Fatal("cannot replay catalog: ", err)
+	}
+
+	laptops, err := catalog.Laptops()
+	if err != nil {
+		log.Fatal("cannot list laptops: ", err)
+	}
+
+	for _, laptop := range laptops {
+		data, err := serializer.ProtobufToJSON(laptop)
+		if err != nil {
+			log.Fatal("cannot marshal laptop: ", err)
+		}
+		fmt.Println(data)
+
+		rating, err := catalog.Rating(laptop.GetId())
+		if err != nil {
+			log.Fatal("cannot find rating: ", err)
+		}
+		if rating != nil {
+			fmt.Printf(
+				"rating: rated_count = %d | avg = %.2f | median = %.2f | bayesian_avg = %.2f | decayed_avg = %.2f\n",
+				rating.Count, rating.Average(), rating.Median, rating.BayesianAverage, rating.DecayedAverage,
+			)
+		}
+
+		for _, image := range catalog.Images(laptop.GetId()) {
+			fmt.Printf("image: id = %s | type = %s | size = %d\n", image.GetImageId(), image.GetImageType(), image.GetSize())
+		}
+
+		gallery, err := catalog.Gallery(laptop.GetId())
+		if err != nil {
+			log.Fatal("cannot find gallery: ", err)
+		}
+		if len(gallery) > 0 {
+			fmt.Printf("gallery: %s\n", strings.Join(gallery, ", "))
+		}
+	}
+
+	fmt.Printf("%d laptops in the catalog\n", len(laptops))
+}
@@ -10,6 +10,9 @@ import (
 	"net"
 	"net/http"
 	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/grpc-ecosystem/grpc-gateway/v2/runtime"
@@ -64,9 +67,25 @@ func createUser(userStore service.UserStore, username, password, role string) er
 func accessibleRoles() map[string][]string {
 	const laptopServicePath = "/brucemig.pcbook.LaptopService/"
 	return map[string][]string{
-		laptopServicePath + "CreateLaptop": {viper.GetString("ROLE1")},
-		laptopServicePath + "UploadImage":  {viper.GetString("ROLE1")},
-		laptopServicePath + "RateLaptop":   {viper.GetString("ROLE1"), viper.GetString("ROLE2")},
+		laptopServicePath + "CreateLaptop":             {viper.GetString("ROLE1")},
+		laptopServicePath + "UploadImage":              {viper.GetString("ROLE1")},
+		laptopServicePath + "StartUpload":              {viper.GetString("ROLE1")},
+		laptopServicePath + "UploadChunk":              {viper.GetString("ROLE1")},
+		laptopServicePath + "GetUploadStatus":          {viper.GetString("ROLE1")},
+		laptopServicePath + "CompleteUpload":           {viper.GetString("ROLE1")},
+		laptopServicePath + "DeleteImage":              {viper.GetString("ROLE1")},
+		laptopServicePath + "SetPrimaryImage":          {viper.GetString("ROLE1")},
+		laptopServicePath + "ReorderImages":            {viper.GetString("ROLE1")},
+		laptopServicePath + "RateLaptop":               {viper.GetString("ROLE1"), viper.GetString("ROLE2")},
+		laptopServicePath + "RateLaptops":              {viper.GetString("ROLE1"), viper.GetString("ROLE2")},
+		laptopServicePath + "GetMyRatings":             {viper.GetString("ROLE1"), viper.GetString("ROLE2")},
+		laptopServicePath + "SubmitReview":             {viper.GetString("ROLE1"), viper.GetString("ROLE2")},
+		laptopServicePath + "ListPendingReviews":       {viper.GetString("ROLE1")},
+		laptopServicePath + "ApproveReview":            {viper.GetString("ROLE1")},
+		laptopServicePath + "RejectReview":             {viper.GetString("ROLE1")},
+		laptopServicePath + "ListQuarantinedRatings":   {viper.GetString("ROLE1")},
+		laptopServicePath + "ReleaseQuarantinedRating": {viper.GetString("ROLE1")},
+		laptopServicePath + "DiscardQuarantinedRating": {viper.GetString("ROLE1")},
 	}
 }
 
@@ -132,7 +151,6 @@ func runGRPCServer(
 
 func runRESTServer(
 	authServer pb.AuthServiceServer,
-	laptopServer pb.LaptopServiceServer,
 	jwtManager *service.JWTManager,
 	enableTLS bool,
 	listener net.Listener,
@@ -150,11 +168,31 @@ func runRESTServer(
 		return err
 	}
 
-	// err = pb.RegisterLaptopServiceHandlerServer(ctx, mux, laptopServer)
 	err = pb.RegisterLaptopServiceHandlerFromEndpoint(ctx, mux, grpcEndpoint, dialOptions)
 	if err != nil {
 		return err
 	}
+
+	// images are served as raw bytes instead of a stream of JSON messages
+	conn, err := grpc.Dial(grpcEndpoint, dialOptions...)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	laptopClient := pb.NewLaptopServiceClient(conn)
+	err = mux.HandlePath("GET", "/v1/laptop/image/{id}", service.DownloadImageHandler(laptopClient))
+	if err != nil {
+		return err
+	}
+
+	// browsers upload images as multipart forms, which the gateway cannot turn into a client stream.
+	// The handler replaces the generated one, since the mux matches the last registered handler first.
+	err = mux.HandlePath("POST", "/v1/laptop/upload_image", service.UploadImageHandler(laptopClient))
+	if err != nil {
+		return err
+	}
+
 	log.Printf("REST server listening on port %s, TLS = %t", listener.Addr().String(), enableTLS)
 	if enableTLS {
 		return http.ServeTLS(listener, mux, serverCertFile, serverKeyFile)
@@ -183,8 +221,57 @@ func main() {
 	enableTLS := flag.Bool("tls", false, "enable SSL/TLS")
 	serverType := flag.String("type", "grpc", "type of server (grpc/rest)")
 	endPoint := flag.String("endpoint", "", "gRPC endpoint")
+	eventLogFile := flag.String("event-log", "", "the catalog event log file (in memory if empty)")
+	cacheSize := flag.Int("cache-size", 1000, "the maximum number of cached laptops")
+	cacheTTL := flag.Duration("cache-ttl", time.Minute, "how long a laptop stays in the cache")
+	maxImageDimension := flag.Int("max-image-dimension", 8192, "the maximum width and height of uploaded images, in pixels")
+	maxImagesPerLaptop := flag.Int("max-images-per-laptop", 20, "the maximum number of images in a laptop gallery")
+	maxImageSize := flag.Int64("max-image-size", 1<<20, "the maximum size of uploaded images, in bytes")
+	maxConcurrentUploads := flag.Int("max-concurrent-uploads", 64, "the maximum number of images uploaded at the same time")
+	maxUserUploadSessions := flag.Int("max-user-upload-sessions", 8, "the maximum number of open upload sessions of a user")
+	uploadSessionTTL := flag.Duration("upload-session-ttl", time.Hour, "how long an upload session without new chunks is kept")
+	renditionSizes := flag.String("rendition-sizes", "128,512,1024", "comma-separated long edge sizes of the image renditions, in pixels")
+	renditionWorkers := flag.Int("rendition-workers", 2, "the number of workers that generate the image renditions")
+	renditionQueueSize := flag.Int("rendition-queue-size", 64, "the maximum number of images waiting for their renditions")
+	imageStoreType := flag.String("image-store", "disk", "where images are stored (disk/s3)")
+	s3Endpoint := flag.String("s3-endpoint", "", "the S3 API endpoint, such as https://s3.us-east-1.amazonaws.com")
+	s3Bucket := flag.String("s3-bucket", "", "the S3 bucket of the images")
+	s3Region := flag.String("s3-region", "us-east-1", "the region of the S3 bucket")
+	laptopImageQuota := flag.Int64("laptop-image-quota", 64<<20, "the maximum total size of the images of a laptop on disk, in bytes (0 for no limit)")
+	imageQuota := flag.Int64("image-quota", 10<<30, "the maximum total size of the images on disk, in bytes (0 for no limit)")
+	imageGCInterval := flag.Duration("image-gc-interval", 10*time.Minute, "how often orphan images and files are collected on disk")
+	ratingPriorWeight := flag.Float64("rating-prior-weight", service.DefaultRatingPrior.Weight, "the number of virtual scores in the Bayesian average of the ratings")
+	ratingPriorMean := flag.Float64("rating-prior-mean", service.DefaultRatingPrior.Mean, "the value of the virtual scores in the Bayesian average of the ratings")
+	ratingHalfLife := flag.Duration("rating-half-life", service.DefaultRatingHalfLife, "the time after which a score weighs half as much in the decayed average of the ratings (0 for no decay)")
+	ratingUpdateInterval := flag.Duration("rating-update-interval", 500*time.Millisecond, "the minimum time between the rating updates sent to a watcher, during which the updates of a laptop are coalesced")
+	minScore := flag.Float64("min-score", service.MinScore, "the minimum rating and review score")
+	maxScore := flag.Float64("max-score", service.MaxScore, "the maximum rating and review score")
+	scorePrecision := flag.Int("score-precision", 1, "the maximum number of decimals of the rating and review scores")
+	abuseWindow := flag.Duration("abuse-window", service.DefaultAbuseThresholds.Window, "the sliding window of the rating velocity statistics of the abuse detector")
+	abuseMaxUserVotes := flag.Int("abuse-max-user-votes", service.DefaultAbuseThresholds.MaxUserVotes, "the maximum number of scores of a user in the abuse window (0 for no limit)")
+	abuseMaxExtremeVotes := flag.Int("abuse-max-extreme-votes", service.DefaultAbuseThresholds.MaxUserExtremeVotes, "the maximum number of minimum or maximum scores of a user in the abuse window (0 for no limit)")
+	abuseMaxIdenticalScores := flag.Int("abuse-max-identical-scores", service.DefaultAbuseThresholds.MaxIdenticalScores, "the maximum number of identical scores of a laptop in the abuse window (0 for no limit)")
+	imageGCGracePeriod := flag.Duration("image-gc-grace-period", time.Hour, "how old orphan images and files must be to be collected")
 	flag.Parse()
 
+	sizes, err := parseRenditionSizes(*renditionSizes)
+	if err != nil {
+		log.Fatal("cannot parse rendition sizes: ", err)
+	}
+
+	if *ratingPriorWeight < 0 {
+		log.Fatal("rating prior weight must not be negative: ", *ratingPriorWeight)
+	}
+	if *ratingHalfLife < 0 {
+		log.Fatal("rating half-life must not be negative: ", *ratingHalfLife)
+	}
+	if *minScore > *maxScore {
+		log.Fatalf("min score %v must not be greater than max score %v", *minScore, *maxScore)
+	}
+	if *scorePrecision < 0 {
+		log.Fatal("score precision must not be negative: ", *scorePrecision)
+	}
+
 	userStore := service.NewInMemoryUserStore()
 	if err := seedUsers(userStore); err != nil {
 		log.Fatal("cannot seed users:", err)
@@ -193,25 +280,147 @@ func main() {
 	jwtManager := service.NewJWTManager(viper.GetString("SECRET_KEY"), viper.GetDuration("TOKEN_DURATION")*time.Minute)
 	authServer := service.NewAuthServer(userStore, jwtManager)
 
-	laptopStore := service.NewInMemoryLaptopStore()
-	imageStore := service.NewDiskImageStore("img")
-	ratingStore := service.NewInMemoryRatingStore()
-
-	laptopServer := service.NewLaptopServer(laptopStore, imageStore, ratingStore)
-
 	address := fmt.Sprintf("0.0.0.0:%d", *port)
 	listener, err := net.Listen("tcp", address)
 	if err != nil {
 		log.Fatal("cannot start server:", err)
 	}
 
-	if *serverType == "grpc" {
-		err = runGRPCServer(authServer, laptopServer, jwtManager, *enableTLS, listener)
-	} else {
-		err = runRESTServer(authServer, laptopServer, jwtManager, *enableTLS, listener, *endPoint)
+	// the REST server only proxies to the gRPC server, which owns the catalog and the images
+	if *serverType != "grpc" {
+		err = runRESTServer(authServer, jwtManager, *enableTLS, listener, *endPoint)
+		if err != nil {
+			log.Fatal("cannot start server: ", err)
+		}
+		return
 	}
 
+	var eventLog service.EventLog = service.NewInMemoryEventLog()
+	if *eventLogFile != "" {
+		fileEventLog, err := service.NewFileEventLog(*eventLogFile)
+		if err != nil {
+			log.Fatal("cannot open event log: ", err)
+		}
+		defer fileEventLog.Close()
+		eventLog = fileEventLog
+	}
+
+	ratingBroker := service.NewRatingBroker()
+	catalog, err := service.NewCatalog(
+		eventLog,
+		service.WithRatingPrior(*ratingPriorWeight, *ratingPriorMean),
+		service.WithRatingHalfLife(*ratingHalfLife),
+		service.WithRatingScoreRange(*minScore, *maxScore),
+		service.WithRatingObserver(ratingBroker.Publish),
+	)
+	if err != nil {
+		log.Fatal("cannot load catalog: ", err)
+	}
+
+	laptopStore := service.NewCachedLaptopStore(catalog.LaptopStore(), *cacheSize, *cacheTTL)
+
+	var baseImageStore service.ImageStore
+	var diskImageStore *service.DiskImageStore
+	var s3ImageStore *service.S3ImageStore
+	switch *imageStoreType {
+	case "disk":
+		diskImageStore, err = service.NewDiskImageStore("img", service.WithDiskQuota(*laptopImageQuota, *imageQuota))
+		baseImageStore = diskImageStore
+	case "s3":
+		s3ImageStore, err = service.NewS3ImageStore(service.S3Config{
+			Endpoint:        *s3Endpoint,
+			Bucket:          *s3Bucket,
+			Region:          *s3Region,
+			AccessKeyID:     viper.GetString("S3_ACCESS_KEY_ID"),
+			SecretAccessKey: viper.GetString("S3_SECRET_ACCESS_KEY"),
+		})
+		baseImageStore = s3ImageStore
+	default:
+		err = fmt.Errorf("unknown image store: %s", *imageStoreType)
+	}
+	if err != nil {
+		log.Fatal("cannot load image store: ", err)
+	}
+
+	imageStore := catalog.ImageStore(baseImageStore)
+	ratingStore := catalog.RatingStore()
+
+	// without an event log, the laptops are lost at restart, and all the images on disk would look orphan
+	if diskImageStore != nil && *eventLogFile == "" {
+		log.Print("orphan images are not collected without an event log")
+	} else if diskImageStore != nil {
+		imageCollector := service.NewImageCollector(
+			diskImageStore,
+			imageStore,
+			laptopStore,
+			*imageGCGracePeriod,
+			service.WithCollectorCatalog(catalog),
+		)
+		go service.CollectImages(context.Background(), imageCollector, *imageGCInterval)
+	}
+	if s3ImageStore != nil {
+		go service.CollectDanglingImages(context.Background(), s3ImageStore, imageStore, *imageGCGracePeriod, *imageGCInterval)
+	}
+
+	// the partial uploads are spooled next to the images when they are stored on disk
+	var uploadSessionOptions []service.UploadSessionStoreOption
+	if diskImageStore != nil {
+		uploadSessionOptions = append(uploadSessionOptions, service.WithUploadFolder(filepath.Join("img", "uploads")))
+	}
+	uploadSessionStore := service.NewInMemoryUploadSessionStore(*uploadSessionTTL, uploadSessionOptions...)
+	go service.CollectUploadSessions(context.Background(), uploadSessionStore, time.Minute)
+
+	laptopServer := service.NewLaptopServer(
+		laptopStore,
+		imageStore,
+		ratingStore,
+		service.WithImageValidator(service.NewImageValidator(*maxImageDimension, *maxImageDimension)),
+		service.WithRenditionSizes(sizes...),
+		service.WithRenditionWorkers(*renditionWorkers, *renditionQueueSize),
+		service.WithUploadSessionStore(uploadSessionStore),
+		service.WithMaxUserUploadSessions(*maxUserUploadSessions),
+		service.WithMaxImageSize(*maxImageSize),
+		service.WithMaxConcurrentUploads(*maxConcurrentUploads),
+		service.WithGalleryStore(catalog.GalleryStore()),
+		service.WithMaxImagesPerLaptop(*maxImagesPerLaptop),
+		service.WithReviewStore(catalog.ReviewStore()),
+		service.WithScoreRange(*minScore, *maxScore),
+		service.WithScorePrecision(*scorePrecision),
+		service.WithRatingBroker(ratingBroker),
+		service.WithRatingUpdateInterval(*ratingUpdateInterval),
+		service.WithAbuseDetector(service.NewVelocityAbuseDetector(service.AbuseThresholds{
+			Window:              *abuseWindow,
+			MaxUserVotes:        *abuseMaxUserVotes,
+			MaxUserExtremeVotes: *abuseMaxExtremeVotes,
+			MinScore:            *minScore,
+			MaxScore:            *maxScore,
+			MaxIdenticalScores:  *abuseMaxIdenticalScores,
+		})),
+		service.WithQuarantineStore(catalog.QuarantineStore()),
+	)
+
+	err = runGRPCServer(authServer, laptopServer, jwtManager, *enableTLS, listener)
 	if err != nil {
 		log.Fatal("cannot start server: ", err)
 	}
 }
+
+func parseRenditionSizes(value string) ([]int, error) {
+	var sizes []int
+	for _, field := range strings.Split(value, ",") {
+		field = strings.TrimSpace(field)
+		if field == "" {
+			continue
+		}
+
+		size, err := strconv.Atoi(field)
+		if err != nil {
+			return nil, err
+		}
+		if size <= 0 {
+			return nil, fmt.Errorf("rendition size must be positive: %d", size)
+		}
+		sizes = append(sizes, size)
+	}
+	return sizes, nil
+}
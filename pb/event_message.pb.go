@@ -0,0 +1,1457 @@
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.31.0
+// 	protoc        v4.25.1
+// source: event_message.proto
+
+package pb
+
+import (
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	timestamppb "google.golang.org/protobuf/types/known/timestamppb"
+	reflect "reflect"
+	sync "sync"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+type LaptopCreated struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Laptop *Laptop `protobuf:"bytes,1,opt,name=laptop,proto3" json:"laptop,omitempty"`
+}
+
+func (x *LaptopCreated) Reset() {
+	*x = LaptopCreated{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_event_message_proto_msgTypes[0]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *LaptopCreated) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*LaptopCreated) ProtoMessage() {}
+
+func (x *LaptopCreated) ProtoReflect() protoreflect.Message {
+	mi := &file_event_message_proto_msgTypes[0]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use LaptopCreated.ProtoReflect.Descriptor instead.
+func (*LaptopCreated) Descriptor() ([]byte, []int) {
+	return file_event_message_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *LaptopCreated) GetLaptop() *Laptop {
+	if x != nil {
+		return x.Laptop
+	}
+	return nil
+}
+
+type LaptopUpdated struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Laptop *Laptop `protobuf:"bytes,1,opt,name=laptop,proto3" json:"laptop,omitempty"`
+}
+
+func (x *LaptopUpdated) Reset() {
+	*x = LaptopUpdated{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_event_message_proto_msgTypes[1]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *LaptopUpdated) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*LaptopUpdated) ProtoMessage() {}
+
+func (x *LaptopUpdated) ProtoReflect() protoreflect.Message {
+	mi := &file_event_message_proto_msgTypes[1]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use LaptopUpdated.ProtoReflect.Descriptor instead.
+func (*LaptopUpdated) Descriptor() ([]byte, []int) {
+	return file_event_message_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *LaptopUpdated) GetLaptop() *Laptop {
+	if x != nil {
+		return x.Laptop
+	}
+	return nil
+}
+
+type LaptopDeleted struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	LaptopId string `protobuf:"bytes,1,opt,name=laptop_id,json=laptopId,proto3" json:"laptop_id,omitempty"`
+}
+
+func (x *LaptopDeleted) Reset() {
+	*x = LaptopDeleted{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_event_message_proto_msgTypes[2]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *LaptopDeleted) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*LaptopDeleted) ProtoMessage() {}
+
+func (x *LaptopDeleted) ProtoReflect() protoreflect.Message {
+	mi := &file_event_message_proto_msgTypes[2]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use LaptopDeleted.ProtoReflect.Descriptor instead.
+func (*LaptopDeleted) Descriptor() ([]byte, []int) {
+	return file_event_message_proto_rawDescGZIP(), []int{2}
+}
+
+func (x *LaptopDeleted) GetLaptopId() string {
+	if x != nil {
+		return x.LaptopId
+	}
+	return ""
+}
+
+type ImageUploaded struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	ImageId   string `protobuf:"bytes,1,opt,name=image_id,json=imageId,proto3" json:"image_id,omitempty"`
+	LaptopId  string `protobuf:"bytes,2,opt,name=laptop_id,json=laptopId,proto3" json:"laptop_id,omitempty"`
+	ImageType string `protobuf:"bytes,3,opt,name=image_type,json=imageType,proto3" json:"image_type,omitempty"`
+	Size      uint32 `protobuf:"varint,4,opt,name=size,proto3" json:"size,omitempty"`
+	Checksum  string `protobuf:"bytes,5,opt,name=checksum,proto3" json:"checksum,omitempty"`
+	Uploader  string `protobuf:"bytes,6,opt,name=uploader,proto3" json:"uploader,omitempty"`
+	// original_image_id is set for a rendition of an original image, with the rendition size
+	OriginalImageId string `protobuf:"bytes,7,opt,name=original_image_id,json=originalImageId,proto3" json:"original_image_id,omitempty"`
+	Rendition       uint32 `protobuf:"varint,8,opt,name=rendition,proto3" json:"rendition,omitempty"`
+}
+
+func (x *ImageUploaded) Reset() {
+	*x = ImageUploaded{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_event_message_proto_msgTypes[3]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *ImageUploaded) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ImageUploaded) ProtoMessage() {}
+
+func (x *ImageUploaded) ProtoReflect() protoreflect.Message {
+	mi := &file_event_message_proto_msgTypes[3]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ImageUploaded.ProtoReflect.Descriptor instead.
+func (*ImageUploaded) Descriptor() ([]byte, []int) {
+	return file_event_message_proto_rawDescGZIP(), []int{3}
+}
+
+func (x *ImageUploaded) GetImageId() string {
+	if x != nil {
+		return x.ImageId
+	}
+	return ""
+}
+
+func (x *ImageUploaded) GetLaptopId() string {
+	if x != nil {
+		return x.LaptopId
+	}
+	return ""
+}
+
+func (x *ImageUploaded) GetImageType() string {
+	if x != nil {
+		return x.ImageType
+	}
+	return ""
+}
+
+func (x *ImageUploaded) GetSize() uint32 {
+	if x != nil {
+		return x.Size
+	}
+	return 0
+}
+
+func (x *ImageUploaded) GetChecksum() string {
+	if x != nil {
+		return x.Checksum
+	}
+	return ""
+}
+
+func (x *ImageUploaded) GetUploader() string {
+	if x != nil {
+		return x.Uploader
+	}
+	return ""
+}
+
+func (x *ImageUploaded) GetOriginalImageId() string {
+	if x != nil {
+		return x.OriginalImageId
+	}
+	return ""
+}
+
+func (x *ImageUploaded) GetRendition() uint32 {
+	if x != nil {
+		return x.Rendition
+	}
+	return 0
+}
+
+type ImageDeleted struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	ImageId string `protobuf:"bytes,1,opt,name=image_id,json=imageId,proto3" json:"image_id,omitempty"`
+}
+
+func (x *ImageDeleted) Reset() {
+	*x = ImageDeleted{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_event_message_proto_msgTypes[4]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *ImageDeleted) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ImageDeleted) ProtoMessage() {}
+
+func (x *ImageDeleted) ProtoReflect() protoreflect.Message {
+	mi := &file_event_message_proto_msgTypes[4]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ImageDeleted.ProtoReflect.Descriptor instead.
+func (*ImageDeleted) Descriptor() ([]byte, []int) {
+	return file_event_message_proto_rawDescGZIP(), []int{4}
+}
+
+func (x *ImageDeleted) GetImageId() string {
+	if x != nil {
+		return x.ImageId
+	}
+	return ""
+}
+
+type GalleryChanged struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	LaptopId string `protobuf:"bytes,1,opt,name=laptop_id,json=laptopId,proto3" json:"laptop_id,omitempty"`
+	// the new order of the gallery images, the first one is the primary image
+	ImageIds []string `protobuf:"bytes,2,rep,name=image_ids,json=imageIds,proto3" json:"image_ids,omitempty"`
+}
+
+func (x *GalleryChanged) Reset() {
+	*x = GalleryChanged{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_event_message_proto_msgTypes[5]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *GalleryChanged) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GalleryChanged) ProtoMessage() {}
+
+func (x *GalleryChanged) ProtoReflect() protoreflect.Message {
+	mi := &file_event_message_proto_msgTypes[5]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GalleryChanged.ProtoReflect.Descriptor instead.
+func (*GalleryChanged) Descriptor() ([]byte, []int) {
+	return file_event_message_proto_rawDescGZIP(), []int{5}
+}
+
+func (x *GalleryChanged) GetLaptopId() string {
+	if x != nil {
+		return x.LaptopId
+	}
+	return ""
+}
+
+func (x *GalleryChanged) GetImageIds() []string {
+	if x != nil {
+		return x.ImageIds
+	}
+	return nil
+}
+
+type LaptopRated struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	LaptopId string  `protobuf:"bytes,1,opt,name=laptop_id,json=laptopId,proto3" json:"laptop_id,omitempty"`
+	Score    float64 `protobuf:"fixed64,2,opt,name=score,proto3" json:"score,omitempty"`
+	// the user who rated the laptop, whose previous score is replaced, or empty for an anonymous score
+	Username string `protobuf:"bytes,3,opt,name=username,proto3" json:"username,omitempty"`
+	// the time of the score, which is the time of the event if not set
+	RatedAt *timestamppb.Timestamp `protobuf:"bytes,4,opt,name=rated_at,json=ratedAt,proto3" json:"rated_at,omitempty"`
+	// the approved review whose score this is, whose previous score is replaced instead of the one of the user
+	ReviewId string `protobuf:"bytes,5,opt,name=review_id,json=reviewId,proto3" json:"review_id,omitempty"`
+}
+
+func (x *LaptopRated) Reset() {
+	*x = LaptopRated{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_event_message_proto_msgTypes[6]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *LaptopRated) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*LaptopRated) ProtoMessage() {}
+
+func (x *LaptopRated) ProtoReflect() protoreflect.Message {
+	mi := &file_event_message_proto_msgTypes[6]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use LaptopRated.ProtoReflect.Descriptor instead.
+func (*LaptopRated) Descriptor() ([]byte, []int) {
+	return file_event_message_proto_rawDescGZIP(), []int{6}
+}
+
+func (x *LaptopRated) GetLaptopId() string {
+	if x != nil {
+		return x.LaptopId
+	}
+	return ""
+}
+
+func (x *LaptopRated) GetScore() float64 {
+	if x != nil {
+		return x.Score
+	}
+	return 0
+}
+
+func (x *LaptopRated) GetUsername() string {
+	if x != nil {
+		return x.Username
+	}
+	return ""
+}
+
+func (x *LaptopRated) GetRatedAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.RatedAt
+	}
+	return nil
+}
+
+func (x *LaptopRated) GetReviewId() string {
+	if x != nil {
+		return x.ReviewId
+	}
+	return ""
+}
+
+type LaptopsRated struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	// the ratings of a batch, which are counted together in order
+	Ratings []*LaptopRated `protobuf:"bytes,1,rep,name=ratings,proto3" json:"ratings,omitempty"`
+}
+
+func (x *LaptopsRated) Reset() {
+	*x = LaptopsRated{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_event_message_proto_msgTypes[7]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *LaptopsRated) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*LaptopsRated) ProtoMessage() {}
+
+func (x *LaptopsRated) ProtoReflect() protoreflect.Message {
+	mi := &file_event_message_proto_msgTypes[7]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use LaptopsRated.ProtoReflect.Descriptor instead.
+func (*LaptopsRated) Descriptor() ([]byte, []int) {
+	return file_event_message_proto_rawDescGZIP(), []int{7}
+}
+
+func (x *LaptopsRated) GetRatings() []*LaptopRated {
+	if x != nil {
+		return x.Ratings
+	}
+	return nil
+}
+
+type ReviewSubmitted struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Review *Review `protobuf:"bytes,1,opt,name=review,proto3" json:"review,omitempty"`
+}
+
+func (x *ReviewSubmitted) Reset() {
+	*x = ReviewSubmitted{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_event_message_proto_msgTypes[8]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *ReviewSubmitted) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ReviewSubmitted) ProtoMessage() {}
+
+func (x *ReviewSubmitted) ProtoReflect() protoreflect.Message {
+	mi := &file_event_message_proto_msgTypes[8]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ReviewSubmitted.ProtoReflect.Descriptor instead.
+func (*ReviewSubmitted) Descriptor() ([]byte, []int) {
+	return file_event_message_proto_rawDescGZIP(), []int{8}
+}
+
+func (x *ReviewSubmitted) GetReview() *Review {
+	if x != nil {
+		return x.Review
+	}
+	return nil
+}
+
+type ReviewModerated struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	ReviewId    string                 `protobuf:"bytes,1,opt,name=review_id,json=reviewId,proto3" json:"review_id,omitempty"`
+	Status      Review_Status          `protobuf:"varint,2,opt,name=status,proto3,enum=brucemig.pcbook.Review_Status" json:"status,omitempty"`
+	Moderator   string                 `protobuf:"bytes,3,opt,name=moderator,proto3" json:"moderator,omitempty"`
+	ModeratedAt *timestamppb.Timestamp `protobuf:"bytes,4,opt,name=moderated_at,json=moderatedAt,proto3" json:"moderated_at,omitempty"`
+}
+
+func (x *ReviewModerated) Reset() {
+	*x = ReviewModerated{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_event_message_proto_msgTypes[9]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *ReviewModerated) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ReviewModerated) ProtoMessage() {}
+
+func (x *ReviewModerated) ProtoReflect() protoreflect.Message {
+	mi := &file_event_message_proto_msgTypes[9]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ReviewModerated.ProtoReflect.Descriptor instead.
+func (*ReviewModerated) Descriptor() ([]byte, []int) {
+	return file_event_message_proto_rawDescGZIP(), []int{9}
+}
+
+func (x *ReviewModerated) GetReviewId() string {
+	if x != nil {
+		return x.ReviewId
+	}
+	return ""
+}
+
+func (x *ReviewModerated) GetStatus() Review_Status {
+	if x != nil {
+		return x.Status
+	}
+	return Review_PENDING
+}
+
+func (x *ReviewModerated) GetModerator() string {
+	if x != nil {
+		return x.Moderator
+	}
+	return ""
+}
+
+func (x *ReviewModerated) GetModeratedAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.ModeratedAt
+	}
+	return nil
+}
+
+type RatingQuarantined struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Rating *QuarantinedRating `protobuf:"bytes,1,opt,name=rating,proto3" json:"rating,omitempty"`
+}
+
+func (x *RatingQuarantined) Reset() {
+	*x = RatingQuarantined{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_event_message_proto_msgTypes[10]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *RatingQuarantined) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*RatingQuarantined) ProtoMessage() {}
+
+func (x *RatingQuarantined) ProtoReflect() protoreflect.Message {
+	mi := &file_event_message_proto_msgTypes[10]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use RatingQuarantined.ProtoReflect.Descriptor instead.
+func (*RatingQuarantined) Descriptor() ([]byte, []int) {
+	return file_event_message_proto_rawDescGZIP(), []int{10}
+}
+
+func (x *RatingQuarantined) GetRating() *QuarantinedRating {
+	if x != nil {
+		return x.Rating
+	}
+	return nil
+}
+
+type RatingsQuarantined struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	// the ratings of a batch, which are quarantined together
+	Ratings []*QuarantinedRating `protobuf:"bytes,1,rep,name=ratings,proto3" json:"ratings,omitempty"`
+}
+
+func (x *RatingsQuarantined) Reset() {
+	*x = RatingsQuarantined{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_event_message_proto_msgTypes[11]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *RatingsQuarantined) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*RatingsQuarantined) ProtoMessage() {}
+
+func (x *RatingsQuarantined) ProtoReflect() protoreflect.Message {
+	mi := &file_event_message_proto_msgTypes[11]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use RatingsQuarantined.ProtoReflect.Descriptor instead.
+func (*RatingsQuarantined) Descriptor() ([]byte, []int) {
+	return file_event_message_proto_rawDescGZIP(), []int{11}
+}
+
+func (x *RatingsQuarantined) GetRatings() []*QuarantinedRating {
+	if x != nil {
+		return x.Ratings
+	}
+	return nil
+}
+
+type QuarantinedRatingRemoved struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	// the quarantined rating is released or discarded; a released rating is counted by a LaptopRated event
+	QuarantineId string `protobuf:"bytes,1,opt,name=quarantine_id,json=quarantineId,proto3" json:"quarantine_id,omitempty"`
+}
+
+func (x *QuarantinedRatingRemoved) Reset() {
+	*x = QuarantinedRatingRemoved{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_event_message_proto_msgTypes[12]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *QuarantinedRatingRemoved) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*QuarantinedRatingRemoved) ProtoMessage() {}
+
+func (x *QuarantinedRatingRemoved) ProtoReflect() protoreflect.Message {
+	mi := &file_event_message_proto_msgTypes[12]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use QuarantinedRatingRemoved.ProtoReflect.Descriptor instead.
+func (*QuarantinedRatingRemoved) Descriptor() ([]byte, []int) {
+	return file_event_message_proto_rawDescGZIP(), []int{12}
+}
+
+func (x *QuarantinedRatingRemoved) GetQuarantineId() string {
+	if x != nil {
+		return x.QuarantineId
+	}
+	return ""
+}
+
+type Event struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Id   string                 `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	Time *timestamppb.Timestamp `protobuf:"bytes,2,opt,name=time,proto3" json:"time,omitempty"`
+	// Types that are assignable to Payload:
+	//
+	//	*Event_LaptopCreated
+	//	*Event_LaptopUpdated
+	//	*Event_LaptopDeleted
+	//	*Event_ImageUploaded
+	//	*Event_LaptopRated
+	//	*Event_ImageDeleted
+	//	*Event_GalleryChanged
+	//	*Event_ReviewSubmitted
+	//	*Event_ReviewModerated
+	//	*Event_RatingQuarantined
+	//	*Event_QuarantinedRatingRemoved
+	//	*Event_LaptopsRated
+	//	*Event_RatingsQuarantined
+	Payload isEvent_Payload `protobuf_oneof:"payload"`
+}
+
+func (x *Event) Reset() {
+	*x = Event{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_event_message_proto_msgTypes[13]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *Event) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Event) ProtoMessage() {}
+
+func (x *Event) ProtoReflect() protoreflect.Message {
+	mi := &file_event_message_proto_msgTypes[13]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Event.ProtoReflect.Descriptor instead.
+func (*Event) Descriptor() ([]byte, []int) {
+	return file_event_message_proto_rawDescGZIP(), []int{13}
+}
+
+func (x *Event) GetId() string {
+	if x != nil {
+		return x.Id
+	}
+	return ""
+}
+
+func (x *Event) GetTime() *timestamppb.Timestamp {
+	if x != nil {
+		return x.Time
+	}
+	return nil
+}
+
+func (m *Event) GetPayload() isEvent_Payload {
+	if m != nil {
+		return m.Payload
+	}
+	return nil
+}
+
+func (x *Event) GetLaptopCreated() *LaptopCreated {
+	if x, ok := x.GetPayload().(*Event_LaptopCreated); ok {
+		return x.LaptopCreated
+	}
+	return nil
+}
+
+func (x *Event) GetLaptopUpdated() *LaptopUpdated {
+	if x, ok := x.GetPayload().(*Event_LaptopUpdated); ok {
+		return x.LaptopUpdated
+	}
+	return nil
+}
+
+func (x *Event) GetLaptopDeleted() *LaptopDeleted {
+	if x, ok := x.GetPayload().(*Event_LaptopDeleted); ok {
+		return x.LaptopDeleted
+	}
+	return nil
+}
+
+func (x *Event) GetImageUploaded() *ImageUploaded {
+	if x, ok := x.GetPayload().(*Event_ImageUploaded); ok {
+		return x.ImageUploaded
+	}
+	return nil
+}
+
+func (x *Event) GetLaptopRated() *LaptopRated {
+	if x, ok := x.GetPayload().(*Event_LaptopRated); ok {
+		return x.LaptopRated
+	}
+	return nil
+}
+
+func (x *Event) GetImageDeleted() *ImageDeleted {
+	if x, ok := x.GetPayload().(*Event_ImageDeleted); ok {
+		return x.ImageDeleted
+	}
+	return nil
+}
+
+func (x *Event) GetGalleryChanged() *GalleryChanged {
+	if x, ok := x.GetPayload().(*Event_GalleryChanged); ok {
+		return x.GalleryChanged
+	}
+	return nil
+}
+
+func (x *Event) GetReviewSubmitted() *ReviewSubmitted {
+	if x, ok := x.GetPayload().(*Event_ReviewSubmitted); ok {
+		return x.ReviewSubmitted
+	}
+	return nil
+}
+
+func (x *Event) GetReviewModerated() *ReviewModerated {
+	if x, ok := x.GetPayload().(*Event_ReviewModerated); ok {
+		return x.ReviewModerated
+	}
+	return nil
+}
+
+func (x *Event) GetRatingQuarantined() *RatingQuarantined {
+	if x, ok := x.GetPayload().(*Event_RatingQuarantined); ok {
+		return x.RatingQuarantined
+	}
+	return nil
+}
+
+func (x *Event) GetQuarantinedRatingRemoved() *QuarantinedRatingRemoved {
+	if x, ok := x.GetPayload().(*Event_QuarantinedRatingRemoved); ok {
+		return x.QuarantinedRatingRemoved
+	}
+	return nil
+}
+
+func (x *Event) GetLaptopsRated() *LaptopsRated {
+	if x, ok := x.GetPayload().(*Event_LaptopsRated); ok {
+		return x.LaptopsRated
+	}
+	return nil
+}
+
+func (x *Event) GetRatingsQuarantined() *RatingsQuarantined {
+	if x, ok := x.GetPayload().(*Event_RatingsQuarantined); ok {
+		return x.RatingsQuarantined
+	}
+	return nil
+}
+
+type isEvent_Payload interface {
+	isEvent_Payload()
+}
+
+type Event_LaptopCreated struct {
+	LaptopCreated *LaptopCreated `protobuf:"bytes,3,opt,name=laptop_created,json=laptopCreated,proto3,oneof"`
+}
+
+type Event_LaptopUpdated struct {
+	LaptopUpdated *LaptopUpdated `protobuf:"bytes,4,opt,name=laptop_updated,json=laptopUpdated,proto3,oneof"`
+}
+
+type Event_LaptopDeleted struct {
+	LaptopDeleted *LaptopDeleted `protobuf:"bytes,5,opt,name=laptop_deleted,json=laptopDeleted,proto3,oneof"`
+}
+
+type Event_ImageUploaded struct {
+	ImageUploaded *ImageUploaded `protobuf:"bytes,6,opt,name=image_uploaded,json=imageUploaded,proto3,oneof"`
+}
+
+type Event_LaptopRated struct {
+	LaptopRated *LaptopRated `protobuf:"bytes,7,opt,name=laptop_rated,json=laptopRated,proto3,oneof"`
+}
+
+type Event_ImageDeleted struct {
+	ImageDeleted *ImageDeleted `protobuf:"bytes,8,opt,name=image_deleted,json=imageDeleted,proto3,oneof"`
+}
+
+type Event_GalleryChanged struct {
+	GalleryChanged *GalleryChanged `protobuf:"bytes,9,opt,name=gallery_changed,json=galleryChanged,proto3,oneof"`
+}
+
+type Event_ReviewSubmitted struct {
+	ReviewSubmitted *ReviewSubmitted `protobuf:"bytes,10,opt,name=review_submitted,json=reviewSubmitted,proto3,oneof"`
+}
+
+type Event_ReviewModerated struct {
+	ReviewModerated *ReviewModerated `protobuf:"bytes,11,opt,name=review_moderated,json=reviewModerated,proto3,oneof"`
+}
+
+type Event_RatingQuarantined struct {
+	RatingQuarantined *RatingQuarantined `protobuf:"bytes,12,opt,name=rating_quarantined,json=ratingQuarantined,proto3,oneof"`
+}
+
+type Event_QuarantinedRatingRemoved struct {
+	QuarantinedRatingRemoved *QuarantinedRatingRemoved `protobuf:"bytes,13,opt,name=quarantined_rating_removed,json=quarantinedRatingRemoved,proto3,oneof"`
+}
+
+type Event_LaptopsRated struct {
+	LaptopsRated *LaptopsRated `protobuf:"bytes,14,opt,name=laptops_rated,json=laptopsRated,proto3,oneof"`
+}
+
+type Event_RatingsQuarantined struct {
+	RatingsQuarantined *RatingsQuarantined `protobuf:"bytes,15,opt,name=ratings_quarantined,json=ratingsQuarantined,proto3,oneof"`
+}
+
+func (*Event_LaptopCreated) isEvent_Payload() {}
+
+func (*Event_LaptopUpdated) isEvent_Payload() {}
+
+func (*Event_LaptopDeleted) isEvent_Payload() {}
+
+func (*Event_ImageUploaded) isEvent_Payload() {}
+
+func (*Event_LaptopRated) isEvent_Payload() {}
+
+func (*Event_ImageDeleted) isEvent_Payload() {}
+
+func (*Event_GalleryChanged) isEvent_Payload() {}
+
+func (*Event_ReviewSubmitted) isEvent_Payload() {}
+
+func (*Event_ReviewModerated) isEvent_Payload() {}
+
+func (*Event_RatingQuarantined) isEvent_Payload() {}
+
+func (*Event_QuarantinedRatingRemoved) isEvent_Payload() {}
+
+func (*Event_LaptopsRated) isEvent_Payload() {}
+
+func (*Event_RatingsQuarantined) isEvent_Payload() {}
+
+var File_event_message_proto protoreflect.FileDescriptor
+
+var file_event_message_proto_rawDesc = []byte{
+	0x0a, 0x13, 0x65, 0x76, 0x65, 0x6e, 0x74, 0x5f, 0x6d, 0x65, 0x73, 0x73, 0x61, 0x67, 0x65, 0x2e,
+	0x70, 0x72, 0x6f, 0x74, 0x6f, 0x12, 0x0f, 0x62, 0x72, 0x75, 0x63, 0x65, 0x6d, 0x69, 0x67, 0x2e,
+	0x70, 0x63, 0x62, 0x6f, 0x6f, 0x6b, 0x1a, 0x14, 0x6c, 0x61, 0x70, 0x74, 0x6f, 0x70, 0x5f, 0x6d,
+	0x65, 0x73, 0x73, 0x61, 0x67, 0x65, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x1a, 0x14, 0x72, 0x61,
+	0x74, 0x69, 0x6e, 0x67, 0x5f, 0x6d, 0x65, 0x73, 0x73, 0x61, 0x67, 0x65, 0x2e, 0x70, 0x72, 0x6f,
+	0x74, 0x6f, 0x1a, 0x14, 0x72, 0x65, 0x76, 0x69, 0x65, 0x77, 0x5f, 0x6d, 0x65, 0x73, 0x73, 0x61,
+	0x67, 0x65, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x1a, 0x1f, 0x67, 0x6f, 0x6f, 0x67, 0x6c, 0x65,
+	0x2f, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x62, 0x75, 0x66, 0x2f, 0x74, 0x69, 0x6d, 0x65, 0x73, 0x74,
+	0x61, 0x6d, 0x70, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x22, 0x40, 0x0a, 0x0d, 0x4c, 0x61, 0x70,
+	0x74, 0x6f, 0x70, 0x43, 0x72, 0x65, 0x61, 0x74, 0x65, 0x64, 0x12, 0x2f, 0x0a, 0x06, 0x6c, 0x61,
+	0x70, 0x74, 0x6f, 0x70, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x17, 0x2e, 0x62, 0x72, 0x75,
+	0x63, 0x65, 0x6d, 0x69, 0x67, 0x2e, 0x70, 0x63, 0x62, 0x6f, 0x6f, 0x6b, 0x2e, 0x4c, 0x61, 0x70,
+	0x74, 0x6f, 0x70, 0x52, 0x06, 0x6c, 0x61, 0x70, 0x74, 0x6f, 0x70, 0x22, 0x40, 0x0a, 0x0d, 0x4c,
+	0x61, 0x70, 0x74, 0x6f, 0x70, 0x55, 0x70, 0x64, 0x61, 0x74, 0x65, 0x64, 0x12, 0x2f, 0x0a, 0x06,
+	0x6c, 0x61, 0x70, 0x74, 0x6f, 0x70, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x17, 0x2e, 0x62,
+	0x72, 0x75, 0x63, 0x65, 0x6d, 0x69, 0x67, 0x2e, 0x70, 0x63, 0x62, 0x6f, 0x6f, 0x6b, 0x2e, 0x4c,
+	0x61, 0x70, 0x74, 0x6f, 0x70, 0x52, 0x06, 0x6c, 0x61, 0x70, 0x74, 0x6f, 0x70, 0x22, 0x2c, 0x0a,
+	0x0d, 0x4c, 0x61, 0x70, 0x74, 0x6f, 0x70, 0x44, 0x65, 0x6c, 0x65, 0x74, 0x65, 0x64, 0x12, 0x1b,
+	0x0a, 0x09, 0x6c, 0x61, 0x70, 0x74, 0x6f, 0x70, 0x5f, 0x69, 0x64, 0x18, 0x01, 0x20, 0x01, 0x28,
+	0x09, 0x52, 0x08, 0x6c, 0x61, 0x70, 0x74, 0x6f, 0x70, 0x49, 0x64, 0x22, 0xfc, 0x01, 0x0a, 0x0d,
+	0x49, 0x6d, 0x61, 0x67, 0x65, 0x55, 0x70, 0x6c, 0x6f, 0x61, 0x64, 0x65, 0x64, 0x12, 0x19, 0x0a,
+	0x08, 0x69, 0x6d, 0x61, 0x67, 0x65, 0x5f, 0x69, 0x64, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52,
+	0x07, 0x69, 0x6d, 0x61, 0x67, 0x65, 0x49, 0x64, 0x12, 0x1b, 0x0a, 0x09, 0x6c, 0x61, 0x70, 0x74,
+	0x6f, 0x70, 0x5f, 0x69, 0x64, 0x18, 0x02, 0x20, 0x01, 0x28, 0x09, 0x52, 0x08, 0x6c, 0x61, 0x70,
+	0x74, 0x6f, 0x70, 0x49, 0x64, 0x12, 0x1d, 0x0a, 0x0a, 0x69, 0x6d, 0x61, 0x67, 0x65, 0x5f, 0x74,
+	0x79, 0x70, 0x65, 0x18, 0x03, 0x20, 0x01, 0x28, 0x09, 0x52, 0x09, 0x69, 0x6d, 0x61, 0x67, 0x65,
+	0x54, 0x79, 0x70, 0x65, 0x12, 0x12, 0x0a, 0x04, 0x73, 0x69, 0x7a, 0x65, 0x18, 0x04, 0x20, 0x01,
+	0x28, 0x0d, 0x52, 0x04, 0x73, 0x69, 0x7a, 0x65, 0x12, 0x1a, 0x0a, 0x08, 0x63, 0x68, 0x65, 0x63,
+	0x6b, 0x73, 0x75, 0x6d, 0x18, 0x05, 0x20, 0x01, 0x28, 0x09, 0x52, 0x08, 0x63, 0x68, 0x65, 0x63,
+	0x6b, 0x73, 0x75, 0x6d, 0x12, 0x1a, 0x0a, 0x08, 0x75, 0x70, 0x6c, 0x6f, 0x61, 0x64, 0x65, 0x72,
+	0x18, 0x06, 0x20, 0x01, 0x28, 0x09, 0x52, 0x08, 0x75, 0x70, 0x6c, 0x6f, 0x61, 0x64, 0x65, 0x72,
+	0x12, 0x2a, 0x0a, 0x11, 0x6f, 0x72, 0x69, 0x67, 0x69, 0x6e, 0x61, 0x6c, 0x5f, 0x69, 0x6d, 0x61,
+	0x67, 0x65, 0x5f, 0x69, 0x64, 0x18, 0x07, 0x20, 0x01, 0x28, 0x09, 0x52, 0x0f, 0x6f, 0x72, 0x69,
+	0x67, 0x69, 0x6e, 0x61, 0x6c, 0x49, 0x6d, 0x61, 0x67, 0x65, 0x49, 0x64, 0x12, 0x1c, 0x0a, 0x09,
+	0x72, 0x65, 0x6e, 0x64, 0x69, 0x74, 0x69, 0x6f, 0x6e, 0x18, 0x08, 0x20, 0x01, 0x28, 0x0d, 0x52,
+	0x09, 0x72, 0x65, 0x6e, 0x64, 0x69, 0x74, 0x69, 0x6f, 0x6e, 0x22, 0x29, 0x0a, 0x0c, 0x49, 0x6d,
+	0x61, 0x67, 0x65, 0x44, 0x65, 0x6c, 0x65, 0x74, 0x65, 0x64, 0x12, 0x19, 0x0a, 0x08, 0x69, 0x6d,
+	0x61, 0x67, 0x65, 0x5f, 0x69, 0x64, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x07, 0x69, 0x6d,
+	0x61, 0x67, 0x65, 0x49, 0x64, 0x22, 0x4a, 0x0a, 0x0e, 0x47, 0x61, 0x6c, 0x6c, 0x65, 0x72, 0x79,
+	0x43, 0x68, 0x61, 0x6e, 0x67, 0x65, 0x64, 0x12, 0x1b, 0x0a, 0x09, 0x6c, 0x61, 0x70, 0x74, 0x6f,
+	0x70, 0x5f, 0x69, 0x64, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x08, 0x6c, 0x61, 0x70, 0x74,
+	0x6f, 0x70, 0x49, 0x64, 0x12, 0x1b, 0x0a, 0x09, 0x69, 0x6d, 0x61, 0x67, 0x65, 0x5f, 0x69, 0x64,
+	0x73, 0x18, 0x02, 0x20, 0x03, 0x28, 0x09, 0x52, 0x08, 0x69, 0x6d, 0x61, 0x67, 0x65, 0x49, 0x64,
+	0x73, 0x22, 0xb0, 0x01, 0x0a, 0x0b, 0x4c, 0x61, 0x70, 0x74, 0x6f, 0x70, 0x52, 0x61, 0x74, 0x65,
+	0x64, 0x12, 0x1b, 0x0a, 0x09, 0x6c, 0x61, 0x70, 0x74, 0x6f, 0x70, 0x5f, 0x69, 0x64, 0x18, 0x01,
+	0x20, 0x01, 0x28, 0x09, 0x52, 0x08, 0x6c, 0x61, 0x70, 0x74, 0x6f, 0x70, 0x49, 0x64, 0x12, 0x14,
+	0x0a, 0x05, 0x73, 0x63, 0x6f, 0x72, 0x65, 0x18, 0x02, 0x20, 0x01, 0x28, 0x01, 0x52, 0x05, 0x73,
+	0x63, 0x6f, 0x72, 0x65, 0x12, 0x1a, 0x0a, 0x08, 0x75, 0x73, 0x65, 0x72, 0x6e, 0x61, 0x6d, 0x65,
+	0x18, 0x03, 0x20, 0x01, 0x28, 0x09, 0x52, 0x08, 0x75, 0x73, 0x65, 0x72, 0x6e, 0x61, 0x6d, 0x65,
+	0x12, 0x35, 0x0a, 0x08, 0x72, 0x61, 0x74, 0x65, 0x64, 0x5f, 0x61, 0x74, 0x18, 0x04, 0x20, 0x01,
+	0x28, 0x0b, 0x32, 0x1a, 0x2e, 0x67, 0x6f, 0x6f, 0x67, 0x6c, 0x65, 0x2e, 0x70, 0x72, 0x6f, 0x74,
+	0x6f, 0x62, 0x75, 0x66, 0x2e, 0x54, 0x69, 0x6d, 0x65, 0x73, 0x74, 0x61, 0x6d, 0x70, 0x52, 0x07,
+	0x72, 0x61, 0x74, 0x65, 0x64, 0x41, 0x74, 0x12, 0x1b, 0x0a, 0x09, 0x72, 0x65, 0x76, 0x69, 0x65,
+	0x77, 0x5f, 0x69, 0x64, 0x18, 0x05, 0x20, 0x01, 0x28, 0x09, 0x52, 0x08, 0x72, 0x65, 0x76, 0x69,
+	0x65, 0x77, 0x49, 0x64, 0x22, 0x46, 0x0a, 0x0c, 0x4c, 0x61, 0x70, 0x74, 0x6f, 0x70, 0x73, 0x52,
+	0x61, 0x74, 0x65, 0x64, 0x12, 0x36, 0x0a, 0x07, 0x72, 0x61, 0x74, 0x69, 0x6e, 0x67, 0x73, 0x18,
+	0x01, 0x20, 0x03, 0x28, 0x0b, 0x32, 0x1c, 0x2e, 0x62, 0x72, 0x75, 0x63, 0x65, 0x6d, 0x69, 0x67,
+	0x2e, 0x70, 0x63, 0x62, 0x6f, 0x6f, 0x6b, 0x2e, 0x4c, 0x61, 0x70, 0x74, 0x6f, 0x70, 0x52, 0x61,
+	0x74, 0x65, 0x64, 0x52, 0x07, 0x72, 0x61, 0x74, 0x69, 0x6e, 0x67, 0x73, 0x22, 0x42, 0x0a, 0x0f,
+	0x52, 0x65, 0x76, 0x69, 0x65, 0x77, 0x53, 0x75, 0x62, 0x6d, 0x69, 0x74, 0x74, 0x65, 0x64, 0x12,
+	0x2f, 0x0a, 0x06, 0x72, 0x65, 0x76, 0x69, 0x65, 0x77, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0b, 0x32,
+	0x17, 0x2e, 0x62, 0x72, 0x75, 0x63, 0x65, 0x6d, 0x69, 0x67, 0x2e, 0x70, 0x63, 0x62, 0x6f, 0x6f,
+	0x6b, 0x2e, 0x52, 0x65, 0x76, 0x69, 0x65, 0x77, 0x52, 0x06, 0x72, 0x65, 0x76, 0x69, 0x65, 0x77,
+	0x22, 0xc3, 0x01, 0x0a, 0x0f, 0x52, 0x65, 0x76, 0x69, 0x65, 0x77, 0x4d, 0x6f, 0x64, 0x65, 0x72,
+	0x61, 0x74, 0x65, 0x64, 0x12, 0x1b, 0x0a, 0x09, 0x72, 0x65, 0x76, 0x69, 0x65, 0x77, 0x5f, 0x69,
+	0x64, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x08, 0x72, 0x65, 0x76, 0x69, 0x65, 0x77, 0x49,
+	0x64, 0x12, 0x36, 0x0a, 0x06, 0x73, 0x74, 0x61, 0x74, 0x75, 0x73, 0x18, 0x02, 0x20, 0x01, 0x28,
+	0x0e, 0x32, 0x1e, 0x2e, 0x62, 0x72, 0x75, 0x63, 0x65, 0x6d, 0x69, 0x67, 0x2e, 0x70, 0x63, 0x62,
+	0x6f, 0x6f, 0x6b, 0x2e, 0x52, 0x65, 0x76, 0x69, 0x65, 0x77, 0x2e, 0x53, 0x74, 0x61, 0x74, 0x75,
+	0x73, 0x52, 0x06, 0x73, 0x74, 0x61, 0x74, 0x75, 0x73, 0x12, 0x1c, 0x0a, 0x09, 0x6d, 0x6f, 0x64,
+	0x65, 0x72, 0x61, 0x74, 0x6f, 0x72, 0x18, 0x03, 0x20, 0x01, 0x28, 0x09, 0x52, 0x09, 0x6d, 0x6f,
+	0x64, 0x65, 0x72, 0x61, 0x74, 0x6f, 0x72, 0x12, 0x3d, 0x0a, 0x0c, 0x6d, 0x6f, 0x64, 0x65, 0x72,
+	0x61, 0x74, 0x65, 0x64, 0x5f, 0x61, 0x74, 0x18, 0x04, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x1a, 0x2e,
+	0x67, 0x6f, 0x6f, 0x67, 0x6c, 0x65, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x62, 0x75, 0x66, 0x2e,
+	0x54, 0x69, 0x6d, 0x65, 0x73, 0x74, 0x61, 0x6d, 0x70, 0x52, 0x0b, 0x6d, 0x6f, 0x64, 0x65, 0x72,
+	0x61, 0x74, 0x65, 0x64, 0x41, 0x74, 0x22, 0x4f, 0x0a, 0x11, 0x52, 0x61, 0x74, 0x69, 0x6e, 0x67,
+	0x51, 0x75, 0x61, 0x72, 0x61, 0x6e, 0x74, 0x69, 0x6e, 0x65, 0x64, 0x12, 0x3a, 0x0a, 0x06, 0x72,
+	0x61, 0x74, 0x69, 0x6e, 0x67, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x22, 0x2e, 0x62, 0x72,
+	0x75, 0x63, 0x65, 0x6d, 0x69, 0x67, 0x2e, 0x70, 0x63, 0x62, 0x6f, 0x6f, 0x6b, 0x2e, 0x51, 0x75,
+	0x61, 0x72, 0x61, 0x6e, 0x74, 0x69, 0x6e, 0x65, 0x64, 0x52, 0x61, 0x74, 0x69, 0x6e, 0x67, 0x52,
+	0x06, 0x72, 0x61, 0x74, 0x69, 0x6e, 0x67, 0x22, 0x52, 0x0a, 0x12, 0x52, 0x61, 0x74, 0x69, 0x6e,
+	0x67, 0x73, 0x51, 0x75, 0x61, 0x72, 0x61, 0x6e, 0x74, 0x69, 0x6e, 0x65, 0x64, 0x12, 0x3c, 0x0a,
+	0x07, 0x72, 0x61, 0x74, 0x69, 0x6e, 0x67, 0x73, 0x18, 0x01, 0x20, 0x03, 0x28, 0x0b, 0x32, 0x22,
+	0x2e, 0x62, 0x72, 0x75, 0x63, 0x65, 0x6d, 0x69, 0x67, 0x2e, 0x70, 0x63, 0x62, 0x6f, 0x6f, 0x6b,
+	0x2e, 0x51, 0x75, 0x61, 0x72, 0x61, 0x6e, 0x74, 0x69, 0x6e, 0x65, 0x64, 0x52, 0x61, 0x74, 0x69,
+	0x6e, 0x67, 0x52, 0x07, 0x72, 0x61, 0x74, 0x69, 0x6e, 0x67, 0x73, 0x22, 0x3f, 0x0a, 0x18, 0x51,
+	0x75, 0x61, 0x72, 0x61, 0x6e, 0x74, 0x69, 0x6e, 0x65, 0x64, 0x52, 0x61, 0x74, 0x69, 0x6e, 0x67,
+	0x52, 0x65, 0x6d, 0x6f, 0x76, 0x65, 0x64, 0x12, 0x23, 0x0a, 0x0d, 0x71, 0x75, 0x61, 0x72, 0x61,
+	0x6e, 0x74, 0x69, 0x6e, 0x65, 0x5f, 0x69, 0x64, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x0c,
+	0x71, 0x75, 0x61, 0x72, 0x61, 0x6e, 0x74, 0x69, 0x6e, 0x65, 0x49, 0x64, 0x22, 0xc7, 0x08, 0x0a,
+	0x05, 0x45, 0x76, 0x65, 0x6e, 0x74, 0x12, 0x0e, 0x0a, 0x02, 0x69, 0x64, 0x18, 0x01, 0x20, 0x01,
+	0x28, 0x09, 0x52, 0x02, 0x69, 0x64, 0x12, 0x2e, 0x0a, 0x04, 0x74, 0x69, 0x6d, 0x65, 0x18, 0x02,
+	0x20, 0x01, 0x28, 0x0b, 0x32, 0x1a, 0x2e, 0x67, 0x6f, 0x6f, 0x67, 0x6c, 0x65, 0x2e, 0x70, 0x72,
+	0x6f, 0x74, 0x6f, 0x62, 0x75, 0x66, 0x2e, 0x54, 0x69, 0x6d, 0x65, 0x73, 0x74, 0x61, 0x6d, 0x70,
+	0x52, 0x04, 0x74, 0x69, 0x6d, 0x65, 0x12, 0x47, 0x0a, 0x0e, 0x6c, 0x61, 0x70, 0x74, 0x6f, 0x70,
+	0x5f, 0x63, 0x72, 0x65, 0x61, 0x74, 0x65, 0x64, 0x18, 0x03, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x1e,
+	0x2e, 0x62, 0x72, 0x75, 0x63, 0x65, 0x6d, 0x69, 0x67, 0x2e, 0x70, 0x63, 0x62, 0x6f, 0x6f, 0x6b,
+	0x2e, 0x4c, 0x61, 0x70, 0x74, 0x6f, 0x70, 0x43, 0x72, 0x65, 0x61, 0x74, 0x65, 0x64, 0x48, 0x00,
+	0x52, 0x0d, 0x6c, 0x61, 0x70, 0x74, 0x6f, 0x70, 0x43, 0x72, 0x65, 0x61, 0x74, 0x65, 0x64, 0x12,
+	0x47, 0x0a, 0x0e, 0x6c, 0x61, 0x70, 0x74, 0x6f, 0x70, 0x5f, 0x75, 0x70, 0x64, 0x61, 0x74, 0x65,
+	0x64, 0x18, 0x04, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x1e, 0x2e, 0x62, 0x72, 0x75, 0x63, 0x65, 0x6d,
+	0x69, 0x67, 0x2e, 0x70, 0x63, 0x62, 0x6f, 0x6f, 0x6b, 0x2e, 0x4c, 0x61, 0x70, 0x74, 0x6f, 0x70,
+	0x55, 0x70, 0x64, 0x61, 0x74, 0x65, 0x64, 0x48, 0x00, 0x52, 0x0d, 0x6c, 0x61, 0x70, 0x74, 0x6f,
+	0x70, 0x55, 0x70, 0x64, 0x61, 0x74, 0x65, 0x64, 0x12, 0x47, 0x0a, 0x0e, 0x6c, 0x61, 0x70, 0x74,
+	0x6f, 0x70, 0x5f, 0x64, 0x65, 0x6c, 0x65, 0x74, 0x65, 0x64, 0x18, 0x05, 0x20, 0x01, 0x28, 0x0b,
+	0x32, 0x1e, 0x2e, 0x62, 0x72, 0x75, 0x63, 0x65, 0x6d, 0x69, 0x67, 0x2e, 0x70, 0x63, 0x62, 0x6f,
+	0x6f, 0x6b, 0x2e, 0x4c, 0x61, 0x70, 0x74, 0x6f, 0x70, 0x44, 0x65, 0x6c, 0x65, 0x74, 0x65, 0x64,
+	0x48, 0x00, 0x52, 0x0d, 0x6c, 0x61, 0x70, 0x74, 0x6f, 0x70, 0x44, 0x65, 0x6c, 0x65, 0x74, 0x65,
+	0x64, 0x12, 0x47, 0x0a, 0x0e, 0x69, 0x6d, 0x61, 0x67, 0x65, 0x5f, 0x75, 0x70, 0x6c, 0x6f, 0x61,
+	0x64, 0x65, 0x64, 0x18, 0x06, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x1e, 0x2e, 0x62, 0x72, 0x75, 0x63,
+	0x65, 0x6d, 0x69, 0x67, 0x2e, 0x70, 0x63, 0x62, 0x6f, 0x6f, 0x6b, 0x2e, 0x49, 0x6d, 0x61, 0x67,
+	0x65, 0x55, 0x70, 0x6c, 0x6f, 0x61, 0x64, 0x65, 0x64, 0x48, 0x00, 0x52, 0x0d, 0x69, 0x6d, 0x61,
+	0x67, 0x65, 0x55, 0x70, 0x6c, 0x6f, 0x61, 0x64, 0x65, 0x64, 0x12, 0x41, 0x0a, 0x0c, 0x6c, 0x61,
+	0x70, 0x74, 0x6f, 0x70, 0x5f, 0x72, 0x61, 0x74, 0x65, 0x64, 0x18, 0x07, 0x20, 0x01, 0x28, 0x0b,
+	0x32, 0x1c, 0x2e, 0x62, 0x72, 0x75, 0x63, 0x65, 0x6d, 0x69, 0x67, 0x2e, 0x70, 0x63, 0x62, 0x6f,
+	0x6f, 0x6b, 0x2e, 0x4c, 0x61, 0x70, 0x74, 0x6f, 0x70, 0x52, 0x61, 0x74, 0x65, 0x64, 0x48, 0x00,
+	0x52, 0x0b, 0x6c, 0x61, 0x70, 0x74, 0x6f, 0x70, 0x52, 0x61, 0x74, 0x65, 0x64, 0x12, 0x44, 0x0a,
+	0x0d, 0x69, 0x6d, 0x61, 0x67, 0x65, 0x5f, 0x64, 0x65, 0x6c, 0x65, 0x74, 0x65, 0x64, 0x18, 0x08,
+	0x20, 0x01, 0x28, 0x0b, 0x32, 0x1d, 0x2e, 0x62, 0x72, 0x75, 0x63, 0x65, 0x6d, 0x69, 0x67, 0x2e,
+	0x70, 0x63, 0x62, 0x6f, 0x6f, 0x6b, 0x2e, 0x49, 0x6d, 0x61, 0x67, 0x65, 0x44, 0x65, 0x6c, 0x65,
+	0x74, 0x65, 0x64, 0x48, 0x00, 0x52, 0x0c, 0x69, 0x6d, 0x61, 0x67, 0x65, 0x44, 0x65, 0x6c, 0x65,
+	0x74, 0x65, 0x64, 0x12, 0x4a, 0x0a, 0x0f, 0x67, 0x61, 0x6c, 0x6c, 0x65, 0x72, 0x79, 0x5f, 0x63,
+	0x68, 0x61, 0x6e, 0x67, 0x65, 0x64, 0x18, 0x09, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x1f, 0x2e, 0x62,
+	0x72, 0x75, 0x63, 0x65, 0x6d, 0x69, 0x67, 0x2e, 0x70, 0x63, 0x62, 0x6f, 0x6f, 0x6b, 0x2e, 0x47,
+	0x61, 0x6c, 0x6c, 0x65, 0x72, 0x79, 0x43, 0x68, 0x61, 0x6e, 0x67, 0x65, 0x64, 0x48, 0x00, 0x52,
+	0x0e, 0x67, 0x61, 0x6c, 0x6c, 0x65, 0x72, 0x79, 0x43, 0x68, 0x61, 0x6e, 0x67, 0x65, 0x64, 0x12,
+	0x4d, 0x0a, 0x10, 0x72, 0x65, 0x76, 0x69, 0x65, 0x77, 0x5f, 0x73, 0x75, 0x62, 0x6d, 0x69, 0x74,
+	0x74, 0x65, 0x64, 0x18, 0x0a, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x20, 0x2e, 0x62, 0x72, 0x75, 0x63,
+	0x65, 0x6d, 0x69, 0x67, 0x2e, 0x70, 0x63, 0x62, 0x6f, 0x6f, 0x6b, 0x2e, 0x52, 0x65, 0x76, 0x69,
+	0x65, 0x77, 0x53, 0x75, 0x62, 0x6d, 0x69, 0x74, 0x74, 0x65, 0x64, 0x48, 0x00, 0x52, 0x0f, 0x72,
+	0x65, 0x76, 0x69, 0x65, 0x77, 0x53, 0x75, 0x62, 0x6d, 0x69, 0x74, 0x74, 0x65, 0x64, 0x12, 0x4d,
+	0x0a, 0x10, 0x72, 0x65, 0x76, 0x69, 0x65, 0x77, 0x5f, 0x6d, 0x6f, 0x64, 0x65, 0x72, 0x61, 0x74,
+	0x65, 0x64, 0x18, 0x0b, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x20, 0x2e, 0x62, 0x72, 0x75, 0x63, 0x65,
+	0x6d, 0x69, 0x67, 0x2e, 0x70, 0x63, 0x62, 0x6f, 0x6f, 0x6b, 0x2e, 0x52, 0x65, 0x76, 0x69, 0x65,
+	0x77, 0x4d, 0x6f, 0x64, 0x65, 0x72, 0x61, 0x74, 0x65, 0x64, 0x48, 0x00, 0x52, 0x0f, 0x72, 0x65,
+	0x76, 0x69, 0x65, 0x77, 0x4d, 0x6f, 0x64, 0x65, 0x72, 0x61, 0x74, 0x65, 0x64, 0x12, 0x53, 0x0a,
+	0x12, 0x72, 0x61, 0x74, 0x69, 0x6e, 0x67, 0x5f, 0x71, 0x75, 0x61, 0x72, 0x61, 0x6e, 0x74, 0x69,
+	0x6e, 0x65, 0x64, 0x18, 0x0c, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x22, 0x2e, 0x62, 0x72, 0x75, 0x63,
+	0x65, 0x6d, 0x69, 0x67, 0x2e, 0x70, 0x63, 0x62, 0x6f, 0x6f, 0x6b, 0x2e, 0x52, 0x61, 0x74, 0x69,
+	0x6e, 0x67, 0x51, 0x75, 0x61, 0x72, 0x61, 0x6e, 0x74, 0x69, 0x6e, 0x65, 0x64, 0x48, 0x00, 0x52,
+	0x11, 0x72, 0x61, 0x74, 0x69, 0x6e, 0x67, 0x51, 0x75, 0x61, 0x72, 0x61, 0x6e, 0x74, 0x69, 0x6e,
+	0x65, 0x64, 0x12, 0x69, 0x0a, 0x1a, 0x71, 0x75, 0x61, 0x72, 0x61, 0x6e, 0x74, 0x69, 0x6e, 0x65,
+	0x64, 0x5f, 0x72, 0x61, 0x74, 0x69, 0x6e, 0x67, 0x5f, 0x72, 0x65, 0x6d, 0x6f, 0x76, 0x65, 0x64,
+	0x18, 0x0d, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x29, 0x2e, 0x62, 0x72, 0x75, 0x63, 0x65, 0x6d, 0x69,
+	0x67, 0x2e, 0x70, 0x63, 0x62, 0x6f, 0x6f, 0x6b, 0x2e, 0x51, 0x75, 0x61, 0x72, 0x61, 0x6e, 0x74,
+	0x69, 0x6e, 0x65, 0x64, 0x52, 0x61, 0x74, 0x69, 0x6e, 0x67, 0x52, 0x65, 0x6d, 0x6f, 0x76, 0x65,
+	0x64, 0x48, 0x00, 0x52, 0x18, 0x71, 0x75, 0x61, 0x72, 0x61, 0x6e, 0x74, 0x69, 0x6e, 0x65, 0x64,
+	0x52, 0x61, 0x74, 0x69, 0x6e, 0x67, 0x52, 0x65, 0x6d, 0x6f, 0x76, 0x65, 0x64, 0x12, 0x44, 0x0a,
+	0x0d, 0x6c, 0x61, 0x70, 0x74, 0x6f, 0x70, 0x73, 0x5f, 0x72, 0x61, 0x74, 0x65, 0x64, 0x18, 0x0e,
+	0x20, 0x01, 0x28, 0x0b, 0x32, 0x1d, 0x2e, 0x62, 0x72, 0x75, 0x63, 0x65, 0x6d, 0x69, 0x67, 0x2e,
+	0x70, 0x63, 0x62, 0x6f, 0x6f, 0x6b, 0x2e, 0x4c, 0x61, 0x70, 0x74, 0x6f, 0x70, 0x73, 0x52, 0x61,
+	0x74, 0x65, 0x64, 0x48, 0x00, 0x52, 0x0c, 0x6c, 0x61, 0x70, 0x74, 0x6f, 0x70, 0x73, 0x52, 0x61,
+	0x74, 0x65, 0x64, 0x12, 0x56, 0x0a, 0x13, 0x72, 0x61, 0x74, 0x69, 0x6e, 0x67, 0x73, 0x5f, 0x71,
+	0x75, 0x61, 0x72, 0x61, 0x6e, 0x74, 0x69, 0x6e, 0x65, 0x64, 0x18, 0x0f, 0x20, 0x01, 0x28, 0x0b,
+	0x32, 0x23, 0x2e, 0x62, 0x72, 0x75, 0x63, 0x65, 0x6d, 0x69, 0x67, 0x2e, 0x70, 0x63, 0x62, 0x6f,
+	0x6f, 0x6b, 0x2e, 0x52, 0x61, 0x74, 0x69, 0x6e, 0x67, 0x73, 0x51, 0x75, 0x61, 0x72, 0x61, 0x6e,
+	0x74, 0x69, 0x6e, 0x65, 0x64, 0x48, 0x00, 0x52, 0x12, 0x72, 0x61, 0x74, 0x69, 0x6e, 0x67, 0x73,
+	0x51, 0x75, 0x61, 0x72, 0x61, 0x6e, 0x74, 0x69, 0x6e, 0x65, 0x64, 0x42, 0x09, 0x0a, 0x07, 0x70,
+	0x61, 0x79, 0x6c, 0x6f, 0x61, 0x64, 0x42, 0x27, 0x0a, 0x1d, 0x63, 0x6f, 0x6d, 0x2e, 0x67, 0x69,
+	0x74, 0x6c, 0x61, 0x62, 0x2e, 0x62, 0x72, 0x75, 0x63, 0x65, 0x6d, 0x69, 0x67, 0x2e, 0x70, 0x63,
+	0x62, 0x6f, 0x6f, 0x6b, 0x2e, 0x70, 0x62, 0x50, 0x01, 0x5a, 0x04, 0x2e, 0x3b, 0x70, 0x62, 0x62,
+	0x06, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x33,
+}
+
+var (
+	file_event_message_proto_rawDescOnce sync.Once
+	file_event_message_proto_rawDescData = file_event_message_proto_rawDesc
+)
+
+func file_event_message_proto_rawDescGZIP() []byte {
+	file_event_message_proto_rawDescOnce.Do(func() {
+		file_event_message_proto_rawDescData = protoimpl.X.CompressGZIP(file_event_message_proto_rawDescData)
+	})
+	return file_event_message_proto_rawDescData
+}
+
+var file_event_message_proto_msgTypes = make([]protoimpl.MessageInfo, 14)
+var file_event_message_proto_goTypes = []interface{}{
+	(*LaptopCreated)(nil),            // 0: brucemig.pcbook.LaptopCreated
+	(*LaptopUpdated)(nil),            // 1: brucemig.pcbook.LaptopUpdated
+	(*LaptopDeleted)(nil),            // 2: brucemig.pcbook.LaptopDeleted
+	(*ImageUploaded)(nil),            // 3: brucemig.pcbook.ImageUploaded
+	(*ImageDeleted)(nil),             // 4: brucemig.pcbook.ImageDeleted
+	(*GalleryChanged)(nil),           // 5: brucemig.pcbook.GalleryChanged
+	(*LaptopRated)(nil),              // 6: brucemig.pcbook.LaptopRated
+	(*LaptopsRated)(nil),             // 7: brucemig.pcbook.LaptopsRated
+	(*ReviewSubmitted)(nil),          // 8: brucemig.pcbook.ReviewSubmitted
+	(*ReviewModerated)(nil),          // 9: brucemig.pcbook.ReviewModerated
+	(*RatingQuarantined)(nil),        // 10: brucemig.pcbook.RatingQuarantined
+	(*RatingsQuarantined)(nil),       // 11: brucemig.pcbook.RatingsQuarantined
+	(*QuarantinedRatingRemoved)(nil), // 12: brucemig.pcbook.QuarantinedRatingRemoved
+	(*Event)(nil),                    // 13: brucemig.pcbook.Event
+	(*Laptop)(nil),                   // 14: brucemig.pcbook.Laptop
+	(*timestamppb.Timestamp)(nil),    // 15: google.protobuf.Timestamp
+	(*Review)(nil),                   // 16: brucemig.pcbook.Review
+	(Review_Status)(0),               // 17: brucemig.pcbook.Review.Status
+	(*QuarantinedRating)(nil),        // 18: brucemig.pcbook.QuarantinedRating
+}
+var file_event_message_proto_depIdxs = []int32{
+	14, // 0: brucemig.pcbook.LaptopCreated.laptop:type_name -> brucemig.pcbook.Laptop
+	14, // 1: brucemig.pcbook.LaptopUpdated.laptop:type_name -> brucemig.pcbook.Laptop
+	15, // 2: brucemig.pcbook.LaptopRated.rated_at:type_name -> google.protobuf.Timestamp
+	6,  // 3: brucemig.pcbook.LaptopsRated.ratings:type_name -> brucemig.pcbook.LaptopRated
+	16, // 4: brucemig.pcbook.ReviewSubmitted.review:type_name -> brucemig.pcbook.Review
+	17, // 5: brucemig.pcbook.ReviewModerated.status:type_name -> brucemig.pcbook.Review.Status
+	15, // 6: brucemig.pcbook.ReviewModerated.moderated_at:type_name -> google.protobuf.Timestamp
+	18, // 7: brucemig.pcbook.RatingQuarantined.rating:type_name -> brucemig.pcbook.QuarantinedRating
+	18, // 8: brucemig.pcbook.RatingsQuarantined.ratings:type_name -> brucemig.pcbook.QuarantinedRating
+	15, // 9: brucemig.pcbook.Event.time:type_name -> google.protobuf.Timestamp
+	0,  // 10: brucemig.pcbook.Event.laptop_created:type_name -> brucemig.pcbook.LaptopCreated
+	1,  // 11: brucemig.pcbook.Event.laptop_updated:type_name -> brucemig.pcbook.LaptopUpdated
+	2,  // 12: brucemig.pcbook.Event.laptop_deleted:type_name -> brucemig.pcbook.LaptopDeleted
+	3,  // 13: brucemig.pcbook.Event.image_uploaded:type_name -> brucemig.pcbook.ImageUploaded
+	6,  // 14: brucemig.pcbook.Event.laptop_rated:type_name -> brucemig.pcbook.LaptopRated
+	4,  // 15: brucemig.pcbook.Event.image_deleted:type_name -> brucemig.pcbook.ImageDeleted
+	5,  // 16: brucemig.pcbook.Event.gallery_changed:type_name -> brucemig.pcbook.GalleryChanged
+	8,  // 17: brucemig.pcbook.Event.review_submitted:type_name -> brucemig.pcbook.ReviewSubmitted
+	9,  // 18: brucemig.pcbook.Event.review_moderated:type_name -> brucemig.pcbook.ReviewModerated
+	10, // 19: brucemig.pcbook.Event.rating_quarantined:type_name -> brucemig.pcbook.RatingQuarantined
+	12, // 20: brucemig.pcbook.Event.quarantined_rating_removed:type_name -> brucemig.pcbook.QuarantinedRatingRemoved
+	7,  // 21: brucemig.pcbook.Event.laptops_rated:type_name -> brucemig.pcbook.LaptopsRated
+	11, // 22: brucemig.pcbook.Event.ratings_quarantined:type_name -> brucemig.pcbook.RatingsQuarantined
+	23, // [23:23] is the sub-list for method output_type
+	23, // [23:23] is the sub-list for method input_type
+	23, // [23:23] is the sub-list for extension type_name
+	23, // [23:23] is the sub-list for extension extendee
+	0,  // [0:23] is the sub-list for field type_name
+}
+
+func init() { file_event_message_proto_init() }
+func file_event_message_proto_init() {
+	if File_event_message_proto != nil {
+		return
+	}
+	file_laptop_message_proto_init()
+	file_rating_message_proto_init()
+	file_review_message_proto_init()
+	if !protoimpl.UnsafeEnabled {
+		file_event_message_proto_msgTypes[0].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*LaptopCreated); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_event_message_proto_msgTypes[1].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*LaptopUpdated); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_event_message_proto_msgTypes[2].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*LaptopDeleted); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_event_message_proto_msgTypes[3].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*ImageUploaded); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_event_message_proto_msgTypes[4].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*ImageDeleted); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_event_message_proto_msgTypes[5].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*GalleryChanged); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_event_message_proto_msgTypes[6].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*LaptopRated); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_event_message_proto_msgTypes[7].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*LaptopsRated); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_event_message_proto_msgTypes[8].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*ReviewSubmitted); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_event_message_proto_msgTypes[9].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*ReviewModerated); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_event_message_proto_msgTypes[10].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*RatingQuarantined); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_event_message_proto_msgTypes[11].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*RatingsQuarantined); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_event_message_proto_msgTypes[12].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*QuarantinedRatingRemoved); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_event_message_proto_msgTypes[13].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*Event); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+	}
+	file_event_message_proto_msgTypes[13].OneofWrappers = []interface{}{
+		(*Event_LaptopCreated)(nil),
+		(*Event_LaptopUpdated)(nil),
+		(*Event_LaptopDeleted)(nil),
+		(*Event_ImageUploaded)(nil),
+		(*Event_LaptopRated)(nil),
+		(*Event_ImageDeleted)(nil),
+		(*Event_GalleryChanged)(nil),
+		(*Event_ReviewSubmitted)(nil),
+		(*Event_ReviewModerated)(nil),
+		(*Event_RatingQuarantined)(nil),
+		(*Event_QuarantinedRatingRemoved)(nil),
+		(*Event_LaptopsRated)(nil),
+		(*Event_RatingsQuarantined)(nil),
+	}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: file_event_message_proto_rawDesc,
+			NumEnums:      0,
+			NumMessages:   14,
+			NumExtensions: 0,
+			NumServices:   0,
+		},
+		GoTypes:           file_event_message_proto_goTypes,
+		DependencyIndexes: file_event_message_proto_depIdxs,
+		MessageInfos:      file_event_message_proto_msgTypes,
+	}.Build()
+	File_event_message_proto = out.File
+	file_event_message_proto_rawDesc = nil
+	file_event_message_proto_goTypes = nil
+	file_event_message_proto_depIdxs = nil
+}
@@ -19,10 +19,33 @@ import (
 const _ = grpc.SupportPackageIsVersion7
 
 const (
-	LaptopService_CreateLaptop_FullMethodName = "/brucemig.pcbook.LaptopService/CreateLaptop"
-	LaptopService_SearchLaptop_FullMethodName = "/brucemig.pcbook.LaptopService/SearchLaptop"
-	LaptopService_UploadImage_FullMethodName  = "/brucemig.pcbook.LaptopService/UploadImage"
-	LaptopService_RateLaptop_FullMethodName   = "/brucemig.pcbook.LaptopService/RateLaptop"
+	LaptopService_CreateLaptop_FullMethodName             = "/brucemig.pcbook.LaptopService/CreateLaptop"
+	LaptopService_GetLaptop_FullMethodName                = "/brucemig.pcbook.LaptopService/GetLaptop"
+	LaptopService_SearchLaptop_FullMethodName             = "/brucemig.pcbook.LaptopService/SearchLaptop"
+	LaptopService_UploadImage_FullMethodName              = "/brucemig.pcbook.LaptopService/UploadImage"
+	LaptopService_DownloadImage_FullMethodName            = "/brucemig.pcbook.LaptopService/DownloadImage"
+	LaptopService_ListImages_FullMethodName               = "/brucemig.pcbook.LaptopService/ListImages"
+	LaptopService_DeleteImage_FullMethodName              = "/brucemig.pcbook.LaptopService/DeleteImage"
+	LaptopService_SetPrimaryImage_FullMethodName          = "/brucemig.pcbook.LaptopService/SetPrimaryImage"
+	LaptopService_ReorderImages_FullMethodName            = "/brucemig.pcbook.LaptopService/ReorderImages"
+	LaptopService_StartUpload_FullMethodName              = "/brucemig.pcbook.LaptopService/StartUpload"
+	LaptopService_UploadChunk_FullMethodName              = "/brucemig.pcbook.LaptopService/UploadChunk"
+	LaptopService_GetUploadStatus_FullMethodName          = "/brucemig.pcbook.LaptopService/GetUploadStatus"
+	LaptopService_CompleteUpload_FullMethodName           = "/brucemig.pcbook.LaptopService/CompleteUpload"
+	LaptopService_RateLaptop_FullMethodName               = "/brucemig.pcbook.LaptopService/RateLaptop"
+	LaptopService_RateLaptops_FullMethodName              = "/brucemig.pcbook.LaptopService/RateLaptops"
+	LaptopService_GetRating_FullMethodName                = "/brucemig.pcbook.LaptopService/GetRating"
+	LaptopService_GetTopRatedLaptops_FullMethodName       = "/brucemig.pcbook.LaptopService/GetTopRatedLaptops"
+	LaptopService_GetMyRatings_FullMethodName             = "/brucemig.pcbook.LaptopService/GetMyRatings"
+	LaptopService_WatchRatings_FullMethodName             = "/brucemig.pcbook.LaptopService/WatchRatings"
+	LaptopService_ListQuarantinedRatings_FullMethodName   = "/brucemig.pcbook.LaptopService/ListQuarantinedRatings"
+	LaptopService_ReleaseQuarantinedRating_FullMethodName = "/brucemig.pcbook.LaptopService/ReleaseQuarantinedRating"
+	LaptopService_DiscardQuarantinedRating_FullMethodName = "/brucemig.pcbook.LaptopService/DiscardQuarantinedRating"
+	LaptopService_SubmitReview_FullMethodName             = "/brucemig.pcbook.LaptopService/SubmitReview"
+	LaptopService_ListReviews_FullMethodName              = "/brucemig.pcbook.LaptopService/ListReviews"
+	LaptopService_ListPendingReviews_FullMethodName       = "/brucemig.pcbook.LaptopService/ListPendingReviews"
+	LaptopService_ApproveReview_FullMethodName            = "/brucemig.pcbook.LaptopService/ApproveReview"
+	LaptopService_RejectReview_FullMethodName             = "/brucemig.pcbook.LaptopService/RejectReview"
 )
 
 // LaptopServiceClient is the client API for LaptopService service.
@@ -30,9 +53,34 @@ const (
 // For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
 type LaptopServiceClient interface {
 	CreateLaptop(ctx context.Context, in *CreateLaptopRequest, opts ...grpc.CallOption) (*CreateLaptopResponse, error)
+	// GetLaptop is declared before SearchLaptop, so that the gateway matches
+	// the /v1/laptop/search path before the /v1/laptop/{id} pattern
+	GetLaptop(ctx context.Context, in *GetLaptopRequest, opts ...grpc.CallOption) (*GetLaptopResponse, error)
 	SearchLaptop(ctx context.Context, in *SearchLaptopRequest, opts ...grpc.CallOption) (LaptopService_SearchLaptopClient, error)
 	UploadImage(ctx context.Context, opts ...grpc.CallOption) (LaptopService_UploadImageClient, error)
+	DownloadImage(ctx context.Context, in *DownloadImageRequest, opts ...grpc.CallOption) (LaptopService_DownloadImageClient, error)
+	ListImages(ctx context.Context, in *ListImagesRequest, opts ...grpc.CallOption) (*ListImagesResponse, error)
+	DeleteImage(ctx context.Context, in *DeleteImageRequest, opts ...grpc.CallOption) (*DeleteImageResponse, error)
+	SetPrimaryImage(ctx context.Context, in *SetPrimaryImageRequest, opts ...grpc.CallOption) (*SetPrimaryImageResponse, error)
+	ReorderImages(ctx context.Context, in *ReorderImagesRequest, opts ...grpc.CallOption) (*ReorderImagesResponse, error)
+	StartUpload(ctx context.Context, in *StartUploadRequest, opts ...grpc.CallOption) (*StartUploadResponse, error)
+	UploadChunk(ctx context.Context, in *UploadChunkRequest, opts ...grpc.CallOption) (*UploadChunkResponse, error)
+	GetUploadStatus(ctx context.Context, in *GetUploadStatusRequest, opts ...grpc.CallOption) (*GetUploadStatusResponse, error)
+	CompleteUpload(ctx context.Context, in *CompleteUploadRequest, opts ...grpc.CallOption) (*CompleteUploadResponse, error)
 	RateLaptop(ctx context.Context, opts ...grpc.CallOption) (LaptopService_RateLaptopClient, error)
+	RateLaptops(ctx context.Context, in *RateLaptopsRequest, opts ...grpc.CallOption) (*RateLaptopsResponse, error)
+	GetRating(ctx context.Context, in *GetRatingRequest, opts ...grpc.CallOption) (*GetRatingResponse, error)
+	GetTopRatedLaptops(ctx context.Context, in *GetTopRatedLaptopsRequest, opts ...grpc.CallOption) (*GetTopRatedLaptopsResponse, error)
+	GetMyRatings(ctx context.Context, in *GetMyRatingsRequest, opts ...grpc.CallOption) (*GetMyRatingsResponse, error)
+	WatchRatings(ctx context.Context, in *WatchRatingsRequest, opts ...grpc.CallOption) (LaptopService_WatchRatingsClient, error)
+	ListQuarantinedRatings(ctx context.Context, in *ListQuarantinedRatingsRequest, opts ...grpc.CallOption) (*ListQuarantinedRatingsResponse, error)
+	ReleaseQuarantinedRating(ctx context.Context, in *ReleaseQuarantinedRatingRequest, opts ...grpc.CallOption) (*ReleaseQuarantinedRatingResponse, error)
+	DiscardQuarantinedRating(ctx context.Context, in *DiscardQuarantinedRatingRequest, opts ...grpc.CallOption) (*DiscardQuarantinedRatingResponse, error)
+	SubmitReview(ctx context.Context, in *SubmitReviewRequest, opts ...grpc.CallOption) (*SubmitReviewResponse, error)
+	ListReviews(ctx context.Context, in *ListReviewsRequest, opts ...grpc.CallOption) (*ListReviewsResponse, error)
+	ListPendingReviews(ctx context.Context, in *ListPendingReviewsRequest, opts ...grpc.CallOption) (*ListPendingReviewsResponse, error)
+	ApproveReview(ctx context.Context, in *ApproveReviewRequest, opts ...grpc.CallOption) (*ApproveReviewResponse, error)
+	RejectReview(ctx context.Context, in *RejectReviewRequest, opts ...grpc.CallOption) (*RejectReviewResponse, error)
 }
 
 type laptopServiceClient struct {
@@ -52,6 +100,15 @@ func (c *laptopServiceClient) CreateLaptop(ctx context.Context, in *CreateLaptop
 	return out, nil
 }
 
+func (c *laptopServiceClient) GetLaptop(ctx context.Context, in *GetLaptopRequest, opts ...grpc.CallOption) (*GetLaptopResponse, error) {
+	out := new(GetLaptopResponse)
+	err := c.cc.Invoke(ctx, LaptopService_GetLaptop_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 func (c *laptopServiceClient) SearchLaptop(ctx context.Context, in *SearchLaptopRequest, opts ...grpc.CallOption) (LaptopService_SearchLaptopClient, error) {
 	stream, err := c.cc.NewStream(ctx, &LaptopService_ServiceDesc.Streams[0], LaptopService_SearchLaptop_FullMethodName, opts...)
 	if err != nil {
@@ -118,8 +175,112 @@ func (x *laptopServiceUploadImageClient) CloseAndRecv() (*UploadImageResponse, e
 	return m, nil
 }
 
+func (c *laptopServiceClient) DownloadImage(ctx context.Context, in *DownloadImageRequest, opts ...grpc.CallOption) (LaptopService_DownloadImageClient, error) {
+	stream, err := c.cc.NewStream(ctx, &LaptopService_ServiceDesc.Streams[2], LaptopService_DownloadImage_FullMethodName, opts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &laptopServiceDownloadImageClient{stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+type LaptopService_DownloadImageClient interface {
+	Recv() (*DownloadImageResponse, error)
+	grpc.ClientStream
+}
+
+type laptopServiceDownloadImageClient struct {
+	grpc.ClientStream
+}
+
+func (x *laptopServiceDownloadImageClient) Recv() (*DownloadImageResponse, error) {
+	m := new(DownloadImageResponse)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+func (c *laptopServiceClient) ListImages(ctx context.Context, in *ListImagesRequest, opts ...grpc.CallOption) (*ListImagesResponse, error) {
+	out := new(ListImagesResponse)
+	err := c.cc.Invoke(ctx, LaptopService_ListImages_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *laptopServiceClient) DeleteImage(ctx context.Context, in *DeleteImageRequest, opts ...grpc.CallOption) (*DeleteImageResponse, error) {
+	out := new(DeleteImageResponse)
+	err := c.cc.Invoke(ctx, LaptopService_DeleteImage_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *laptopServiceClient) SetPrimaryImage(ctx context.Context, in *SetPrimaryImageRequest, opts ...grpc.CallOption) (*SetPrimaryImageResponse, error) {
+	out := new(SetPrimaryImageResponse)
+	err := c.cc.Invoke(ctx, LaptopService_SetPrimaryImage_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *laptopServiceClient) ReorderImages(ctx context.Context, in *ReorderImagesRequest, opts ...grpc.CallOption) (*ReorderImagesResponse, error) {
+	out := new(ReorderImagesResponse)
+	err := c.cc.Invoke(ctx, LaptopService_ReorderImages_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *laptopServiceClient) StartUpload(ctx context.Context, in *StartUploadRequest, opts ...grpc.CallOption) (*StartUploadResponse, error) {
+	out := new(StartUploadResponse)
+	err := c.cc.Invoke(ctx, LaptopService_StartUpload_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *laptopServiceClient) UploadChunk(ctx context.Context, in *UploadChunkRequest, opts ...grpc.CallOption) (*UploadChunkResponse, error) {
+	out := new(UploadChunkResponse)
+	err := c.cc.Invoke(ctx, LaptopService_UploadChunk_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *laptopServiceClient) GetUploadStatus(ctx context.Context, in *GetUploadStatusRequest, opts ...grpc.CallOption) (*GetUploadStatusResponse, error) {
+	out := new(GetUploadStatusResponse)
+	err := c.cc.Invoke(ctx, LaptopService_GetUploadStatus_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *laptopServiceClient) CompleteUpload(ctx context.Context, in *CompleteUploadRequest, opts ...grpc.CallOption) (*CompleteUploadResponse, error) {
+	out := new(CompleteUploadResponse)
+	err := c.cc.Invoke(ctx, LaptopService_CompleteUpload_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 func (c *laptopServiceClient) RateLaptop(ctx context.Context, opts ...grpc.CallOption) (LaptopService_RateLaptopClient, error) {
-	stream, err := c.cc.NewStream(ctx, &LaptopService_ServiceDesc.Streams[2], LaptopService_RateLaptop_FullMethodName, opts...)
+	stream, err := c.cc.NewStream(ctx, &LaptopService_ServiceDesc.Streams[3], LaptopService_RateLaptop_FullMethodName, opts...)
 	if err != nil {
 		return nil, err
 	}
@@ -149,14 +310,179 @@ func (x *laptopServiceRateLaptopClient) Recv() (*RateLaptopResponse, error) {
 	return m, nil
 }
 
+func (c *laptopServiceClient) RateLaptops(ctx context.Context, in *RateLaptopsRequest, opts ...grpc.CallOption) (*RateLaptopsResponse, error) {
+	out := new(RateLaptopsResponse)
+	err := c.cc.Invoke(ctx, LaptopService_RateLaptops_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *laptopServiceClient) GetRating(ctx context.Context, in *GetRatingRequest, opts ...grpc.CallOption) (*GetRatingResponse, error) {
+	out := new(GetRatingResponse)
+	err := c.cc.Invoke(ctx, LaptopService_GetRating_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *laptopServiceClient) GetTopRatedLaptops(ctx context.Context, in *GetTopRatedLaptopsRequest, opts ...grpc.CallOption) (*GetTopRatedLaptopsResponse, error) {
+	out := new(GetTopRatedLaptopsResponse)
+	err := c.cc.Invoke(ctx, LaptopService_GetTopRatedLaptops_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *laptopServiceClient) GetMyRatings(ctx context.Context, in *GetMyRatingsRequest, opts ...grpc.CallOption) (*GetMyRatingsResponse, error) {
+	out := new(GetMyRatingsResponse)
+	err := c.cc.Invoke(ctx, LaptopService_GetMyRatings_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *laptopServiceClient) WatchRatings(ctx context.Context, in *WatchRatingsRequest, opts ...grpc.CallOption) (LaptopService_WatchRatingsClient, error) {
+	stream, err := c.cc.NewStream(ctx, &LaptopService_ServiceDesc.Streams[4], LaptopService_WatchRatings_FullMethodName, opts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &laptopServiceWatchRatingsClient{stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+type LaptopService_WatchRatingsClient interface {
+	Recv() (*WatchRatingsResponse, error)
+	grpc.ClientStream
+}
+
+type laptopServiceWatchRatingsClient struct {
+	grpc.ClientStream
+}
+
+func (x *laptopServiceWatchRatingsClient) Recv() (*WatchRatingsResponse, error) {
+	m := new(WatchRatingsResponse)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+func (c *laptopServiceClient) ListQuarantinedRatings(ctx context.Context, in *ListQuarantinedRatingsRequest, opts ...grpc.CallOption) (*ListQuarantinedRatingsResponse, error) {
+	out := new(ListQuarantinedRatingsResponse)
+	err := c.cc.Invoke(ctx, LaptopService_ListQuarantinedRatings_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *laptopServiceClient) ReleaseQuarantinedRating(ctx context.Context, in *ReleaseQuarantinedRatingRequest, opts ...grpc.CallOption) (*ReleaseQuarantinedRatingResponse, error) {
+	out := new(ReleaseQuarantinedRatingResponse)
+	err := c.cc.Invoke(ctx, LaptopService_ReleaseQuarantinedRating_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *laptopServiceClient) DiscardQuarantinedRating(ctx context.Context, in *DiscardQuarantinedRatingRequest, opts ...grpc.CallOption) (*DiscardQuarantinedRatingResponse, error) {
+	out := new(DiscardQuarantinedRatingResponse)
+	err := c.cc.Invoke(ctx, LaptopService_DiscardQuarantinedRating_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *laptopServiceClient) SubmitReview(ctx context.Context, in *SubmitReviewRequest, opts ...grpc.CallOption) (*SubmitReviewResponse, error) {
+	out := new(SubmitReviewResponse)
+	err := c.cc.Invoke(ctx, LaptopService_SubmitReview_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *laptopServiceClient) ListReviews(ctx context.Context, in *ListReviewsRequest, opts ...grpc.CallOption) (*ListReviewsResponse, error) {
+	out := new(ListReviewsResponse)
+	err := c.cc.Invoke(ctx, LaptopService_ListReviews_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *laptopServiceClient) ListPendingReviews(ctx context.Context, in *ListPendingReviewsRequest, opts ...grpc.CallOption) (*ListPendingReviewsResponse, error) {
+	out := new(ListPendingReviewsResponse)
+	err := c.cc.Invoke(ctx, LaptopService_ListPendingReviews_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *laptopServiceClient) ApproveReview(ctx context.Context, in *ApproveReviewRequest, opts ...grpc.CallOption) (*ApproveReviewResponse, error) {
+	out := new(ApproveReviewResponse)
+	err := c.cc.Invoke(ctx, LaptopService_ApproveReview_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *laptopServiceClient) RejectReview(ctx context.Context, in *RejectReviewRequest, opts ...grpc.CallOption) (*RejectReviewResponse, error) {
+	out := new(RejectReviewResponse)
+	err := c.cc.Invoke(ctx, LaptopService_RejectReview_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 // LaptopServiceServer is the server API for LaptopService service.
 // All implementations must embed UnimplementedLaptopServiceServer
 // for forward compatibility
 type LaptopServiceServer interface {
 	CreateLaptop(context.Context, *CreateLaptopRequest) (*CreateLaptopResponse, error)
+	// GetLaptop is declared before SearchLaptop, so that the gateway matches
+	// the /v1/laptop/search path before the /v1/laptop/{id} pattern
+	GetLaptop(context.Context, *GetLaptopRequest) (*GetLaptopResponse, error)
 	SearchLaptop(*SearchLaptopRequest, LaptopService_SearchLaptopServer) error
 	UploadImage(LaptopService_UploadImageServer) error
+	DownloadImage(*DownloadImageRequest, LaptopService_DownloadImageServer) error
+	ListImages(context.Context, *ListImagesRequest) (*ListImagesResponse, error)
+	DeleteImage(context.Context, *DeleteImageRequest) (*DeleteImageResponse, error)
+	SetPrimaryImage(context.Context, *SetPrimaryImageRequest) (*SetPrimaryImageResponse, error)
+	ReorderImages(context.Context, *ReorderImagesRequest) (*ReorderImagesResponse, error)
+	StartUpload(context.Context, *StartUploadRequest) (*StartUploadResponse, error)
+	UploadChunk(context.Context, *UploadChunkRequest) (*UploadChunkResponse, error)
+	GetUploadStatus(context.Context, *GetUploadStatusRequest) (*GetUploadStatusResponse, error)
+	CompleteUpload(context.Context, *CompleteUploadRequest) (*CompleteUploadResponse, error)
 	RateLaptop(LaptopService_RateLaptopServer) error
+	RateLaptops(context.Context, *RateLaptopsRequest) (*RateLaptopsResponse, error)
+	GetRating(context.Context, *GetRatingRequest) (*GetRatingResponse, error)
+	GetTopRatedLaptops(context.Context, *GetTopRatedLaptopsRequest) (*GetTopRatedLaptopsResponse, error)
+	GetMyRatings(context.Context, *GetMyRatingsRequest) (*GetMyRatingsResponse, error)
+	WatchRatings(*WatchRatingsRequest, LaptopService_WatchRatingsServer) error
+	ListQuarantinedRatings(context.Context, *ListQuarantinedRatingsRequest) (*ListQuarantinedRatingsResponse, error)
+	ReleaseQuarantinedRating(context.Context, *ReleaseQuarantinedRatingRequest) (*ReleaseQuarantinedRatingResponse, error)
+	DiscardQuarantinedRating(context.Context, *DiscardQuarantinedRatingRequest) (*DiscardQuarantinedRatingResponse, error)
+	SubmitReview(context.Context, *SubmitReviewRequest) (*SubmitReviewResponse, error)
+	ListReviews(context.Context, *ListReviewsRequest) (*ListReviewsResponse, error)
+	ListPendingReviews(context.Context, *ListPendingReviewsRequest) (*ListPendingReviewsResponse, error)
+	ApproveReview(context.Context, *ApproveReviewRequest) (*ApproveReviewResponse, error)
+	RejectReview(context.Context, *RejectReviewRequest) (*RejectReviewResponse, error)
 	mustEmbedUnimplementedLaptopServiceServer()
 }
 
@@ -167,15 +493,84 @@ type UnimplementedLaptopServiceServer struct {
 func (UnimplementedLaptopServiceServer) CreateLaptop(context.Context, *CreateLaptopRequest) (*CreateLaptopResponse, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method CreateLaptop not implemented")
 }
+func (UnimplementedLaptopServiceServer) GetLaptop(context.Context, *GetLaptopRequest) (*GetLaptopResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetLaptop not implemented")
+}
 func (UnimplementedLaptopServiceServer) SearchLaptop(*SearchLaptopRequest, LaptopService_SearchLaptopServer) error {
 	return status.Errorf(codes.Unimplemented, "method SearchLaptop not implemented")
 }
 func (UnimplementedLaptopServiceServer) UploadImage(LaptopService_UploadImageServer) error {
 	return status.Errorf(codes.Unimplemented, "method UploadImage not implemented")
 }
+func (UnimplementedLaptopServiceServer) DownloadImage(*DownloadImageRequest, LaptopService_DownloadImageServer) error {
+	return status.Errorf(codes.Unimplemented, "method DownloadImage not implemented")
+}
+func (UnimplementedLaptopServiceServer) ListImages(context.Context, *ListImagesRequest) (*ListImagesResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ListImages not implemented")
+}
+func (UnimplementedLaptopServiceServer) DeleteImage(context.Context, *DeleteImageRequest) (*DeleteImageResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method DeleteImage not implemented")
+}
+func (UnimplementedLaptopServiceServer) SetPrimaryImage(context.Context, *SetPrimaryImageRequest) (*SetPrimaryImageResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method SetPrimaryImage not implemented")
+}
+func (UnimplementedLaptopServiceServer) ReorderImages(context.Context, *ReorderImagesRequest) (*ReorderImagesResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ReorderImages not implemented")
+}
+func (UnimplementedLaptopServiceServer) StartUpload(context.Context, *StartUploadRequest) (*StartUploadResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method StartUpload not implemented")
+}
+func (UnimplementedLaptopServiceServer) UploadChunk(context.Context, *UploadChunkRequest) (*UploadChunkResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method UploadChunk not implemented")
+}
+func (UnimplementedLaptopServiceServer) GetUploadStatus(context.Context, *GetUploadStatusRequest) (*GetUploadStatusResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetUploadStatus not implemented")
+}
+func (UnimplementedLaptopServiceServer) CompleteUpload(context.Context, *CompleteUploadRequest) (*CompleteUploadResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method CompleteUpload not implemented")
+}
 func (UnimplementedLaptopServiceServer) RateLaptop(LaptopService_RateLaptopServer) error {
 	return status.Errorf(codes.Unimplemented, "method RateLaptop not implemented")
 }
+func (UnimplementedLaptopServiceServer) RateLaptops(context.Context, *RateLaptopsRequest) (*RateLaptopsResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method RateLaptops not implemented")
+}
+func (UnimplementedLaptopServiceServer) GetRating(context.Context, *GetRatingRequest) (*GetRatingResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetRating not implemented")
+}
+func (UnimplementedLaptopServiceServer) GetTopRatedLaptops(context.Context, *GetTopRatedLaptopsRequest) (*GetTopRatedLaptopsResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetTopRatedLaptops not implemented")
+}
+func (UnimplementedLaptopServiceServer) GetMyRatings(context.Context, *GetMyRatingsRequest) (*GetMyRatingsResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetMyRatings not implemented")
+}
+func (UnimplementedLaptopServiceServer) WatchRatings(*WatchRatingsRequest, LaptopService_WatchRatingsServer) error {
+	return status.Errorf(codes.Unimplemented, "method WatchRatings not implemented")
+}
+func (UnimplementedLaptopServiceServer) ListQuarantinedRatings(context.Context, *ListQuarantinedRatingsRequest) (*ListQuarantinedRatingsResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ListQuarantinedRatings not implemented")
+}
+func (UnimplementedLaptopServiceServer) ReleaseQuarantinedRating(context.Context, *ReleaseQuarantinedRatingRequest) (*ReleaseQuarantinedRatingResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ReleaseQuarantinedRating not implemented")
+}
+func (UnimplementedLaptopServiceServer) DiscardQuarantinedRating(context.Context, *DiscardQuarantinedRatingRequest) (*DiscardQuarantinedRatingResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method DiscardQuarantinedRating not implemented")
+}
+func (UnimplementedLaptopServiceServer) SubmitReview(context.Context, *SubmitReviewRequest) (*SubmitReviewResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method SubmitReview not implemented")
+}
+func (UnimplementedLaptopServiceServer) ListReviews(context.Context, *ListReviewsRequest) (*ListReviewsResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ListReviews not implemented")
+}
+func (UnimplementedLaptopServiceServer) ListPendingReviews(context.Context, *ListPendingReviewsRequest) (*ListPendingReviewsResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ListPendingReviews not implemented")
+}
+func (UnimplementedLaptopServiceServer) ApproveReview(context.Context, *ApproveReviewRequest) (*ApproveReviewResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ApproveReview not implemented")
+}
+func (UnimplementedLaptopServiceServer) RejectReview(context.Context, *RejectReviewRequest) (*RejectReviewResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method RejectReview not implemented")
+}
 func (UnimplementedLaptopServiceServer) mustEmbedUnimplementedLaptopServiceServer() {}
 
 // UnsafeLaptopServiceServer may be embedded to opt out of forward compatibility for this service.
@@ -207,6 +602,24 @@ func _LaptopService_CreateLaptop_Handler(srv interface{}, ctx context.Context, d
 	return interceptor(ctx, in, info, handler)
 }
 
+func _LaptopService_GetLaptop_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetLaptopRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(LaptopServiceServer).GetLaptop(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: LaptopService_GetLaptop_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(LaptopServiceServer).GetLaptop(ctx, req.(*GetLaptopRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
 func _LaptopService_SearchLaptop_Handler(srv interface{}, stream grpc.ServerStream) error {
 	m := new(SearchLaptopRequest)
 	if err := stream.RecvMsg(m); err != nil {
@@ -254,61 +667,557 @@ func (x *laptopServiceUploadImageServer) Recv() (*UploadImageRequest, error) {
 	return m, nil
 }
 
-func _LaptopService_RateLaptop_Handler(srv interface{}, stream grpc.ServerStream) error {
-	return srv.(LaptopServiceServer).RateLaptop(&laptopServiceRateLaptopServer{stream})
+func _LaptopService_DownloadImage_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(DownloadImageRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(LaptopServiceServer).DownloadImage(m, &laptopServiceDownloadImageServer{stream})
 }
 
-type LaptopService_RateLaptopServer interface {
-	Send(*RateLaptopResponse) error
-	Recv() (*RateLaptopRequest, error)
+type LaptopService_DownloadImageServer interface {
+	Send(*DownloadImageResponse) error
 	grpc.ServerStream
 }
 
-type laptopServiceRateLaptopServer struct {
+type laptopServiceDownloadImageServer struct {
 	grpc.ServerStream
 }
 
-func (x *laptopServiceRateLaptopServer) Send(m *RateLaptopResponse) error {
+func (x *laptopServiceDownloadImageServer) Send(m *DownloadImageResponse) error {
 	return x.ServerStream.SendMsg(m)
 }
 
-func (x *laptopServiceRateLaptopServer) Recv() (*RateLaptopRequest, error) {
-	m := new(RateLaptopRequest)
-	if err := x.ServerStream.RecvMsg(m); err != nil {
+func _LaptopService_ListImages_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListImagesRequest)
+	if err := dec(in); err != nil {
 		return nil, err
 	}
-	return m, nil
+	if interceptor == nil {
+		return srv.(LaptopServiceServer).ListImages(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: LaptopService_ListImages_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(LaptopServiceServer).ListImages(ctx, req.(*ListImagesRequest))
+	}
+	return interceptor(ctx, in, info, handler)
 }
 
-// LaptopService_ServiceDesc is the grpc.ServiceDesc for LaptopService service.
-// It's only intended for direct use with grpc.RegisterService,
-// and not to be introspected or modified (even as a copy)
-var LaptopService_ServiceDesc = grpc.ServiceDesc{
-	ServiceName: "brucemig.pcbook.LaptopService",
-	HandlerType: (*LaptopServiceServer)(nil),
-	Methods: []grpc.MethodDesc{
-		{
-			MethodName: "CreateLaptop",
-			Handler:    _LaptopService_CreateLaptop_Handler,
-		},
-	},
-	Streams: []grpc.StreamDesc{
-		{
-			StreamName:    "SearchLaptop",
-			Handler:       _LaptopService_SearchLaptop_Handler,
-			ServerStreams: true,
-		},
-		{
-			StreamName:    "UploadImage",
-			Handler:       _LaptopService_UploadImage_Handler,
-			ClientStreams: true,
-		},
+func _LaptopService_DeleteImage_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(DeleteImageRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(LaptopServiceServer).DeleteImage(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: LaptopService_DeleteImage_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(LaptopServiceServer).DeleteImage(ctx, req.(*DeleteImageRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _LaptopService_SetPrimaryImage_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(SetPrimaryImageRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(LaptopServiceServer).SetPrimaryImage(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: LaptopService_SetPrimaryImage_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(LaptopServiceServer).SetPrimaryImage(ctx, req.(*SetPrimaryImageRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _LaptopService_ReorderImages_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ReorderImagesRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(LaptopServiceServer).ReorderImages(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: LaptopService_ReorderImages_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(LaptopServiceServer).ReorderImages(ctx, req.(*ReorderImagesRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _LaptopService_StartUpload_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(StartUploadRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(LaptopServiceServer).StartUpload(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: LaptopService_StartUpload_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(LaptopServiceServer).StartUpload(ctx, req.(*StartUploadRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _LaptopService_UploadChunk_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(UploadChunkRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(LaptopServiceServer).UploadChunk(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: LaptopService_UploadChunk_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(LaptopServiceServer).UploadChunk(ctx, req.(*UploadChunkRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _LaptopService_GetUploadStatus_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetUploadStatusRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(LaptopServiceServer).GetUploadStatus(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: LaptopService_GetUploadStatus_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(LaptopServiceServer).GetUploadStatus(ctx, req.(*GetUploadStatusRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _LaptopService_CompleteUpload_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(CompleteUploadRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(LaptopServiceServer).CompleteUpload(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: LaptopService_CompleteUpload_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(LaptopServiceServer).CompleteUpload(ctx, req.(*CompleteUploadRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _LaptopService_RateLaptop_Handler(srv interface{}, stream grpc.ServerStream) error {
+	return srv.(LaptopServiceServer).RateLaptop(&laptopServiceRateLaptopServer{stream})
+}
+
+type LaptopService_RateLaptopServer interface {
+	Send(*RateLaptopResponse) error
+	Recv() (*RateLaptopRequest, error)
+	grpc.ServerStream
+}
+
+type laptopServiceRateLaptopServer struct {
+	grpc.ServerStream
+}
+
+func (x *laptopServiceRateLaptopServer) Send(m *RateLaptopResponse) error {
+	return x.ServerStream.SendMsg(m)
+}
+
+func (x *laptopServiceRateLaptopServer) Recv() (*RateLaptopRequest, error) {
+	m := new(RateLaptopRequest)
+	if err := x.ServerStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+func _LaptopService_RateLaptops_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(RateLaptopsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(LaptopServiceServer).RateLaptops(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: LaptopService_RateLaptops_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(LaptopServiceServer).RateLaptops(ctx, req.(*RateLaptopsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _LaptopService_GetRating_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetRatingRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(LaptopServiceServer).GetRating(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: LaptopService_GetRating_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(LaptopServiceServer).GetRating(ctx, req.(*GetRatingRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _LaptopService_GetTopRatedLaptops_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetTopRatedLaptopsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(LaptopServiceServer).GetTopRatedLaptops(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: LaptopService_GetTopRatedLaptops_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(LaptopServiceServer).GetTopRatedLaptops(ctx, req.(*GetTopRatedLaptopsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _LaptopService_GetMyRatings_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetMyRatingsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(LaptopServiceServer).GetMyRatings(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: LaptopService_GetMyRatings_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(LaptopServiceServer).GetMyRatings(ctx, req.(*GetMyRatingsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _LaptopService_WatchRatings_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(WatchRatingsRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(LaptopServiceServer).WatchRatings(m, &laptopServiceWatchRatingsServer{stream})
+}
+
+type LaptopService_WatchRatingsServer interface {
+	Send(*WatchRatingsResponse) error
+	grpc.ServerStream
+}
+
+type laptopServiceWatchRatingsServer struct {
+	grpc.ServerStream
+}
+
+func (x *laptopServiceWatchRatingsServer) Send(m *WatchRatingsResponse) error {
+	return x.ServerStream.SendMsg(m)
+}
+
+func _LaptopService_ListQuarantinedRatings_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListQuarantinedRatingsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(LaptopServiceServer).ListQuarantinedRatings(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: LaptopService_ListQuarantinedRatings_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(LaptopServiceServer).ListQuarantinedRatings(ctx, req.(*ListQuarantinedRatingsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _LaptopService_ReleaseQuarantinedRating_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ReleaseQuarantinedRatingRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(LaptopServiceServer).ReleaseQuarantinedRating(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: LaptopService_ReleaseQuarantinedRating_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(LaptopServiceServer).ReleaseQuarantinedRating(ctx, req.(*ReleaseQuarantinedRatingRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _LaptopService_DiscardQuarantinedRating_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(DiscardQuarantinedRatingRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(LaptopServiceServer).DiscardQuarantinedRating(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: LaptopService_DiscardQuarantinedRating_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(LaptopServiceServer).DiscardQuarantinedRating(ctx, req.(*DiscardQuarantinedRatingRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _LaptopService_SubmitReview_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(SubmitReviewRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(LaptopServiceServer).SubmitReview(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: LaptopService_SubmitReview_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(LaptopServiceServer).SubmitReview(ctx, req.(*SubmitReviewRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _LaptopService_ListReviews_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListReviewsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(LaptopServiceServer).ListReviews(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: LaptopService_ListReviews_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(LaptopServiceServer).ListReviews(ctx, req.(*ListReviewsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _LaptopService_ListPendingReviews_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListPendingReviewsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(LaptopServiceServer).ListPendingReviews(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: LaptopService_ListPendingReviews_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(LaptopServiceServer).ListPendingReviews(ctx, req.(*ListPendingReviewsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _LaptopService_ApproveReview_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ApproveReviewRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(LaptopServiceServer).ApproveReview(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: LaptopService_ApproveReview_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(LaptopServiceServer).ApproveReview(ctx, req.(*ApproveReviewRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _LaptopService_RejectReview_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(RejectReviewRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(LaptopServiceServer).RejectReview(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: LaptopService_RejectReview_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(LaptopServiceServer).RejectReview(ctx, req.(*RejectReviewRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+// LaptopService_ServiceDesc is the grpc.ServiceDesc for LaptopService service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var LaptopService_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "brucemig.pcbook.LaptopService",
+	HandlerType: (*LaptopServiceServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "CreateLaptop",
+			Handler:    _LaptopService_CreateLaptop_Handler,
+		},
+		{
+			MethodName: "GetLaptop",
+			Handler:    _LaptopService_GetLaptop_Handler,
+		},
+		{
+			MethodName: "ListImages",
+			Handler:    _LaptopService_ListImages_Handler,
+		},
+		{
+			MethodName: "DeleteImage",
+			Handler:    _LaptopService_DeleteImage_Handler,
+		},
+		{
+			MethodName: "SetPrimaryImage",
+			Handler:    _LaptopService_SetPrimaryImage_Handler,
+		},
+		{
+			MethodName: "ReorderImages",
+			Handler:    _LaptopService_ReorderImages_Handler,
+		},
+		{
+			MethodName: "StartUpload",
+			Handler:    _LaptopService_StartUpload_Handler,
+		},
+		{
+			MethodName: "UploadChunk",
+			Handler:    _LaptopService_UploadChunk_Handler,
+		},
+		{
+			MethodName: "GetUploadStatus",
+			Handler:    _LaptopService_GetUploadStatus_Handler,
+		},
+		{
+			MethodName: "CompleteUpload",
+			Handler:    _LaptopService_CompleteUpload_Handler,
+		},
+		{
+			MethodName: "RateLaptops",
+			Handler:    _LaptopService_RateLaptops_Handler,
+		},
+		{
+			MethodName: "GetRating",
+			Handler:    _LaptopService_GetRating_Handler,
+		},
+		{
+			MethodName: "GetTopRatedLaptops",
+			Handler:    _LaptopService_GetTopRatedLaptops_Handler,
+		},
+		{
+			MethodName: "GetMyRatings",
+			Handler:    _LaptopService_GetMyRatings_Handler,
+		},
+		{
+			MethodName: "ListQuarantinedRatings",
+			Handler:    _LaptopService_ListQuarantinedRatings_Handler,
+		},
+		{
+			MethodName: "ReleaseQuarantinedRating",
+			Handler:    _LaptopService_ReleaseQuarantinedRating_Handler,
+		},
+		{
+			MethodName: "DiscardQuarantinedRating",
+			Handler:    _LaptopService_DiscardQuarantinedRating_Handler,
+		},
+		{
+			MethodName: "SubmitReview",
+			Handler:    _LaptopService_SubmitReview_Handler,
+		},
+		{
+			MethodName: "ListReviews",
+			Handler:    _LaptopService_ListReviews_Handler,
+		},
+		{
+			MethodName: "ListPendingReviews",
+			Handler:    _LaptopService_ListPendingReviews_Handler,
+		},
+		{
+			MethodName: "ApproveReview",
+			Handler:    _LaptopService_ApproveReview_Handler,
+		},
+		{
+			MethodName: "RejectReview",
+			Handler:    _LaptopService_RejectReview_Handler,
+		},
+	},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "SearchLaptop",
+			Handler:       _LaptopService_SearchLaptop_Handler,
+			ServerStreams: true,
+		},
+		{
+			StreamName:    "UploadImage",
+			Handler:       _LaptopService_UploadImage_Handler,
+			ClientStreams: true,
+		},
+		{
+			StreamName:    "DownloadImage",
+			Handler:       _LaptopService_DownloadImage_Handler,
+			ServerStreams: true,
+		},
 		{
 			StreamName:    "RateLaptop",
 			Handler:       _LaptopService_RateLaptop_Handler,
 			ServerStreams: true,
 			ClientStreams: true,
 		},
+		{
+			StreamName:    "WatchRatings",
+			Handler:       _LaptopService_WatchRatings_Handler,
+			ServerStreams: true,
+		},
 	},
 	Metadata: "laptop_service.proto",
 }
@@ -1,6 +1,8 @@
 package sample
 
 import (
+	"math"
+
 	"gitlab.com/brucemig/pcbook/pb"
 	"google.golang.org/protobuf/types/known/timestamppb"
 )
@@ -130,5 +132,6 @@ func NewLaptop() *pb.Laptop {
 // }
 
 func RandomLaptopScore() float64 {
-	return randomFloat64(1, 10)
+	// the server accepts scores with one decimal
+	return math.Round(randomFloat64(1, 10)*10) / 10
 }
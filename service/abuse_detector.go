@@ -0,0 +1,217 @@
+package service
+
+import (
+	"fmt"
+	"sync"
+	"time"
+)
+
+// AbuseDetector flags the suspicious votes, which are quarantined instead of counted
+type AbuseDetector interface {
+	// Check records the votes of a batch that are about to be counted together,
+	// and returns why the batch is suspicious, or an empty string if it isn't.
+	// A batch is flagged as a whole, so that its votes are all counted or all quarantined.
+	Check(votes ...*Vote) string
+}
+
+// AbuseThresholds are the limits of the velocity statistics over which a VelocityAbuseDetector flags a vote.
+// A zero limit disables its check.
+type AbuseThresholds struct {
+	// Window is the duration of the sliding window of the statistics
+	Window time.Duration
+	// MaxUserVotes is the maximum number of votes of a user in the window
+	MaxUserVotes int
+	// MaxUserExtremeVotes is the maximum number of extreme votes of a user in the window,
+	// with a score at or beyond MinScore or MaxScore
+	MaxUserExtremeVotes int
+	MinScore            float64
+	MaxScore            float64
+	// MaxIdenticalScores is the maximum number of votes of a laptop with the same score in the window,
+	// as coordinated users give the same score
+	MaxIdenticalScores int
+}
+
+// DefaultAbuseThresholds are the default thresholds of a VelocityAbuseDetector.
+// A user can send a full batch of ratings, which is flagged as a whole.
+var DefaultAbuseThresholds = AbuseThresholds{
+	Window:              10 * time.Minute,
+	MaxUserVotes:        maxRatingBatchSize,
+	MaxUserExtremeVotes: 10,
+	MinScore:            MinScore,
+	MaxScore:            MaxScore,
+	MaxIdenticalScores:  20,
+}
+
+// VelocityAbuseDetector flags the votes that exceed the velocity statistics of their user or laptop
+// in a sliding window. The anonymous votes are only checked against the statistics of their laptop.
+// The flagged votes are counted in the statistics too, so a burst stays flagged until it slows down.
+// The statistics are spread over stripes by username and by laptop ID, each with its own lock,
+// so that the votes of different users and laptops are checked concurrently.
+type VelocityAbuseDetector struct {
+	thresholds    AbuseThresholds
+	userStripes   [defaultShardCount]userVelocityStripe
+	laptopStripes [defaultShardCount]laptopVelocityStripe
+}
+
+// userVelocityStripe holds the statistics of the users of a stripe
+type userVelocityStripe struct {
+	mutex sync.Mutex
+	// votes and extremeVotes are the times of the votes of each user in the window, oldest first
+	votes        map[string][]time.Time
+	extremeVotes map[string][]time.Time
+	// lastSweep is the time when the statistics of all users of the stripe were last trimmed to the window
+	lastSweep time.Time
+}
+
+// laptopVelocityStripe holds the statistics of the laptops of a stripe
+type laptopVelocityStripe struct {
+	mutex sync.Mutex
+	// scores are the times of the votes of each laptop in the window, by score, oldest first
+	scores map[string]map[float64][]time.Time
+	// lastSweep is the time when the statistics of all laptops of the stripe were last trimmed to the window
+	lastSweep time.Time
+}
+
+// NewVelocityAbuseDetector returns a new VelocityAbuseDetector with the given thresholds
+func NewVelocityAbuseDetector(thresholds AbuseThresholds) *VelocityAbuseDetector {
+	detector := &VelocityAbuseDetector{thresholds: thresholds}
+	for i := range detector.userStripes {
+		detector.userStripes[i].votes = make(map[string][]time.Time)
+		detector.userStripes[i].extremeVotes = make(map[string][]time.Time)
+		detector.laptopStripes[i].scores = make(map[string]map[float64][]time.Time)
+	}
+	return detector
+}
+
+// Check records the votes of a batch and returns why the batch is suspicious, or an empty string.
+// The statistics of all the votes are recorded, and the first exceeded threshold is reported.
+func (detector *VelocityAbuseDetector) Check(votes ...*Vote) string {
+	reason := ""
+	for _, vote := range votes {
+		now := vote.Time
+		if now.IsZero() {
+			now = time.Now()
+		}
+
+		// every statistic is recorded, and the first exceeded threshold is reported
+		var reasons []string
+		if vote.Username != "" {
+			reasons = append(reasons, detector.checkUser(vote, now)...)
+		}
+		reasons = append(reasons, detector.checkLaptop(vote, now)...)
+
+		if reason == "" && len(reasons) > 0 {
+			reason = reasons[0]
+		}
+	}
+	return reason
+}
+
+// checkUser records a vote in the statistics of its user, and returns the exceeded thresholds
+func (detector *VelocityAbuseDetector) checkUser(vote *Vote, now time.Time) []string {
+	start := now.Add(-detector.thresholds.Window)
+	stripe := &detector.userStripes[shardIndex(vote.Username, defaultShardCount)]
+	stripe.mutex.Lock()
+	defer stripe.mutex.Unlock()
+
+	// the statistics of the users that stop voting are dropped once per window
+	if now.Sub(stripe.lastSweep) > detector.thresholds.Window {
+		stripe.sweep(start)
+		stripe.lastSweep = now
+	}
+
+	var reasons []string
+	votes := append(trimTimes(stripe.votes[vote.Username], start), now)
+	stripe.votes[vote.Username] = votes
+	if exceeds(len(votes), detector.thresholds.MaxUserVotes) {
+		reasons = append(reasons, fmt.Sprintf(
+			"user %s gave %d scores in %v", vote.Username, len(votes), detector.thresholds.Window,
+		))
+	}
+
+	if vote.Score <= detector.thresholds.MinScore || vote.Score >= detector.thresholds.MaxScore {
+		votes := append(trimTimes(stripe.extremeVotes[vote.Username], start), now)
+		stripe.extremeVotes[vote.Username] = votes
+		if exceeds(len(votes), detector.thresholds.MaxUserExtremeVotes) {
+			reasons = append(reasons, fmt.Sprintf(
+				"user %s gave %d extreme scores in %v", vote.Username, len(votes), detector.thresholds.Window,
+			))
+		}
+	}
+	return reasons
+}
+
+// checkLaptop records a vote in the statistics of its laptop, and returns the exceeded thresholds
+func (detector *VelocityAbuseDetector) checkLaptop(vote *Vote, now time.Time) []string {
+	start := now.Add(-detector.thresholds.Window)
+	stripe := &detector.laptopStripes[shardIndex(vote.LaptopID, defaultShardCount)]
+	stripe.mutex.Lock()
+	defer stripe.mutex.Unlock()
+
+	// the statistics of the laptops that stop getting votes are dropped once per window
+	if now.Sub(stripe.lastSweep) > detector.thresholds.Window {
+		stripe.sweep(start)
+		stripe.lastSweep = now
+	}
+
+	scores := stripe.scores[vote.LaptopID]
+	if scores == nil {
+		scores = make(map[float64][]time.Time)
+		stripe.scores[vote.LaptopID] = scores
+	}
+	votes := append(trimTimes(scores[vote.Score], start), now)
+	scores[vote.Score] = votes
+	if exceeds(len(votes), detector.thresholds.MaxIdenticalScores) {
+		return []string{fmt.Sprintf(
+			"laptop %s got %d identical scores of %v in %v", vote.LaptopID, len(votes), vote.Score, detector.thresholds.Window,
+		)}
+	}
+	return nil
+}
+
+// sweep trims the statistics of the users of the stripe to the window that starts at the given time,
+// and drops the empty ones. The caller must hold the stripe mutex.
+func (stripe *userVelocityStripe) sweep(start time.Time) {
+	for _, votes := range []map[string][]time.Time{stripe.votes, stripe.extremeVotes} {
+		for username, times := range votes {
+			times = trimTimes(times, start)
+			if len(times) == 0 {
+				delete(votes, username)
+			} else {
+				votes[username] = times
+			}
+		}
+	}
+}
+
+// sweep trims the statistics of the laptops of the stripe to the window that starts at the given time,
+// and drops the empty ones. The caller must hold the stripe mutex.
+func (stripe *laptopVelocityStripe) sweep(start time.Time) {
+	for laptopID, scores := range stripe.scores {
+		for score, times := range scores {
+			times = trimTimes(times, start)
+			if len(times) == 0 {
+				delete(scores, score)
+			} else {
+				scores[score] = times
+			}
+		}
+		if len(scores) == 0 {
+			delete(stripe.scores, laptopID)
+		}
+	}
+}
+
+// trimTimes drops the times before the start of the window from the beginning of the times
+func trimTimes(times []time.Time, start time.Time) []time.Time {
+	i := 0
+	for i < len(times) && !times[i].After(start) {
+		i++
+	}
+	return times[i:]
+}
+
+// exceeds tells whether a count exceeds a limit, where a zero limit is no limit
+func exceeds(count int, limit int) bool {
+	return limit > 0 && count > limit
+}
@@ -0,0 +1,120 @@
+package service_test
+
+import (
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gitlab.com/brucemig/pcbook/service"
+)
+
+func TestVelocityAbuseDetector(t *testing.T) {
+	t.Parallel()
+
+	start := time.Unix(1000, 0)
+	at := func(seconds int) time.Time {
+		return start.Add(time.Duration(seconds) * time.Second)
+	}
+
+	t.Run("user_votes", func(t *testing.T) {
+		t.Parallel()
+
+		detector := service.NewVelocityAbuseDetector(service.AbuseThresholds{Window: time.Minute, MaxUserVotes: 3})
+		for i := 0; i < 3; i++ {
+			vote := &service.Vote{LaptopID: fmt.Sprintf("laptop%d", i), Username: "user1", Score: 5, Time: at(i)}
+			require.Empty(t, detector.Check(vote))
+		}
+
+		require.NotEmpty(t, detector.Check(&service.Vote{LaptopID: "laptop3", Username: "user1", Score: 5, Time: at(3)}))
+		require.Empty(t, detector.Check(&service.Vote{LaptopID: "laptop3", Username: "user2", Score: 5, Time: at(3)}))
+
+		// the anonymous votes have no user statistics
+		for i := 0; i < 5; i++ {
+			require.Empty(t, detector.Check(&service.Vote{LaptopID: "laptop4", Score: float64(i + 1), Time: at(i)}))
+		}
+
+		// the votes leave the statistics at the end of the window
+		require.Empty(t, detector.Check(&service.Vote{LaptopID: "laptop4", Username: "user1", Score: 5, Time: at(62)}))
+	})
+
+	t.Run("extreme_votes", func(t *testing.T) {
+		t.Parallel()
+
+		detector := service.NewVelocityAbuseDetector(service.AbuseThresholds{
+			Window:              time.Minute,
+			MaxUserExtremeVotes: 2,
+			MinScore:            1,
+			MaxScore:            10,
+		})
+		require.Empty(t, detector.Check(&service.Vote{LaptopID: "laptop1", Username: "user1", Score: 10, Time: at(0)}))
+		require.Empty(t, detector.Check(&service.Vote{LaptopID: "laptop2", Username: "user1", Score: 5, Time: at(1)}))
+		require.Empty(t, detector.Check(&service.Vote{LaptopID: "laptop3", Username: "user1", Score: 1, Time: at(2)}))
+
+		reason := detector.Check(&service.Vote{LaptopID: "laptop4", Username: "user1", Score: 10, Time: at(3)})
+		require.Contains(t, reason, "extreme")
+		require.Empty(t, detector.Check(&service.Vote{LaptopID: "laptop5", Username: "user1", Score: 7, Time: at(4)}))
+	})
+
+	t.Run("batch", func(t *testing.T) {
+		t.Parallel()
+
+		detector := service.NewVelocityAbuseDetector(service.AbuseThresholds{Window: time.Minute, MaxUserVotes: 3})
+		batch := func(username string, count int, seconds int) []*service.Vote {
+			votes := make([]*service.Vote, count)
+			for i := range votes {
+				votes[i] = &service.Vote{LaptopID: fmt.Sprintf("laptop%d", i), Username: username, Score: 5, Time: at(seconds)}
+			}
+			return votes
+		}
+
+		require.Empty(t, detector.Check(batch("user1", 3, 0)...))
+		require.Empty(t, detector.Check())
+
+		// a batch is flagged as a whole when any of its votes exceeds a threshold, and all its votes are recorded
+		require.Contains(t, detector.Check(batch("user2", 5, 0)...), "user2 gave 4 scores")
+		require.NotEmpty(t, detector.Check(batch("user2", 1, 1)...))
+		require.Empty(t, detector.Check(batch("user2", 1, 61)...))
+	})
+
+	t.Run("concurrent", func(t *testing.T) {
+		t.Parallel()
+
+		// the users and laptops are checked concurrently, and each keeps its own statistics
+		detector := service.NewVelocityAbuseDetector(service.AbuseThresholds{Window: time.Minute, MaxUserVotes: 50, MaxIdenticalScores: 50})
+		var wg sync.WaitGroup
+		for i := 0; i < 8; i++ {
+			wg.Add(1)
+			go func(i int) {
+				defer wg.Done()
+				for j := 0; j < 50; j++ {
+					vote := &service.Vote{LaptopID: fmt.Sprintf("laptop%d", i), Username: fmt.Sprintf("user%d", i), Score: 5, Time: at(j)}
+					assert.Empty(t, detector.Check(vote))
+				}
+			}(i)
+		}
+		wg.Wait()
+
+		for i := 0; i < 8; i++ {
+			vote := &service.Vote{LaptopID: fmt.Sprintf("laptop%d", i), Username: fmt.Sprintf("user%d", i), Score: 5, Time: at(50)}
+			require.NotEmpty(t, detector.Check(vote))
+		}
+	})
+
+	t.Run("identical_scores", func(t *testing.T) {
+		t.Parallel()
+
+		detector := service.NewVelocityAbuseDetector(service.AbuseThresholds{Window: time.Minute, MaxIdenticalScores: 3})
+		for i := 0; i < 3; i++ {
+			vote := &service.Vote{LaptopID: "laptop1", Username: fmt.Sprintf("user%d", i), Score: 2.5, Time: at(i)}
+			require.Empty(t, detector.Check(vote))
+		}
+
+		reason := detector.Check(&service.Vote{LaptopID: "laptop1", Username: "user3", Score: 2.5, Time: at(3)})
+		require.Contains(t, reason, "identical")
+		require.Empty(t, detector.Check(&service.Vote{LaptopID: "laptop1", Username: "user4", Score: 3, Time: at(4)}))
+		require.Empty(t, detector.Check(&service.Vote{LaptopID: "laptop2", Username: "user5", Score: 2.5, Time: at(5)}))
+	})
+}
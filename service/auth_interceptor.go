@@ -29,12 +29,12 @@ func (interceptor *AuthInterceptor) Unary() grpc.UnaryServerInterceptor {
 		handler grpc.UnaryHandler) (any, error) {
 		log.Println("--> unary interceptor: ", info.FullMethod)
 
-		err := interceptor.authorize(ctx, info.FullMethod)
+		claims, err := interceptor.authorize(ctx, info.FullMethod)
 		if err != nil {
 			return nil, err
 		}
 
-		return handler(ctx, req)
+		return handler(contextWithClaims(ctx, claims), req)
 	}
 }
 
@@ -47,25 +47,29 @@ func (interceptor *AuthInterceptor) Stream() grpc.StreamServerInterceptor {
 		handler grpc.StreamHandler) error {
 		log.Println("--> stream interceptor: ", info.FullMethod)
 
-		err := interceptor.authorize(stream.Context(), info.FullMethod)
+		claims, err := interceptor.authorize(stream.Context(), info.FullMethod)
 		if err != nil {
 			return err
 		}
 
-		return handler(srv, stream)
+		return handler(srv, &authorizedServerStream{
+			ServerStream: stream,
+			ctx:          contextWithClaims(stream.Context(), claims),
+		})
 	}
 }
 
-func (interceptor *AuthInterceptor) authorize(ctx context.Context, method string) error {
+// authorize returns the claims of the access token if the method requires one
+func (interceptor *AuthInterceptor) authorize(ctx context.Context, method string) (*UserClaims, error) {
 	accessibleRoles, ok := interceptor.accessibleRoles[method]
 	if !ok {
 		// everyone can access
-		return nil
+		return nil, nil
 	}
 
 	md, ok := metadata.FromIncomingContext(ctx)
 	if !ok {
-		return status.Errorf(
+		return nil, status.Errorf(
 			codes.Unauthenticated,
 			"metadata is not provided",
 		)
@@ -73,7 +77,7 @@ func (interceptor *AuthInterceptor) authorize(ctx context.Context, method string
 
 	values := md["authorization"]
 	if len(values) == 0 {
-		return status.Errorf(
+		return nil, status.Errorf(
 			codes.Unauthenticated,
 			"authorization token is not provided",
 		)
@@ -82,7 +86,7 @@ func (interceptor *AuthInterceptor) authorize(ctx context.Context, method string
 	accessToken := values[0]
 	claims, err := interceptor.jwtManager.Verify(accessToken)
 	if err != nil {
-		return status.Errorf(
+		return nil, status.Errorf(
 			codes.Unauthenticated,
 			"access token is invalid: %v", err,
 		)
@@ -90,12 +94,38 @@ func (interceptor *AuthInterceptor) authorize(ctx context.Context, method string
 
 	for _, role := range accessibleRoles {
 		if role == claims.Role {
-			return nil
+			return claims, nil
 		}
 	}
 
-	return status.Errorf(
+	return nil, status.Errorf(
 		codes.PermissionDenied,
 		"no permission to access this RPC",
 	)
 }
+
+// authorizedServerStream is a server stream whose context carries the user claims
+type authorizedServerStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+// Context returns the context of the stream
+func (stream *authorizedServerStream) Context() context.Context {
+	return stream.ctx
+}
+
+type claimsKey struct{}
+
+func contextWithClaims(ctx context.Context, claims *UserClaims) context.Context {
+	if claims == nil {
+		return ctx
+	}
+	return context.WithValue(ctx, claimsKey{}, claims)
+}
+
+// ClaimsFromContext returns the claims of the authenticated user of an RPC, if any
+func ClaimsFromContext(ctx context.Context) (*UserClaims, bool) {
+	claims, ok := ctx.Value(claimsKey{}).(*UserClaims)
+	return claims, ok
+}
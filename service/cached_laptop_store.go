@@ -0,0 +1,158 @@
+package service
+
+import (
+	"container/list"
+	"context"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"gitlab.com/brucemig/pcbook/pb"
+)
+
+// CachedLaptopStore is a read-through cache in front of another laptop store.
+// It keeps the most recently found laptops in a bounded LRU list, each for at most ttl.
+type CachedLaptopStore struct {
+	store    LaptopStore
+	capacity int
+	ttl      time.Duration
+
+	mutex      sync.Mutex
+	entries    map[string]*list.Element
+	lru        *list.List
+	generation uint64
+
+	hits   atomic.Uint64
+	misses atomic.Uint64
+}
+
+type cacheEntry struct {
+	laptop    *pb.Laptop
+	expiresAt time.Time
+}
+
+// NewCachedLaptopStore returns a new CachedLaptopStore that caches up to capacity laptops of the store
+func NewCachedLaptopStore(store LaptopStore, capacity int, ttl time.Duration) *CachedLaptopStore {
+	return &CachedLaptopStore{
+		store:    store,
+		capacity: capacity,
+		ttl:      ttl,
+		entries:  make(map[string]*list.Element),
+		lru:      list.New(),
+	}
+}
+
+// Save saves the laptop to the underlying store
+func (cache *CachedLaptopStore) Save(laptop *pb.Laptop) error {
+	defer cache.invalidate(laptop.GetId())
+	return cache.store.Save(laptop)
+}
+
+// Update updates the laptop in the underlying store and drops its cached copy
+func (cache *CachedLaptopStore) Update(laptop *pb.Laptop) error {
+	defer cache.invalidate(laptop.GetId())
+	return cache.store.Update(laptop)
+}
+
+// Delete deletes the laptop from the underlying store and drops its cached copy
+func (cache *CachedLaptopStore) Delete(id string) error {
+	defer cache.invalidate(id)
+	return cache.store.Delete(id)
+}
+
+// Find finds a laptop by ID, from the cache if possible
+func (cache *CachedLaptopStore) Find(id string) (*pb.Laptop, error) {
+	cache.mutex.Lock()
+	element := cache.entries[id]
+	if element != nil {
+		entry := element.Value.(*cacheEntry)
+		if time.Now().Before(entry.expiresAt) {
+			cache.lru.MoveToFront(element)
+			cache.mutex.Unlock()
+
+			cache.hits.Add(1)
+			return deepCopy(entry.laptop), nil
+		}
+		cache.remove(element)
+	}
+	generation := cache.generation
+	cache.mutex.Unlock()
+
+	cache.misses.Add(1)
+
+	laptop, err := cache.store.Find(id)
+	if err != nil || laptop == nil {
+		return laptop, err
+	}
+
+	other := deepCopy(laptop)
+
+	cache.mutex.Lock()
+	defer cache.mutex.Unlock()
+
+	// the laptop might have been changed while we were reading it from the store
+	if generation == cache.generation && cache.entries[id] == nil {
+		cache.add(other)
+	}
+
+	return laptop, nil
+}
+
+// Search searches for laptops in the underlying store
+func (cache *CachedLaptopStore) Search(
+	ctx context.Context,
+	filter *pb.Filter,
+	found func(laptop *pb.Laptop) error,
+) error {
+	return cache.store.Search(ctx, filter, found)
+}
+
+// Hits returns the number of Find calls served from the cache
+func (cache *CachedLaptopStore) Hits() uint64 {
+	return cache.hits.Load()
+}
+
+// Misses returns the number of Find calls that went to the underlying store
+func (cache *CachedLaptopStore) Misses() uint64 {
+	return cache.misses.Load()
+}
+
+// Len returns the number of laptops in the cache
+func (cache *CachedLaptopStore) Len() int {
+	cache.mutex.Lock()
+	defer cache.mutex.Unlock()
+
+	return cache.lru.Len()
+}
+
+func (cache *CachedLaptopStore) invalidate(id string) {
+	cache.mutex.Lock()
+	defer cache.mutex.Unlock()
+
+	cache.generation++
+	element := cache.entries[id]
+	if element != nil {
+		cache.remove(element)
+	}
+}
+
+func (cache *CachedLaptopStore) add(laptop *pb.Laptop) {
+	if cache.capacity <= 0 {
+		return
+	}
+
+	for cache.lru.Len() >= cache.capacity {
+		cache.remove(cache.lru.Back())
+	}
+
+	entry := &cacheEntry{
+		laptop:    laptop,
+		expiresAt: time.Now().Add(cache.ttl),
+	}
+	cache.entries[laptop.GetId()] = cache.lru.PushFront(entry)
+}
+
+func (cache *CachedLaptopStore) remove(element *list.Element) {
+	entry := cache.lru.Remove(element).(*cacheEntry)
+	delete(cache.entries, entry.laptop.GetId())
+}
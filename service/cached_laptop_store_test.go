@@ -0,0 +1,74 @@
+package service_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"gitlab.com/brucemig/pcbook/pb"
+	"gitlab.com/brucemig/pcbook/sample"
+	"gitlab.com/brucemig/pcbook/service"
+)
+
+func TestCachedLaptopStoreFind(t *testing.T) {
+	t.Parallel()
+
+	store := service.NewCachedLaptopStore(service.NewInMemoryLaptopStore(), 2, time.Minute)
+
+	laptops := make([]*pb.Laptop, 3)
+	for i := range laptops {
+		laptops[i] = sample.NewLaptop()
+		require.NoError(t, store.Save(laptops[i]))
+	}
+
+	requireFind(t, store, laptops[0])
+	requireFind(t, store, laptops[0])
+	require.Equal(t, uint64(1), store.Hits())
+	require.Equal(t, uint64(1), store.Misses())
+
+	// the least recently used laptop is evicted
+	requireFind(t, store, laptops[1])
+	requireFind(t, store, laptops[2])
+	require.Equal(t, 2, store.Len())
+	requireFind(t, store, laptops[0])
+	require.Equal(t, uint64(1), store.Hits())
+	require.Equal(t, uint64(4), store.Misses())
+
+	// updating a laptop drops its cached copy
+	laptops[0].PriceUsd = 1234
+	require.NoError(t, store.Update(laptops[0]))
+	requireFind(t, store, laptops[0])
+	require.Equal(t, uint64(5), store.Misses())
+
+	// deleting a laptop drops its cached copy
+	require.NoError(t, store.Delete(laptops[0].GetId()))
+	found, err := store.Find(laptops[0].GetId())
+	require.NoError(t, err)
+	require.Nil(t, found)
+}
+
+func TestCachedLaptopStoreTTL(t *testing.T) {
+	t.Parallel()
+
+	store := service.NewCachedLaptopStore(service.NewInMemoryLaptopStore(), 2, 10*time.Millisecond)
+
+	laptop := sample.NewLaptop()
+	require.NoError(t, store.Save(laptop))
+
+	requireFind(t, store, laptop)
+	requireFind(t, store, laptop)
+	require.Equal(t, uint64(1), store.Hits())
+
+	time.Sleep(20 * time.Millisecond)
+
+	requireFind(t, store, laptop)
+	require.Equal(t, uint64(1), store.Hits())
+	require.Equal(t, uint64(2), store.Misses())
+}
+
+func requireFind(t *testing.T, store service.LaptopStore, expected *pb.Laptop) {
+	found, err := store.Find(expected.GetId())
+	require.NoError(t, err)
+	require.NotNil(t, found)
+	requireSameLaptop(t, expected, found)
+}
@@ -0,0 +1,718 @@
+package service
+
+import (
+	"fmt"
+	"io"
+	"slices"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"gitlab.com/brucemig/pcbook/pb"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// Catalog is an event-sourced laptop catalog.
+// Every change is appended to the event log before it is applied to the laptop and rating projections,
+// so the current state can always be rebuilt by replaying the log.
+type Catalog struct {
+	mutex        sync.Mutex
+	eventLog     EventLog
+	laptopStore  *InMemoryLaptopStore
+	ratingStore  *InMemoryRatingStore
+	galleryStore *InMemoryGalleryStore
+	reviewStore  *InMemoryReviewStore
+	quarantine   *InMemoryQuarantineStore
+	images       map[string]*pb.ImageUploaded
+	// imagesSince and galleriesSince are the times of the first ImageUploaded and GalleryChanged events,
+	// since when every saved image is expected to be recorded and in a gallery
+	imagesSince    time.Time
+	galleriesSince time.Time
+	// ratingMutexes serialize the ratings of the laptops of each shard instead of the catalog mutex,
+	// as the ratings of different laptops commute and are appended to the log concurrently
+	ratingMutexes [defaultShardCount]sync.Mutex
+}
+
+// NewCatalog returns a new catalog with its projections rebuilt from the whole event log.
+// The rating options configure the rating projection.
+func NewCatalog(eventLog EventLog, ratingOptions ...RatingStoreOption) (*Catalog, error) {
+	return ReplayCatalog(eventLog, time.Time{}, ratingOptions...)
+}
+
+// ReplayCatalog returns a new catalog with its projections rebuilt from the events
+// that happened until the given time. A zero time replays the whole log.
+func ReplayCatalog(eventLog EventLog, until time.Time, ratingOptions ...RatingStoreOption) (*Catalog, error) {
+	catalog := &Catalog{
+		eventLog:     eventLog,
+		laptopStore:  NewInMemoryLaptopStore(),
+		ratingStore:  NewInMemoryRatingStore(ratingOptions...),
+		galleryStore: NewInMemoryGalleryStore(),
+		reviewStore:  NewInMemoryReviewStore(),
+		quarantine:   NewInMemoryQuarantineStore(),
+		images:       make(map[string]*pb.ImageUploaded),
+	}
+
+	err := eventLog.Replay(until, catalog.apply)
+	if err != nil {
+		return nil, fmt.Errorf("cannot replay event log: %w", err)
+	}
+
+	return catalog, nil
+}
+
+// LaptopStore returns a laptop store backed by the catalog
+func (catalog *Catalog) LaptopStore() LaptopStore {
+	return &eventSourcedLaptopStore{catalog.laptopStore, catalog}
+}
+
+// RatingStore returns a rating store backed by the catalog
+func (catalog *Catalog) RatingStore() RatingStore {
+	return &eventSourcedRatingStore{catalog.ratingStore, catalog}
+}
+
+// ImageStore returns an image store that records every saved image in the catalog
+func (catalog *Catalog) ImageStore(imageStore ImageStore) ImageStore {
+	return &eventSourcedImageStore{imageStore, catalog}
+}
+
+// GalleryStore returns a gallery store backed by the catalog
+func (catalog *Catalog) GalleryStore() GalleryStore {
+	return &eventSourcedGalleryStore{catalog.galleryStore, catalog}
+}
+
+// ReviewStore returns a review store backed by the catalog
+func (catalog *Catalog) ReviewStore() ReviewStore {
+	return &eventSourcedReviewStore{catalog.reviewStore, catalog}
+}
+
+// QuarantineStore returns a quarantine store backed by the catalog
+func (catalog *Catalog) QuarantineStore() QuarantineStore {
+	return &eventSourcedQuarantineStore{catalog.quarantine, catalog}
+}
+
+// Laptops returns all laptops in the catalog, sorted by ID
+func (catalog *Catalog) Laptops() ([]*pb.Laptop, error) {
+	return catalog.laptopStore.all()
+}
+
+// Rating returns the rating of a laptop, or nil if it has never been rated
+func (catalog *Catalog) Rating(laptopID string) (*Rating, error) {
+	return catalog.ratingStore.Find(laptopID)
+}
+
+// Gallery returns the image IDs of a laptop gallery, in order
+func (catalog *Catalog) Gallery(laptopID string) ([]string, error) {
+	return catalog.galleryStore.Images(laptopID)
+}
+
+// Review returns a review by ID, or nil if it doesn't exist
+func (catalog *Catalog) Review(reviewID string) (*Review, error) {
+	return catalog.reviewStore.Find(reviewID)
+}
+
+// Images returns all original images uploaded for a laptop, without their renditions, sorted by image ID
+func (catalog *Catalog) Images(laptopID string) []*pb.ImageUploaded {
+	catalog.mutex.Lock()
+	defer catalog.mutex.Unlock()
+
+	var images []*pb.ImageUploaded
+	for _, image := range catalog.images {
+		if image.GetLaptopId() == laptopID && image.GetOriginalImageId() == "" {
+			images = append(images, image)
+		}
+	}
+
+	sort.Slice(images, func(i, j int) bool {
+		return images[i].GetImageId() < images[j].GetImageId()
+	})
+	return images
+}
+
+// isOrphanImage returns true if an original image was left behind by an upload that failed after saving it:
+// its upload is not recorded, or it is not in the gallery of its laptop.
+// The images saved before the catalog recorded uploads or galleries are never orphans.
+func (catalog *Catalog) isOrphanImage(info *ImageInfo) (bool, error) {
+	catalog.mutex.Lock()
+	defer catalog.mutex.Unlock()
+
+	if catalog.imagesSince.IsZero() || info.UploadedAt.Before(catalog.imagesSince) {
+		return false, nil
+	}
+	if catalog.images[info.ID] == nil {
+		return true, nil
+	}
+
+	if catalog.galleriesSince.IsZero() || info.UploadedAt.Before(catalog.galleriesSince) {
+		return false, nil
+	}
+	gallery, err := catalog.galleryStore.Images(info.LaptopID)
+	if err != nil {
+		return false, err
+	}
+	return !slices.Contains(gallery, info.ID), nil
+}
+
+// ratingMutex returns the mutex that serializes the ratings of a laptop
+func (catalog *Catalog) ratingMutex(laptopID string) *sync.Mutex {
+	return &catalog.ratingMutexes[shardIndex(laptopID, defaultShardCount)]
+}
+
+// record appends a new event to the log and applies it to the projections.
+// The caller must hold the catalog mutex, or the rating mutexes of the laptops for a LaptopRated or LaptopsRated event.
+func (catalog *Catalog) record(event *pb.Event) error {
+	id, err := uuid.NewRandom()
+	if err != nil {
+		return fmt.Errorf("cannot generate event id: %w", err)
+	}
+
+	event.Id = id.String()
+	event.Time = timestamppb.Now()
+
+	err = catalog.eventLog.Append(event)
+	if err != nil {
+		return fmt.Errorf("cannot append event to the log: %w", err)
+	}
+
+	return catalog.apply(event)
+}
+
+// apply applies an event to the projections
+func (catalog *Catalog) apply(event *pb.Event) error {
+	switch payload := event.GetPayload().(type) {
+	case *pb.Event_LaptopCreated:
+		return catalog.laptopStore.Save(payload.LaptopCreated.GetLaptop())
+	case *pb.Event_LaptopUpdated:
+		return catalog.laptopStore.Update(payload.LaptopUpdated.GetLaptop())
+	case *pb.Event_LaptopDeleted:
+		return catalog.laptopStore.Delete(payload.LaptopDeleted.GetLaptopId())
+	case *pb.Event_ImageUploaded:
+		if catalog.imagesSince.IsZero() {
+			catalog.imagesSince = event.GetTime().AsTime()
+		}
+		catalog.images[payload.ImageUploaded.GetImageId()] = payload.ImageUploaded
+		return nil
+	case *pb.Event_ImageDeleted:
+		delete(catalog.images, payload.ImageDeleted.GetImageId())
+		return nil
+	case *pb.Event_GalleryChanged:
+		if catalog.galleriesSince.IsZero() {
+			catalog.galleriesSince = event.GetTime().AsTime()
+		}
+		imageIDs := payload.GalleryChanged.GetImageIds()
+		return catalog.galleryStore.update(payload.GalleryChanged.GetLaptopId(), func([]string) ([]string, error) {
+			return append([]string(nil), imageIDs...), nil
+		})
+	case *pb.Event_LaptopRated:
+		_, err := catalog.ratingStore.Add(fromLaptopRatedMessage(payload.LaptopRated, event))
+		return err
+	case *pb.Event_LaptopsRated:
+		var votes []*Vote
+		for _, rated := range payload.LaptopsRated.GetRatings() {
+			votes = append(votes, fromLaptopRatedMessage(rated, event))
+		}
+		_, err := catalog.ratingStore.AddAll(votes)
+		return err
+	case *pb.Event_ReviewSubmitted:
+		return catalog.reviewStore.Save(fromReviewMessage(payload.ReviewSubmitted.GetReview()))
+	case *pb.Event_ReviewModerated:
+		_, err := catalog.reviewStore.Moderate(
+			payload.ReviewModerated.GetReviewId(),
+			ReviewStatus(payload.ReviewModerated.GetStatus()),
+			payload.ReviewModerated.GetModerator(),
+			payload.ReviewModerated.GetModeratedAt().AsTime(),
+		)
+		return err
+	case *pb.Event_RatingQuarantined:
+		return catalog.quarantine.Add(fromQuarantinedRatingMessage(payload.RatingQuarantined.GetRating()))
+	case *pb.Event_RatingsQuarantined:
+		var votes []*QuarantinedVote
+		for _, rating := range payload.RatingsQuarantined.GetRatings() {
+			votes = append(votes, fromQuarantinedRatingMessage(rating))
+		}
+		return catalog.quarantine.AddAll(votes)
+	case *pb.Event_QuarantinedRatingRemoved:
+		_, err := catalog.quarantine.Remove(payload.QuarantinedRatingRemoved.GetQuarantineId())
+		return err
+	default:
+		return fmt.Errorf("unknown event %s with payload %T", event.GetId(), payload)
+	}
+}
+
+// eventSourcedLaptopStore is a laptop store that records every change in the catalog event log
+type eventSourcedLaptopStore struct {
+	*InMemoryLaptopStore
+	catalog *Catalog
+}
+
+// Save records a LaptopCreated event
+func (store *eventSourcedLaptopStore) Save(laptop *pb.Laptop) error {
+	store.catalog.mutex.Lock()
+	defer store.catalog.mutex.Unlock()
+
+	found, err := store.Find(laptop.GetId())
+	if err != nil {
+		return err
+	}
+	if found != nil {
+		return ErrAlreadyExists
+	}
+
+	return store.catalog.record(&pb.Event{
+		Payload: &pb.Event_LaptopCreated{
+			LaptopCreated: &pb.LaptopCreated{Laptop: laptop},
+		},
+	})
+}
+
+// Update records a LaptopUpdated event
+func (store *eventSourcedLaptopStore) Update(laptop *pb.Laptop) error {
+	store.catalog.mutex.Lock()
+	defer store.catalog.mutex.Unlock()
+
+	found, err := store.Find(laptop.GetId())
+	if err != nil {
+		return err
+	}
+	if found == nil {
+		return ErrNotFound
+	}
+
+	return store.catalog.record(&pb.Event{
+		Payload: &pb.Event_LaptopUpdated{
+			LaptopUpdated: &pb.LaptopUpdated{Laptop: laptop},
+		},
+	})
+}
+
+// Delete records a LaptopDeleted event
+func (store *eventSourcedLaptopStore) Delete(id string) error {
+	store.catalog.mutex.Lock()
+	defer store.catalog.mutex.Unlock()
+
+	found, err := store.Find(id)
+	if err != nil {
+		return err
+	}
+	if found == nil {
+		return ErrNotFound
+	}
+
+	return store.catalog.record(&pb.Event{
+		Payload: &pb.Event_LaptopDeleted{
+			LaptopDeleted: &pb.LaptopDeleted{LaptopId: id},
+		},
+	})
+}
+
+// eventSourcedRatingStore is a rating store that records every vote in the catalog event log
+type eventSourcedRatingStore struct {
+	*InMemoryRatingStore
+	catalog *Catalog
+}
+
+// Add records a LaptopRated event and returns the new rating of the laptop
+func (store *eventSourcedRatingStore) Add(vote *Vote) (*Rating, error) {
+	ratedAt := vote.Time
+	if ratedAt.IsZero() {
+		ratedAt = time.Now()
+	}
+
+	ratingMutex := store.catalog.ratingMutex(vote.LaptopID)
+	ratingMutex.Lock()
+	defer ratingMutex.Unlock()
+
+	err := store.catalog.record(&pb.Event{
+		Payload: &pb.Event_LaptopRated{
+			LaptopRated: toLaptopRatedMessage(vote, ratedAt),
+		},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return store.Find(vote.LaptopID)
+}
+
+// AddIfNewer records a LaptopRated event, unless the user of the vote has already given a more recent vote to the laptop
+func (store *eventSourcedRatingStore) AddIfNewer(vote *Vote) (*Rating, bool, error) {
+	ratedAt := vote.Time
+	if ratedAt.IsZero() {
+		ratedAt = time.Now()
+	}
+
+	ratingMutex := store.catalog.ratingMutex(vote.LaptopID)
+	ratingMutex.Lock()
+	defer ratingMutex.Unlock()
+
+	// the votes of the laptop are only recorded under its rating mutex, so the check holds until the vote is recorded
+	if store.hasNewerVote(vote) {
+		rating, err := store.Find(vote.LaptopID)
+		return rating, false, err
+	}
+
+	err := store.catalog.record(&pb.Event{
+		Payload: &pb.Event_LaptopRated{
+			LaptopRated: toLaptopRatedMessage(vote, ratedAt),
+		},
+	})
+	if err != nil {
+		return nil, false, err
+	}
+
+	rating, err := store.Find(vote.LaptopID)
+	return rating, true, err
+}
+
+// AddAll records a LaptopsRated event with all the votes, and returns the rating of the laptop of each vote after the batch
+func (store *eventSourcedRatingStore) AddAll(votes []*Vote) ([]*Rating, error) {
+	now := time.Now()
+	rated := &pb.LaptopsRated{}
+	for _, vote := range votes {
+		ratedAt := vote.Time
+		if ratedAt.IsZero() {
+			ratedAt = now
+		}
+		rated.Ratings = append(rated.Ratings, toLaptopRatedMessage(vote, ratedAt))
+	}
+
+	// the rating mutexes are locked in order, so that concurrent batches don't deadlock
+	var indexes []int
+	for _, vote := range votes {
+		indexes = append(indexes, shardIndex(vote.LaptopID, defaultShardCount))
+	}
+	slices.Sort(indexes)
+	for _, i := range slices.Compact(indexes) {
+		store.catalog.ratingMutexes[i].Lock()
+		defer store.catalog.ratingMutexes[i].Unlock()
+	}
+
+	err := store.catalog.record(&pb.Event{
+		Payload: &pb.Event_LaptopsRated{LaptopsRated: rated},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	ratings := make([]*Rating, len(votes))
+	for i, vote := range votes {
+		ratings[i], err = store.Find(vote.LaptopID)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return ratings, nil
+}
+
+// toLaptopRatedMessage converts a vote to a laptop rated message with the given time
+func toLaptopRatedMessage(vote *Vote, ratedAt time.Time) *pb.LaptopRated {
+	return &pb.LaptopRated{
+		LaptopId: vote.LaptopID,
+		Score:    vote.Score,
+		Username: vote.Username,
+		RatedAt:  timestamppb.New(ratedAt),
+		ReviewId: vote.ReviewID,
+	}
+}
+
+// fromLaptopRatedMessage converts a laptop rated message of an event to a vote
+func fromLaptopRatedMessage(rated *pb.LaptopRated, event *pb.Event) *Vote {
+	// the events recorded before the scores had a time are timestamped with the time of the event
+	ratedAt := rated.GetRatedAt()
+	if ratedAt == nil {
+		ratedAt = event.GetTime()
+	}
+
+	return &Vote{
+		LaptopID: rated.GetLaptopId(),
+		Username: rated.GetUsername(),
+		Score:    rated.GetScore(),
+		Time:     ratedAt.AsTime(),
+		ReviewID: rated.GetReviewId(),
+	}
+}
+
+// eventSourcedImageStore is an image store that records every saved image in the catalog event log
+type eventSourcedImageStore struct {
+	ImageStore
+	catalog *Catalog
+}
+
+// Save saves the image to the underlying store and records an ImageUploaded event
+func (store *eventSourcedImageStore) Save(info *ImageInfo, imageData io.Reader) (string, error) {
+	imageID, err := store.ImageStore.Save(info, imageData)
+	if err != nil {
+		return "", err
+	}
+
+	saved, err := store.ImageStore.Stat(imageID)
+	if err != nil {
+		return "", err
+	}
+
+	store.catalog.mutex.Lock()
+	defer store.catalog.mutex.Unlock()
+
+	err = store.catalog.record(&pb.Event{
+		Payload: &pb.Event_ImageUploaded{
+			ImageUploaded: &pb.ImageUploaded{
+				ImageId:         imageID,
+				LaptopId:        saved.LaptopID,
+				ImageType:       saved.Type,
+				Size:            uint32(saved.Size),
+				Checksum:        saved.Checksum,
+				Uploader:        saved.Uploader,
+				OriginalImageId: saved.OriginalID,
+				Rendition:       uint32(saved.Rendition),
+			},
+		},
+	})
+	if err != nil {
+		return "", err
+	}
+
+	return imageID, nil
+}
+
+// Delete deletes the image from the underlying store and records an ImageDeleted event
+func (store *eventSourcedImageStore) Delete(imageID string) error {
+	err := store.ImageStore.Delete(imageID)
+	if err != nil {
+		return err
+	}
+
+	store.catalog.mutex.Lock()
+	defer store.catalog.mutex.Unlock()
+
+	return store.catalog.record(&pb.Event{
+		Payload: &pb.Event_ImageDeleted{
+			ImageDeleted: &pb.ImageDeleted{ImageId: imageID},
+		},
+	})
+}
+
+// eventSourcedGalleryStore is a gallery store that records every change in the catalog event log
+type eventSourcedGalleryStore struct {
+	*InMemoryGalleryStore
+	catalog *Catalog
+}
+
+// Add records a GalleryChanged event with the image at the end of the gallery
+func (store *eventSourcedGalleryStore) Add(laptopID string, imageID string, maxImages int) error {
+	return store.change(laptopID, func(images []string) ([]string, error) {
+		return addGalleryImage(images, imageID, maxImages)
+	})
+}
+
+// Remove records a GalleryChanged event without the image
+func (store *eventSourcedGalleryStore) Remove(laptopID string, imageID string) error {
+	return store.change(laptopID, func(images []string) ([]string, error) {
+		return removeGalleryImage(images, imageID)
+	})
+}
+
+// SetPrimary records a GalleryChanged event with the image at the front of the gallery
+func (store *eventSourcedGalleryStore) SetPrimary(laptopID string, imageID string) error {
+	return store.change(laptopID, func(images []string) ([]string, error) {
+		return setPrimaryGalleryImage(images, imageID)
+	})
+}
+
+// Reorder records a GalleryChanged event with the new order of the gallery
+func (store *eventSourcedGalleryStore) Reorder(laptopID string, imageIDs []string) error {
+	return store.change(laptopID, func(images []string) ([]string, error) {
+		return reorderGalleryImages(images, imageIDs)
+	})
+}
+
+// change records a GalleryChanged event with the result of the change function
+func (store *eventSourcedGalleryStore) change(laptopID string, change func(images []string) ([]string, error)) error {
+	store.catalog.mutex.Lock()
+	defer store.catalog.mutex.Unlock()
+
+	images, err := store.Images(laptopID)
+	if err != nil {
+		return err
+	}
+
+	images, err = change(images)
+	if err != nil {
+		return err
+	}
+
+	return store.catalog.record(&pb.Event{
+		Payload: &pb.Event_GalleryChanged{
+			GalleryChanged: &pb.GalleryChanged{LaptopId: laptopID, ImageIds: images},
+		},
+	})
+}
+
+// eventSourcedReviewStore is a review store that records every change in the catalog event log
+type eventSourcedReviewStore struct {
+	*InMemoryReviewStore
+	catalog *Catalog
+}
+
+// Save records a ReviewSubmitted event
+func (store *eventSourcedReviewStore) Save(review *Review) error {
+	store.catalog.mutex.Lock()
+	defer store.catalog.mutex.Unlock()
+
+	found, err := store.Find(review.ID)
+	if err != nil {
+		return err
+	}
+	if found != nil {
+		return ErrAlreadyExists
+	}
+
+	return store.catalog.record(&pb.Event{
+		Payload: &pb.Event_ReviewSubmitted{
+			ReviewSubmitted: &pb.ReviewSubmitted{Review: toReviewMessage(review)},
+		},
+	})
+}
+
+// Moderate records a ReviewModerated event and returns the moderated review
+func (store *eventSourcedReviewStore) Moderate(
+	reviewID string,
+	status ReviewStatus,
+	moderator string,
+	moderatedAt time.Time,
+) (*Review, error) {
+	store.catalog.mutex.Lock()
+	defer store.catalog.mutex.Unlock()
+
+	review, err := store.Find(reviewID)
+	if err != nil {
+		return nil, err
+	}
+	if review == nil {
+		return nil, ErrNotFound
+	}
+	if review.Status != ReviewPending {
+		return nil, ErrAlreadyModerated
+	}
+
+	err = store.catalog.record(&pb.Event{
+		Payload: &pb.Event_ReviewModerated{
+			ReviewModerated: &pb.ReviewModerated{
+				ReviewId:    reviewID,
+				Status:      pb.Review_Status(status),
+				Moderator:   moderator,
+				ModeratedAt: timestamppb.New(moderatedAt),
+			},
+		},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return store.Find(reviewID)
+}
+
+// eventSourcedQuarantineStore is a quarantine store that records every change in the catalog event log
+type eventSourcedQuarantineStore struct {
+	*InMemoryQuarantineStore
+	catalog *Catalog
+}
+
+// Add records a RatingQuarantined event
+func (store *eventSourcedQuarantineStore) Add(vote *QuarantinedVote) error {
+	store.catalog.mutex.Lock()
+	defer store.catalog.mutex.Unlock()
+
+	found, err := store.Find(vote.ID)
+	if err != nil {
+		return err
+	}
+	if found != nil {
+		return ErrAlreadyExists
+	}
+
+	return store.catalog.record(&pb.Event{
+		Payload: &pb.Event_RatingQuarantined{
+			RatingQuarantined: &pb.RatingQuarantined{Rating: toQuarantinedRatingMessage(vote)},
+		},
+	})
+}
+
+// AddAll records a RatingsQuarantined event with all the votes
+func (store *eventSourcedQuarantineStore) AddAll(votes []*QuarantinedVote) error {
+	store.catalog.mutex.Lock()
+	defer store.catalog.mutex.Unlock()
+
+	quarantined := &pb.RatingsQuarantined{}
+	for _, vote := range votes {
+		found, err := store.Find(vote.ID)
+		if err != nil {
+			return err
+		}
+		if found != nil {
+			return ErrAlreadyExists
+		}
+		quarantined.Ratings = append(quarantined.Ratings, toQuarantinedRatingMessage(vote))
+	}
+
+	return store.catalog.record(&pb.Event{
+		Payload: &pb.Event_RatingsQuarantined{RatingsQuarantined: quarantined},
+	})
+}
+
+// Remove records a QuarantinedRatingRemoved event and returns the removed vote
+func (store *eventSourcedQuarantineStore) Remove(quarantineID string) (*QuarantinedVote, error) {
+	store.catalog.mutex.Lock()
+	defer store.catalog.mutex.Unlock()
+
+	vote, err := store.Find(quarantineID)
+	if err != nil {
+		return nil, err
+	}
+	if vote == nil {
+		return nil, ErrNotFound
+	}
+
+	err = store.catalog.record(&pb.Event{
+		Payload: &pb.Event_QuarantinedRatingRemoved{
+			QuarantinedRatingRemoved: &pb.QuarantinedRatingRemoved{QuarantineId: quarantineID},
+		},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return vote, nil
+}
+
+// fromQuarantinedRatingMessage converts a quarantined rating message to a quarantined vote
+func fromQuarantinedRatingMessage(rating *pb.QuarantinedRating) *QuarantinedVote {
+	return &QuarantinedVote{
+		ID: rating.GetId(),
+		Vote: Vote{
+			LaptopID: rating.GetLaptopId(),
+			Username: rating.GetUsername(),
+			Score:    rating.GetScore(),
+			Time:     rating.GetRatedAt().AsTime(),
+		},
+		Reason: rating.GetReason(),
+	}
+}
+
+// fromReviewMessage converts a review message to a review
+func fromReviewMessage(review *pb.Review) *Review {
+	result := &Review{
+		ID:        review.GetId(),
+		LaptopID:  review.GetLaptopId(),
+		Username:  review.GetUsername(),
+		Title:     review.GetTitle(),
+		Body:      review.GetBody(),
+		Score:     review.GetScore(),
+		Status:    ReviewStatus(review.GetStatus()),
+		Moderator: review.GetModerator(),
+		CreatedAt: review.GetCreatedAt().AsTime(),
+	}
+	if review.GetModeratedAt() != nil {
+		result.ModeratedAt = review.GetModeratedAt().AsTime()
+	}
+	return result
+}
@@ -0,0 +1,276 @@
+package service_test
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gitlab.com/brucemig/pcbook/pb"
+	"gitlab.com/brucemig/pcbook/sample"
+	"gitlab.com/brucemig/pcbook/service"
+)
+
+func TestCatalogRatingBatch(t *testing.T) {
+	t.Parallel()
+
+	eventLog, err := service.NewFileEventLog(filepath.Join(t.TempDir(), "events.log"))
+	require.NoError(t, err)
+
+	catalog, err := service.NewCatalog(eventLog)
+	require.NoError(t, err)
+
+	laptopID1 := sample.NewLaptop().GetId()
+	laptopID2 := sample.NewLaptop().GetId()
+	batch := []*service.Vote{
+		{LaptopID: laptopID1, Username: "user1", Score: 8},
+		{LaptopID: laptopID2, Username: "user1", Score: 4},
+		{LaptopID: laptopID1, Username: "user2", Score: 6},
+	}
+
+	// a batch is recorded as a single event
+	_, err = catalog.RatingStore().AddAll(batch)
+	require.NoError(t, err)
+	events := 0
+	require.NoError(t, eventLog.Replay(time.Time{}, func(event *pb.Event) error {
+		events++
+		require.Len(t, event.GetLaptopsRated().GetRatings(), 3)
+		return nil
+	}))
+	require.Equal(t, 1, events)
+
+	replayed, err := service.NewCatalog(eventLog)
+	require.NoError(t, err)
+	rating, err := replayed.Rating(laptopID1)
+	require.NoError(t, err)
+	require.Equal(t, uint32(2), rating.Count)
+	require.Equal(t, 14.0, rating.Sum)
+
+	// a batch that can't be recorded is not counted at all
+	require.NoError(t, eventLog.Close())
+	_, err = catalog.RatingStore().AddAll([]*service.Vote{
+		{LaptopID: laptopID2, Username: "user2", Score: 10},
+		{LaptopID: laptopID1, Username: "user3", Score: 10},
+	})
+	require.Error(t, err)
+
+	rating, err = catalog.Rating(laptopID1)
+	require.NoError(t, err)
+	require.Equal(t, uint32(2), rating.Count)
+	rating, err = catalog.Rating(laptopID2)
+	require.NoError(t, err)
+	require.Equal(t, uint32(1), rating.Count)
+}
+
+func TestCatalogReplayUntilConcurrentRatings(t *testing.T) {
+	t.Parallel()
+
+	eventLog, err := service.NewFileEventLog(filepath.Join(t.TempDir(), "events.log"))
+	require.NoError(t, err)
+	defer eventLog.Close()
+
+	catalog, err := service.NewCatalog(eventLog)
+	require.NoError(t, err)
+
+	// the ratings of different laptops are recorded concurrently, so their events may be out of time order
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			for j := 0; j < 50; j++ {
+				_, err := catalog.RatingStore().Add(&service.Vote{LaptopID: fmt.Sprintf("laptop%d", i), Score: 5})
+				assert.NoError(t, err)
+			}
+		}(i)
+	}
+	wg.Wait()
+
+	var times []time.Time
+	require.NoError(t, eventLog.Replay(time.Time{}, func(event *pb.Event) error {
+		times = append(times, event.GetTime().AsTime())
+		return nil
+	}))
+	require.Len(t, times, 400)
+
+	// a point in time replay counts every event until then, wherever it is in the log
+	until := times[len(times)/2]
+	expected := 0
+	for _, eventTime := range times {
+		if !eventTime.After(until) {
+			expected++
+		}
+	}
+
+	replayed, err := service.ReplayCatalog(eventLog, until)
+	require.NoError(t, err)
+	count := 0
+	for i := 0; i < 8; i++ {
+		rating, err := replayed.Rating(fmt.Sprintf("laptop%d", i))
+		require.NoError(t, err)
+		if rating != nil {
+			count += int(rating.Count)
+		}
+	}
+	require.Equal(t, expected, count)
+}
+
+func TestCatalogReplay(t *testing.T) {
+	t.Parallel()
+
+	eventLog, err := service.NewFileEventLog(filepath.Join(t.TempDir(), "events.log"))
+	require.NoError(t, err)
+	defer eventLog.Close()
+
+	catalog, err := service.NewCatalog(eventLog)
+	require.NoError(t, err)
+
+	laptopStore := catalog.LaptopStore()
+	ratingStore := catalog.RatingStore()
+	imageStore := catalog.ImageStore(newTestDiskImageStore(t))
+
+	laptop1 := sample.NewLaptop()
+	require.NoError(t, laptopStore.Save(laptop1))
+	require.ErrorIs(t, laptopStore.Save(laptop1), service.ErrAlreadyExists)
+
+	_, err = ratingStore.Add(&service.Vote{LaptopID: laptop1.GetId(), Username: "user1", Score: 8})
+	require.NoError(t, err)
+
+	time.Sleep(10 * time.Millisecond)
+	checkpoint := time.Now()
+	time.Sleep(10 * time.Millisecond)
+
+	laptop2 := sample.NewLaptop()
+	require.NoError(t, laptopStore.Save(laptop2))
+
+	laptop1.PriceUsd = 999
+	require.NoError(t, laptopStore.Update(laptop1))
+	require.NoError(t, laptopStore.Delete(laptop2.GetId()))
+	require.ErrorIs(t, laptopStore.Delete(laptop2.GetId()), service.ErrNotFound)
+
+	rating, err := ratingStore.Add(&service.Vote{LaptopID: laptop1.GetId(), Username: "user2", Score: 10})
+	require.NoError(t, err)
+	require.Equal(t, uint32(2), rating.Count)
+	require.Equal(t, 18.0, rating.Sum)
+
+	// the second vote of user1 replaces the first one
+	rating, err = ratingStore.Add(&service.Vote{LaptopID: laptop1.GetId(), Username: "user1", Score: 6})
+	require.NoError(t, err)
+	require.Equal(t, uint32(2), rating.Count)
+	require.Equal(t, 16.0, rating.Sum)
+
+	ratedAt := time.Date(2020, 6, 1, 12, 0, 0, 0, time.UTC)
+	_, err = ratingStore.Add(&service.Vote{LaptopID: laptop1.GetId(), Username: "user3", Score: 4, Time: ratedAt})
+	require.NoError(t, err)
+
+	imageID, err := imageStore.Save(&service.ImageInfo{LaptopID: laptop1.GetId(), Type: ".jpg"}, strings.NewReader("image"))
+	require.NoError(t, err)
+	// the renditions are recorded, but are not images of the laptop
+	_, err = imageStore.Save(&service.ImageInfo{LaptopID: laptop1.GetId(), Type: ".jpg", OriginalID: imageID, Rendition: 128}, strings.NewReader("small"))
+	require.NoError(t, err)
+
+	galleryStore := catalog.GalleryStore()
+	require.NoError(t, galleryStore.Add(laptop1.GetId(), imageID, 10))
+	require.NoError(t, galleryStore.Add(laptop1.GetId(), "other", 10))
+	require.NoError(t, galleryStore.SetPrimary(laptop1.GetId(), "other"))
+
+	reviewStore := catalog.ReviewStore()
+	review := &service.Review{ID: "review1", LaptopID: laptop1.GetId(), Username: "user1", Title: "good", Score: 7, CreatedAt: time.Now()}
+	require.NoError(t, reviewStore.Save(review))
+	_, err = reviewStore.Moderate(review.ID, service.ReviewApproved, "admin1", time.Now())
+	require.NoError(t, err)
+
+	quarantineStore := catalog.QuarantineStore()
+	quarantined := &service.QuarantinedVote{
+		ID:     "quarantined1",
+		Vote:   service.Vote{LaptopID: laptop1.GetId(), Username: "user4", Score: 10, Time: ratedAt},
+		Reason: "too many scores",
+	}
+	require.NoError(t, quarantineStore.Add(quarantined))
+	require.NoError(t, quarantineStore.Add(&service.QuarantinedVote{ID: "quarantined2", Vote: quarantined.Vote}))
+	_, err = quarantineStore.Remove("quarantined2")
+	require.NoError(t, err)
+
+	// replay the whole log
+	replayed, err := service.NewCatalog(eventLog)
+	require.NoError(t, err)
+
+	laptops, err := replayed.Laptops()
+	require.NoError(t, err)
+	require.Len(t, laptops, 1)
+	requireSameLaptop(t, laptop1, laptops[0])
+
+	rating, err = replayed.Rating(laptop1.GetId())
+	require.NoError(t, err)
+	require.Equal(t, uint32(3), rating.Count)
+	require.Equal(t, 20.0, rating.Sum)
+
+	votes, err := replayed.RatingStore().UserVotes("user1")
+	require.NoError(t, err)
+	require.Len(t, votes, 1)
+	require.Equal(t, 6.0, votes[0].Score)
+
+	// the votes keep their time
+	votes, err = replayed.RatingStore().UserVotes("user3")
+	require.NoError(t, err)
+	require.Len(t, votes, 1)
+	require.True(t, ratedAt.Equal(votes[0].Time))
+
+	rating, err = replayed.RatingStore().FindSince(laptop1.GetId(), ratedAt.Add(time.Hour))
+	require.NoError(t, err)
+	require.Equal(t, uint32(2), rating.Count)
+
+	images := replayed.Images(laptop1.GetId())
+	require.Len(t, images, 1)
+	require.Equal(t, imageID, images[0].GetImageId())
+	require.Equal(t, uint32(5), images[0].GetSize())
+
+	gallery, err := replayed.Gallery(laptop1.GetId())
+	require.NoError(t, err)
+	require.Equal(t, []string{"other", imageID}, gallery)
+
+	found, err := replayed.Review(review.ID)
+	require.NoError(t, err)
+	require.Equal(t, "good", found.Title)
+	require.Equal(t, service.ReviewApproved, found.Status)
+	require.Equal(t, "admin1", found.Moderator)
+	require.True(t, review.CreatedAt.Equal(found.CreatedAt))
+
+	quarantinedVotes, err := replayed.QuarantineStore().List("")
+	require.NoError(t, err)
+	require.Len(t, quarantinedVotes, 1)
+	require.Equal(t, "quarantined1", quarantinedVotes[0].ID)
+	require.Equal(t, "too many scores", quarantinedVotes[0].Reason)
+	require.True(t, ratedAt.Equal(quarantinedVotes[0].Vote.Time))
+
+	// replay until the checkpoint
+	replayed, err = service.ReplayCatalog(eventLog, checkpoint)
+	require.NoError(t, err)
+
+	laptops, err = replayed.Laptops()
+	require.NoError(t, err)
+	require.Len(t, laptops, 1)
+	require.Equal(t, laptop1.GetId(), laptops[0].GetId())
+	require.NotEqual(t, 999.0, laptops[0].GetPriceUsd())
+
+	rating, err = replayed.Rating(laptop1.GetId())
+	require.NoError(t, err)
+	require.Equal(t, uint32(1), rating.Count)
+	require.Empty(t, replayed.Images(laptop1.GetId()))
+
+	gallery, err = replayed.Gallery(laptop1.GetId())
+	require.NoError(t, err)
+	require.Empty(t, gallery)
+
+	found, err = replayed.Review(review.ID)
+	require.NoError(t, err)
+	require.Nil(t, found)
+
+	quarantinedVotes, err = replayed.QuarantineStore().List("")
+	require.NoError(t, err)
+	require.Empty(t, quarantinedVotes)
+}
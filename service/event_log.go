@@ -0,0 +1,293 @@
+package service
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"sync"
+	"time"
+
+	"gitlab.com/brucemig/pcbook/pb"
+	"google.golang.org/protobuf/encoding/protodelim"
+	"google.golang.org/protobuf/proto"
+)
+
+// EventLog is an append-only log of catalog events
+type EventLog interface {
+	// Append appends a new event to the end of the log
+	Append(event *pb.Event) error
+	// Replay calls apply for every event in the order they were appended,
+	// skipping the events that happened after until. The events appended concurrently may not be
+	// in the order of their times, so a later event doesn't end the replay. A zero until replays the whole log.
+	Replay(until time.Time, apply func(event *pb.Event) error) error
+}
+
+// InMemoryEventLog stores catalog events in memory
+type InMemoryEventLog struct {
+	mutex  sync.RWMutex
+	events []*pb.Event
+}
+
+// NewInMemoryEventLog returns a new InMemoryEventLog
+func NewInMemoryEventLog() *InMemoryEventLog {
+	return &InMemoryEventLog{}
+}
+
+// Append appends a new event to the end of the log
+func (log *InMemoryEventLog) Append(event *pb.Event) error {
+	log.mutex.Lock()
+	defer log.mutex.Unlock()
+
+	log.events = append(log.events, proto.Clone(event).(*pb.Event))
+	return nil
+}
+
+// Replay calls apply for every event in the order they were appended
+func (log *InMemoryEventLog) Replay(until time.Time, apply func(event *pb.Event) error) error {
+	log.mutex.RLock()
+	events := log.events
+	log.mutex.RUnlock()
+
+	for _, event := range events {
+		if isAfter(event, until) {
+			continue
+		}
+
+		err := apply(proto.Clone(event).(*pb.Event))
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ErrEventLogFailed is returned by the appends to an event log after a failure
+// that left the events in the file unknown, until the log is opened and replayed again
+var ErrEventLogFailed = errors.New("event log failed")
+
+// FileEventLog stores catalog events in a file as size-delimited protobuf messages.
+// The appends are committed in groups: an event is written under the mutex, then a single fsync
+// makes all the events written in the meantime durable, while the next events are written.
+// An event that can't be written is cut from the file. After a failed fsync, the events written since
+// the previous one may or may not be in the file, so the log fails every append from then on.
+type FileEventLog struct {
+	mutex    sync.Mutex
+	filename string
+	file     *os.File
+	// synced is signaled every time an fsync ends
+	synced *sync.Cond
+	// written and durable are the number of events written to the file, and made durable by an fsync
+	written int64
+	durable int64
+	syncing bool
+	// size is the size of the file with the events written so far
+	size int64
+	// failure is the error after which the state of the file is unknown
+	failure error
+}
+
+// NewFileEventLog opens the event log file for appending, creating it if it doesn't exist.
+// The log is owned by the process that opens it: a last event that was only partly written,
+// by a crash in the middle of an append, is cut from the file, so that the log can be appended to again.
+func NewFileEventLog(filename string) (*FileEventLog, error) {
+	file, err := os.OpenFile(filename, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0644)
+	if err != nil {
+		return nil, fmt.Errorf("cannot open event log file: %w", err)
+	}
+
+	err = repairEventLog(filename)
+	if err != nil {
+		file.Close()
+		return nil, err
+	}
+
+	fileInfo, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return nil, fmt.Errorf("cannot stat event log file: %w", err)
+	}
+
+	log := &FileEventLog{
+		filename: filename,
+		file:     file,
+		size:     fileInfo.Size(),
+	}
+	log.synced = sync.NewCond(&log.mutex)
+	return log, nil
+}
+
+// Append appends a new event to the end of the log file, and returns once it is durable
+func (log *FileEventLog) Append(event *pb.Event) error {
+	log.mutex.Lock()
+	defer log.mutex.Unlock()
+
+	if log.failure != nil {
+		return fmt.Errorf("%w: %v", ErrEventLogFailed, log.failure)
+	}
+
+	n, err := protodelim.MarshalTo(log.file, event)
+	if err != nil {
+		// the part of the event that was written is cut, so that it isn't replayed
+		truncateErr := log.file.Truncate(log.size)
+		if truncateErr != nil {
+			log.failure = fmt.Errorf("cannot cut event from log file: %w", truncateErr)
+		}
+		return fmt.Errorf("cannot write event to log file: %w", err)
+	}
+	log.size += int64(n)
+	log.written++
+	position := log.written
+
+	for log.durable < position {
+		if log.failure != nil {
+			return fmt.Errorf("%w: %v", ErrEventLogFailed, log.failure)
+		}
+		if log.syncing {
+			log.synced.Wait()
+			continue
+		}
+
+		// this append leads the next group: its fsync covers all the events written so far
+		log.syncing = true
+		written := log.written
+		log.mutex.Unlock()
+		err := log.file.Sync()
+		log.mutex.Lock()
+		log.syncing = false
+
+		if err != nil {
+			log.failure = fmt.Errorf("cannot sync event log file: %w", err)
+		} else {
+			log.durable = written
+		}
+		log.synced.Broadcast()
+	}
+	return nil
+}
+
+// Replay reads the log file from the beginning and calls apply for every event
+func (log *FileEventLog) Replay(until time.Time, apply func(event *pb.Event) error) error {
+	log.mutex.Lock()
+	defer log.mutex.Unlock()
+
+	_, err := replayEventLogFile(log.filename, until, apply)
+	return err
+}
+
+// Close closes the log file
+func (log *FileEventLog) Close() error {
+	return log.file.Close()
+}
+
+// FileEventLogReader reads the event log file of another process, such as a running server, without modifying it
+type FileEventLogReader struct {
+	filename string
+}
+
+// NewFileEventLogReader returns a reader of an event log file, which must exist
+func NewFileEventLogReader(filename string) (*FileEventLogReader, error) {
+	_, err := os.Stat(filename)
+	if err != nil {
+		return nil, fmt.Errorf("cannot open event log file: %w", err)
+	}
+
+	return &FileEventLogReader{filename: filename}, nil
+}
+
+// Append always fails, since the log belongs to another process
+func (reader *FileEventLogReader) Append(event *pb.Event) error {
+	return fmt.Errorf("cannot append to event log %s: the log is read-only", reader.filename)
+}
+
+// Replay reads the log file from the beginning and calls apply for every event.
+// A last event that is only partly written, such as an append in progress, is skipped.
+func (reader *FileEventLogReader) Replay(until time.Time, apply func(event *pb.Event) error) error {
+	_, err := replayEventLogFile(reader.filename, until, apply)
+	return err
+}
+
+// replayEventLogFile calls apply for every complete event of a log file, skipping the events after until,
+// and returns the offset of the end of the last complete event read. A partly written last event is ignored.
+func replayEventLogFile(filename string, until time.Time, apply func(event *pb.Event) error) (int64, error) {
+	file, err := os.Open(filename)
+	if err != nil {
+		return 0, fmt.Errorf("cannot open event log file: %w", err)
+	}
+	defer file.Close()
+
+	reader := &countingReader{Reader: bufio.NewReader(file)}
+	for {
+		// the offset of the end of the last complete event
+		offset := reader.count
+
+		event := &pb.Event{}
+		err := protodelim.UnmarshalFrom(reader, event)
+		if errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) {
+			return offset, nil
+		}
+		if err != nil {
+			return offset, fmt.Errorf("cannot read event from log file: %w", err)
+		}
+
+		if isAfter(event, until) {
+			continue
+		}
+
+		err = apply(event)
+		if err != nil {
+			return reader.count, err
+		}
+	}
+}
+
+// repairEventLog cuts the partly written event at the end of a log file, if any
+func repairEventLog(filename string) error {
+	offset, err := replayEventLogFile(filename, time.Time{}, func(*pb.Event) error {
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	fileInfo, err := os.Stat(filename)
+	if err != nil {
+		return fmt.Errorf("cannot stat event log file: %w", err)
+	}
+	if fileInfo.Size() == offset {
+		return nil
+	}
+
+	log.Printf("event log %s ends with a truncated event, cutting it at offset %d", filename, offset)
+	err = os.Truncate(filename, offset)
+	if err != nil {
+		return fmt.Errorf("cannot truncate event log file: %w", err)
+	}
+	return nil
+}
+
+// countingReader counts the bytes read from a buffered reader
+type countingReader struct {
+	*bufio.Reader
+	count int64
+}
+
+func (reader *countingReader) Read(p []byte) (int, error) {
+	n, err := reader.Reader.Read(p)
+	reader.count += int64(n)
+	return n, err
+}
+
+func (reader *countingReader) ReadByte() (byte, error) {
+	b, err := reader.Reader.ReadByte()
+	if err == nil {
+		reader.count++
+	}
+	return b, err
+}
+
+func isAfter(event *pb.Event, until time.Time) bool {
+	return !until.IsZero() && event.GetTime().AsTime().After(until)
+}
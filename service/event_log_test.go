@@ -0,0 +1,175 @@
+package service_test
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gitlab.com/brucemig/pcbook/pb"
+	"gitlab.com/brucemig/pcbook/service"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+func TestFileEventLogTruncatedEvent(t *testing.T) {
+	t.Parallel()
+
+	// a crash in the middle of an append leaves a part of the size prefix of the last event, or of the event itself
+	for _, kept := range []int64{1, 2, 100, 200} {
+		filename := filepath.Join(t.TempDir(), "events.log")
+		eventLog, err := service.NewFileEventLog(filename)
+		require.NoError(t, err)
+		require.NoError(t, eventLog.Append(newTestImageDeletedEvent("image1")))
+		require.NoError(t, eventLog.Close())
+
+		fileInfo, err := os.Stat(filename)
+		require.NoError(t, err)
+		size := fileInfo.Size()
+
+		eventLog, err = service.NewFileEventLog(filename)
+		require.NoError(t, err)
+		require.NoError(t, eventLog.Append(newTestImageDeletedEvent(strings.Repeat("x", 100))))
+		require.NoError(t, eventLog.Close())
+
+		fileInfo, err = os.Stat(filename)
+		require.NoError(t, err)
+		require.Greater(t, fileInfo.Size(), size+kept)
+		require.NoError(t, os.Truncate(filename, size+kept))
+
+		eventLog, err = service.NewFileEventLog(filename)
+		require.NoError(t, err)
+		require.Equal(t, []string{"image1"}, replayTestImageIDs(t, eventLog))
+
+		// the truncated event is cut, so the next events are appended after the complete ones
+		fileInfo, err = os.Stat(filename)
+		require.NoError(t, err)
+		require.Equal(t, size, fileInfo.Size())
+
+		require.NoError(t, eventLog.Append(newTestImageDeletedEvent("image2")))
+		require.Equal(t, []string{"image1", "image2"}, replayTestImageIDs(t, eventLog))
+		require.NoError(t, eventLog.Close())
+	}
+}
+
+func TestFileEventLogReader(t *testing.T) {
+	t.Parallel()
+
+	filename := filepath.Join(t.TempDir(), "events.log")
+	_, err := service.NewFileEventLogReader(filename)
+	require.ErrorIs(t, err, os.ErrNotExist)
+
+	eventLog, err := service.NewFileEventLog(filename)
+	require.NoError(t, err)
+	defer eventLog.Close()
+	require.NoError(t, eventLog.Append(newTestImageDeletedEvent("image1")))
+
+	// an append in progress of the owner looks like a truncated event, which the reader skips without cutting it
+	file, err := os.OpenFile(filename, os.O_APPEND|os.O_WRONLY, 0)
+	require.NoError(t, err)
+	_, err = file.Write([]byte{100, 1, 2})
+	require.NoError(t, err)
+	require.NoError(t, file.Close())
+	fileInfo, err := os.Stat(filename)
+	require.NoError(t, err)
+
+	reader, err := service.NewFileEventLogReader(filename)
+	require.NoError(t, err)
+	require.Equal(t, []string{"image1"}, replayTestImageIDs(t, reader))
+	require.Error(t, reader.Append(newTestImageDeletedEvent("image2")))
+
+	readFileInfo, err := os.Stat(filename)
+	require.NoError(t, err)
+	require.Equal(t, fileInfo.Size(), readFileInfo.Size())
+}
+
+func TestFileEventLogFailure(t *testing.T) {
+	t.Parallel()
+
+	eventLog, err := service.NewFileEventLog(filepath.Join(t.TempDir(), "events.log"))
+	require.NoError(t, err)
+	require.NoError(t, eventLog.Append(newTestImageDeletedEvent("image1")))
+
+	// an event that can't be written nor cut leaves the file unknown, so the log fails every next append
+	require.NoError(t, eventLog.Close())
+	err = eventLog.Append(newTestImageDeletedEvent("image2"))
+	require.Error(t, err)
+	require.NotErrorIs(t, err, service.ErrEventLogFailed)
+	require.ErrorIs(t, eventLog.Append(newTestImageDeletedEvent("image3")), service.ErrEventLogFailed)
+}
+
+func TestEventLogReplayUntil(t *testing.T) {
+	t.Parallel()
+
+	fileEventLog, err := service.NewFileEventLog(filepath.Join(t.TempDir(), "events.log"))
+	require.NoError(t, err)
+	defer fileEventLog.Close()
+
+	for _, eventLog := range []service.EventLog{service.NewInMemoryEventLog(), fileEventLog} {
+		// concurrent appends may be out of the order of their times
+		start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+		for _, minutes := range []int{0, 2, 1, 3} {
+			event := newTestImageDeletedEvent(fmt.Sprintf("image%d", minutes))
+			event.Time = timestamppb.New(start.Add(time.Duration(minutes) * time.Minute))
+			require.NoError(t, eventLog.Append(event))
+		}
+
+		var imageIDs []string
+		err := eventLog.Replay(start.Add(90*time.Second), func(event *pb.Event) error {
+			imageIDs = append(imageIDs, event.GetImageDeleted().GetImageId())
+			return nil
+		})
+		require.NoError(t, err)
+		require.Equal(t, []string{"image0", "image1"}, imageIDs)
+	}
+}
+
+func TestFileEventLogConcurrentAppends(t *testing.T) {
+	t.Parallel()
+
+	eventLog, err := service.NewFileEventLog(filepath.Join(t.TempDir(), "events.log"))
+	require.NoError(t, err)
+	defer eventLog.Close()
+
+	// the concurrent appends are committed in groups, and every event is appended once
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			for j := 0; j < 50; j++ {
+				assert.NoError(t, eventLog.Append(newTestImageDeletedEvent(fmt.Sprintf("image%d-%d", i, j))))
+			}
+		}(i)
+	}
+	wg.Wait()
+
+	imageIDs := replayTestImageIDs(t, eventLog)
+	require.Len(t, imageIDs, 400)
+	slices.Sort(imageIDs)
+	require.Len(t, slices.Compact(imageIDs), 400)
+}
+
+func newTestImageDeletedEvent(imageID string) *pb.Event {
+	return &pb.Event{
+		Id: imageID,
+		Payload: &pb.Event_ImageDeleted{
+			ImageDeleted: &pb.ImageDeleted{ImageId: imageID},
+		},
+	}
+}
+
+func replayTestImageIDs(t *testing.T, eventLog service.EventLog) []string {
+	var imageIDs []string
+	err := eventLog.Replay(time.Time{}, func(event *pb.Event) error {
+		imageIDs = append(imageIDs, event.GetImageDeleted().GetImageId())
+		return nil
+	})
+	require.NoError(t, err)
+	return imageIDs
+}
@@ -0,0 +1,161 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"sync"
+)
+
+// ErrGalleryFull is returned when an image is added to a gallery that already has the maximum number of images
+var ErrGalleryFull = errors.New("gallery is full")
+
+// ErrInvalidOrder is returned when the new order of a gallery doesn't contain exactly the images of the gallery
+var ErrInvalidOrder = errors.New("invalid order")
+
+// GalleryStore is an interface to store the ordered list of images of each laptop.
+// The first image of a gallery is the primary image of the laptop.
+type GalleryStore interface {
+	// Add adds an image at the end of a laptop gallery.
+	// It returns ErrGalleryFull if the gallery already has maxImages images.
+	Add(laptopID string, imageID string, maxImages int) error
+	// Remove removes an image from a laptop gallery.
+	// It returns ErrNotFound if the image is not in the gallery.
+	Remove(laptopID string, imageID string) error
+	// Images returns the image IDs of a laptop gallery, in order
+	Images(laptopID string) ([]string, error)
+	// SetPrimary moves an image to the front of a laptop gallery.
+	// It returns ErrNotFound if the image is not in the gallery.
+	SetPrimary(laptopID string, imageID string) error
+	// Reorder changes the order of a laptop gallery.
+	// It returns ErrInvalidOrder if imageIDs is not a permutation of the images of the gallery.
+	Reorder(laptopID string, imageIDs []string) error
+}
+
+// InMemoryGalleryStore stores the laptop galleries in memory
+type InMemoryGalleryStore struct {
+	mutex     sync.RWMutex
+	galleries map[string][]string
+}
+
+// NewInMemoryGalleryStore returns a new InMemoryGalleryStore
+func NewInMemoryGalleryStore() *InMemoryGalleryStore {
+	return &InMemoryGalleryStore{
+		galleries: make(map[string][]string),
+	}
+}
+
+// Add adds an image at the end of a laptop gallery
+func (store *InMemoryGalleryStore) Add(laptopID string, imageID string, maxImages int) error {
+	return store.update(laptopID, func(images []string) ([]string, error) {
+		return addGalleryImage(images, imageID, maxImages)
+	})
+}
+
+// Remove removes an image from a laptop gallery
+func (store *InMemoryGalleryStore) Remove(laptopID string, imageID string) error {
+	return store.update(laptopID, func(images []string) ([]string, error) {
+		return removeGalleryImage(images, imageID)
+	})
+}
+
+// Images returns the image IDs of a laptop gallery, in order
+func (store *InMemoryGalleryStore) Images(laptopID string) ([]string, error) {
+	store.mutex.RLock()
+	defer store.mutex.RUnlock()
+
+	return append([]string(nil), store.galleries[laptopID]...), nil
+}
+
+// SetPrimary moves an image to the front of a laptop gallery
+func (store *InMemoryGalleryStore) SetPrimary(laptopID string, imageID string) error {
+	return store.update(laptopID, func(images []string) ([]string, error) {
+		return setPrimaryGalleryImage(images, imageID)
+	})
+}
+
+// Reorder changes the order of a laptop gallery
+func (store *InMemoryGalleryStore) Reorder(laptopID string, imageIDs []string) error {
+	return store.update(laptopID, func(images []string) ([]string, error) {
+		return reorderGalleryImages(images, imageIDs)
+	})
+}
+
+// update replaces a laptop gallery with the result of the change function
+func (store *InMemoryGalleryStore) update(laptopID string, change func(images []string) ([]string, error)) error {
+	store.mutex.Lock()
+	defer store.mutex.Unlock()
+
+	images, err := change(store.galleries[laptopID])
+	if err != nil {
+		return err
+	}
+
+	store.set(laptopID, images)
+	return nil
+}
+
+// set replaces a laptop gallery.
+// The caller must hold the store mutex.
+func (store *InMemoryGalleryStore) set(laptopID string, images []string) {
+	if len(images) == 0 {
+		delete(store.galleries, laptopID)
+		return
+	}
+	store.galleries[laptopID] = images
+}
+
+// The gallery changes below never modify the given images, so that they can be applied to a copy first
+
+func addGalleryImage(images []string, imageID string, maxImages int) ([]string, error) {
+	if len(images) >= maxImages {
+		return nil, fmt.Errorf("%w: %d images", ErrGalleryFull, len(images))
+	}
+
+	other := make([]string, 0, len(images)+1)
+	other = append(other, images...)
+	return append(other, imageID), nil
+}
+
+func removeGalleryImage(images []string, imageID string) ([]string, error) {
+	i := slices.Index(images, imageID)
+	if i < 0 {
+		return nil, ErrNotFound
+	}
+
+	other := make([]string, 0, len(images)-1)
+	other = append(other, images[:i]...)
+	return append(other, images[i+1:]...), nil
+}
+
+func setPrimaryGalleryImage(images []string, imageID string) ([]string, error) {
+	i := slices.Index(images, imageID)
+	if i < 0 {
+		return nil, ErrNotFound
+	}
+
+	other := make([]string, 0, len(images))
+	other = append(other, imageID)
+	other = append(other, images[:i]...)
+	return append(other, images[i+1:]...), nil
+}
+
+func reorderGalleryImages(images []string, imageIDs []string) ([]string, error) {
+	if len(imageIDs) != len(images) {
+		return nil, fmt.Errorf("%w: %d images, expected %d", ErrInvalidOrder, len(imageIDs), len(images))
+	}
+
+	remaining := make(map[string]bool, len(images))
+	for _, imageID := range images {
+		remaining[imageID] = true
+	}
+
+	for _, imageID := range imageIDs {
+		if !remaining[imageID] {
+			return nil, fmt.Errorf("%w: image %s is not in the gallery or is repeated", ErrInvalidOrder, imageID)
+		}
+		delete(remaining, imageID)
+	}
+
+	return append([]string(nil), imageIDs...), nil
+}
@@ -0,0 +1,254 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// ImageCollector reconciles the image folder of a DiskImageStore with the image metadata and the laptops.
+// It deletes the orphan images, whose laptop or original image doesn't exist anymore,
+// and the orphan files, such as the temporary files of failed uploads and the blobs without any image.
+// With a catalog, the original images whose upload or gallery entry the catalog didn't record are orphans too.
+// Images and files are only collected once they are older than the grace period,
+// so that the uploads in progress are never collected.
+type ImageCollector struct {
+	diskStore *DiskImageStore
+	// imageStore deletes the orphan images, it is diskStore or a store that wraps it, such as the catalog image store
+	imageStore  ImageStore
+	laptopStore LaptopStore
+	catalog     *Catalog
+	gracePeriod time.Duration
+}
+
+// ImageCollectorOption configures an ImageCollector
+type ImageCollectorOption func(*ImageCollector)
+
+// WithCollectorCatalog reconciles the original images with the uploads and galleries recorded in a catalog
+func WithCollectorCatalog(catalog *Catalog) ImageCollectorOption {
+	return func(collector *ImageCollector) {
+		collector.catalog = catalog
+	}
+}
+
+// ImageCollection reports what a run of the image collector deleted
+type ImageCollection struct {
+	// Images is the number of orphan images deleted, renditions included
+	Images int
+	// Files is the number of orphan files deleted
+	Files int
+	// ReclaimedBytes is the disk space freed by the deleted images and files, in bytes
+	ReclaimedBytes int64
+}
+
+// NewImageCollector returns a new ImageCollector
+func NewImageCollector(
+	diskStore *DiskImageStore,
+	imageStore ImageStore,
+	laptopStore LaptopStore,
+	gracePeriod time.Duration,
+	options ...ImageCollectorOption,
+) *ImageCollector {
+	collector := &ImageCollector{
+		diskStore:   diskStore,
+		imageStore:  imageStore,
+		laptopStore: laptopStore,
+		gracePeriod: gracePeriod,
+	}
+	for _, option := range options {
+		option(collector)
+	}
+	return collector
+}
+
+// Collect deletes the orphan images and files that are older than the grace period at the given time
+func (collector *ImageCollector) Collect(now time.Time) (*ImageCollection, error) {
+	before := now.Add(-collector.gracePeriod)
+	collection := &ImageCollection{}
+
+	images := collector.diskStore.all()
+	for _, info := range images {
+		if !info.UploadedAt.Before(before) {
+			continue
+		}
+
+		orphan, err := collector.isOrphan(info, images)
+		if err != nil {
+			return collection, err
+		}
+		if !orphan {
+			continue
+		}
+
+		err = collector.imageStore.Delete(info.ID)
+		if errors.Is(err, ErrNotFound) {
+			// the image has been deleted since the images were listed
+			continue
+		}
+		if err != nil {
+			return collection, fmt.Errorf("cannot delete orphan image %s: %w", info.ID, err)
+		}
+
+		collection.Images++
+		if _, err := os.Stat(info.Path); os.IsNotExist(err) {
+			collection.ReclaimedBytes += info.Size
+		}
+	}
+
+	files, size, err := collector.diskStore.collectFiles(before)
+	collection.Files += files
+	collection.ReclaimedBytes += size
+	return collection, err
+}
+
+// isOrphan returns true if the laptop of an image doesn't exist, if the image is a rendition of an image that doesn't exist,
+// or if the catalog didn't record the upload or gallery entry of an original image
+func (collector *ImageCollector) isOrphan(info *ImageInfo, images map[string]*ImageInfo) (bool, error) {
+	if info.OriginalID != "" && images[info.OriginalID] == nil {
+		return true, nil
+	}
+
+	if info.OriginalID == "" && collector.catalog != nil {
+		orphan, err := collector.catalog.isOrphanImage(info)
+		if err != nil {
+			return false, fmt.Errorf("cannot reconcile image %s with the catalog: %w", info.ID, err)
+		}
+		if orphan {
+			return true, nil
+		}
+	}
+
+	laptop, err := collector.laptopStore.Find(info.LaptopID)
+	if err != nil {
+		return false, fmt.Errorf("cannot find laptop %s: %w", info.LaptopID, err)
+	}
+	return laptop == nil, nil
+}
+
+// CollectImages runs the image collector at every interval, until the context is done
+func CollectImages(ctx context.Context, collector *ImageCollector, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case now := <-ticker.C:
+			collection, err := collector.Collect(now)
+			if err != nil {
+				log.Printf("cannot collect images: %v", err)
+			}
+			log.Printf(
+				"collected %d orphan images and %d orphan files, reclaimed %d bytes",
+				collection.Images, collection.Files, collection.ReclaimedBytes,
+			)
+		}
+	}
+}
+
+// all returns a copy of the info of all images, by ID
+func (store *DiskImageStore) all() map[string]*ImageInfo {
+	store.mutex.RLock()
+	defer store.mutex.RUnlock()
+
+	images := make(map[string]*ImageInfo, len(store.images))
+	for imageID, info := range store.images {
+		other := *info
+		images[imageID] = &other
+	}
+	return images
+}
+
+// collectFiles deletes the temporary files and the blobs without any image that were last modified before the given time,
+// and returns the number and total size of the deleted files.
+// The folder is walked without the lock, which is only held to check the references of each blob as it is deleted.
+func (store *DiskImageStore) collectFiles(before time.Time) (int, int64, error) {
+	count := 0
+	size := int64(0)
+
+	collect := func(path string) error {
+		fileInfo, err := os.Stat(path)
+		if os.IsNotExist(err) {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("cannot stat orphan file: %w", err)
+		}
+		if !fileInfo.ModTime().Before(before) {
+			return nil
+		}
+
+		err = os.Remove(path)
+		if os.IsNotExist(err) {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("cannot delete orphan file: %w", err)
+		}
+
+		count++
+		size += fileInfo.Size()
+		return nil
+	}
+
+	tmpFiles, err := filepath.Glob(filepath.Join(store.imageFolder, "*"+tmpExt))
+	if err != nil {
+		return count, size, fmt.Errorf("cannot list temporary files: %w", err)
+	}
+
+	// the temporary files are never referenced, the uploads in progress are protected by the grace period
+	for _, tmpFile := range tmpFiles {
+		err = collect(tmpFile)
+		if err != nil {
+			return count, size, err
+		}
+	}
+
+	blobs, err := os.ReadDir(filepath.Join(store.imageFolder, blobFolder))
+	if err != nil {
+		return count, size, fmt.Errorf("cannot list blobs: %w", err)
+	}
+
+	for _, checksum := range store.unreferencedBlobs(blobs) {
+		err = store.collectBlob(checksum, collect)
+		if err != nil {
+			return count, size, err
+		}
+	}
+
+	return count, size, nil
+}
+
+// unreferencedBlobs returns the checksums of the blobs that no image points to
+func (store *DiskImageStore) unreferencedBlobs(blobs []os.DirEntry) []string {
+	store.mutex.RLock()
+	defer store.mutex.RUnlock()
+
+	var checksums []string
+	for _, blob := range blobs {
+		checksum := blob.Name()
+		if blob.IsDir() || strings.HasPrefix(checksum, ".") || store.refs[checksum] > 0 {
+			continue
+		}
+		checksums = append(checksums, checksum)
+	}
+	return checksums
+}
+
+// collectBlob calls collect with the path of a blob under the lock, if no image points to the blob anymore,
+// so that Save can't add a reference to the blob while it is deleted
+func (store *DiskImageStore) collectBlob(checksum string, collect func(path string) error) error {
+	store.mutex.Lock()
+	defer store.mutex.Unlock()
+
+	if store.refs[checksum] > 0 {
+		return nil
+	}
+	return collect(store.blobPath(checksum))
+}
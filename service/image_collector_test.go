@@ -0,0 +1,116 @@
+package service_test
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"gitlab.com/brucemig/pcbook/sample"
+	"gitlab.com/brucemig/pcbook/service"
+)
+
+func TestImageCollector(t *testing.T) {
+	t.Parallel()
+
+	imageFolder := t.TempDir()
+	catalog := newTestCatalog(t)
+	diskStore, err := service.NewDiskImageStore(imageFolder)
+	require.NoError(t, err)
+	imageStore := catalog.ImageStore(diskStore)
+
+	laptopStore := service.NewInMemoryLaptopStore()
+	laptop := sample.NewLaptop()
+	require.NoError(t, laptopStore.Save(laptop))
+	deletedLaptopID := sample.NewLaptop().GetId()
+
+	imageID, err := imageStore.Save(&service.ImageInfo{LaptopID: laptop.GetId(), Type: ".png"}, strings.NewReader("image"))
+	require.NoError(t, err)
+	renditionID, err := imageStore.Save(&service.ImageInfo{LaptopID: laptop.GetId(), Type: ".png", OriginalID: imageID, Rendition: 128}, strings.NewReader("small"))
+	require.NoError(t, err)
+
+	// the images of a deleted laptop, and a rendition of a deleted image
+	_, err = imageStore.Save(&service.ImageInfo{LaptopID: deletedLaptopID, Type: ".png"}, strings.NewReader("orphan image"))
+	require.NoError(t, err)
+	_, err = imageStore.Save(&service.ImageInfo{LaptopID: deletedLaptopID, Type: ".png"}, strings.NewReader("image"))
+	require.NoError(t, err)
+	_, err = imageStore.Save(&service.ImageInfo{LaptopID: laptop.GetId(), Type: ".png", OriginalID: "deleted", Rendition: 128}, strings.NewReader("orphan rendition"))
+	require.NoError(t, err)
+
+	// the files of a failed upload and of a crash between a blob and its metadata
+	require.NoError(t, os.WriteFile(filepath.Join(imageFolder, "upload-1.tmp"), []byte("partial"), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(imageFolder, "blobs", "0123"), []byte("orphan blob"), 0644))
+
+	collector := service.NewImageCollector(diskStore, imageStore, laptopStore, time.Hour)
+
+	// everything is within the grace period
+	collection, err := collector.Collect(time.Now())
+	require.NoError(t, err)
+	require.Equal(t, &service.ImageCollection{}, collection)
+
+	collection, err = collector.Collect(time.Now().Add(2 * time.Hour))
+	require.NoError(t, err)
+	require.Equal(t, 3, collection.Images)
+	require.Equal(t, 2, collection.Files)
+	// the blob of the second image of the deleted laptop is shared with an image that is kept
+	require.Equal(t, int64(len("orphan image")+len("orphan rendition")+len("partial")+len("orphan blob")), collection.ReclaimedBytes)
+
+	images, err := imageStore.List(laptop.GetId())
+	require.NoError(t, err)
+	require.Len(t, images, 2)
+	require.ElementsMatch(t, []string{imageID, renditionID}, []string{images[0].ID, images[1].ID})
+
+	images, err = imageStore.List(deletedLaptopID)
+	require.NoError(t, err)
+	require.Empty(t, images)
+	require.Empty(t, catalog.Images(deletedLaptopID))
+
+	blobs, err := os.ReadDir(filepath.Join(imageFolder, "blobs"))
+	require.NoError(t, err)
+	require.Len(t, blobs, 2)
+
+	// nothing is left to collect
+	collection, err = collector.Collect(time.Now().Add(2 * time.Hour))
+	require.NoError(t, err)
+	require.Equal(t, &service.ImageCollection{}, collection)
+}
+
+func TestImageCollectorCatalog(t *testing.T) {
+	t.Parallel()
+
+	catalog := newTestCatalog(t)
+	diskStore, err := service.NewDiskImageStore(t.TempDir())
+	require.NoError(t, err)
+	imageStore := catalog.ImageStore(diskStore)
+	galleryStore := catalog.GalleryStore()
+
+	laptopStore := service.NewInMemoryLaptopStore()
+	laptop := sample.NewLaptop()
+	require.NoError(t, laptopStore.Save(laptop))
+
+	// an image saved before the catalog recorded any upload
+	legacyID, err := diskStore.Save(&service.ImageInfo{LaptopID: laptop.GetId(), Type: ".png"}, strings.NewReader("legacy"))
+	require.NoError(t, err)
+
+	imageID, err := imageStore.Save(&service.ImageInfo{LaptopID: laptop.GetId(), Type: ".png"}, strings.NewReader("image"))
+	require.NoError(t, err)
+	require.NoError(t, galleryStore.Add(laptop.GetId(), imageID, 10))
+
+	// the images of uploads that failed after saving them, without an upload record or a gallery entry
+	_, err = diskStore.Save(&service.ImageInfo{LaptopID: laptop.GetId(), Type: ".png"}, strings.NewReader("unrecorded"))
+	require.NoError(t, err)
+	_, err = imageStore.Save(&service.ImageInfo{LaptopID: laptop.GetId(), Type: ".png"}, strings.NewReader("ungalleried"))
+	require.NoError(t, err)
+
+	collector := service.NewImageCollector(diskStore, imageStore, laptopStore, time.Hour, service.WithCollectorCatalog(catalog))
+	collection, err := collector.Collect(time.Now().Add(2 * time.Hour))
+	require.NoError(t, err)
+	require.Equal(t, 2, collection.Images)
+
+	images, err := imageStore.List(laptop.GetId())
+	require.NoError(t, err)
+	require.Len(t, images, 2)
+	require.ElementsMatch(t, []string{legacyID, imageID}, []string{images[0].ID, images[1].ID})
+}
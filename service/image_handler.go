@@ -0,0 +1,211 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log"
+	"mime"
+	"net/http"
+	"path/filepath"
+	"strconv"
+
+	"github.com/grpc-ecosystem/grpc-gateway/v2/runtime"
+	"gitlab.com/brucemig/pcbook/pb"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/encoding/protojson"
+)
+
+// maxFormFieldSize is the maximum size of the non-file fields of a multipart form
+const maxFormFieldSize = 1024
+
+// DownloadImageHandler returns a REST handler that downloads a laptop image through the DownloadImage RPC
+// and writes the raw image data with the Content-Type of its image type.
+// The image ID is read from the "id" path parameter, and the optional rendition from the "rendition" query parameter.
+func DownloadImageHandler(laptopClient pb.LaptopServiceClient) runtime.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request, pathParams map[string]string) {
+		ctx := outgoingContext(r)
+
+		req := &pb.DownloadImageRequest{ImageId: pathParams["id"]}
+		if value := r.URL.Query().Get("rendition"); value != "" {
+			rendition, err := strconv.ParseUint(value, 10, 32)
+			if err != nil {
+				http.Error(w, "invalid rendition", http.StatusBadRequest)
+				return
+			}
+			req.Rendition = uint32(rendition)
+		}
+
+		stream, err := laptopClient.DownloadImage(ctx, req)
+		if err != nil {
+			writeHTTPError(w, err)
+			return
+		}
+
+		res, err := stream.Recv()
+		if err != nil {
+			writeHTTPError(w, err)
+			return
+		}
+
+		info := res.GetInfo()
+		if info == nil {
+			writeHTTPError(w, errors.New("image info is not received"))
+			return
+		}
+
+		contentType := mime.TypeByExtension(info.GetImageType())
+		if contentType == "" {
+			contentType = "application/octet-stream"
+		}
+		w.Header().Set("Content-Type", contentType)
+
+		for {
+			res, err := stream.Recv()
+			if err == io.EOF {
+				return
+			}
+			if err != nil {
+				// the status is already sent, so we can only stop writing
+				log.Printf("cannot receive image chunk: %v", err)
+				return
+			}
+
+			_, err = w.Write(res.GetChunkData())
+			if err != nil {
+				log.Printf("cannot write image chunk: %v", err)
+				return
+			}
+		}
+	}
+}
+
+// UploadImageHandler returns a REST handler that uploads a laptop image from a multipart/form-data request,
+// such as a browser form, through the UploadImage RPC, and writes the JSON response of the RPC.
+// The form must have a "laptop_id" field followed by an "image" file field, whose file name extension is the image type.
+// The file is forwarded in chunks as it is read, so the handler never holds the whole image in memory.
+func UploadImageHandler(laptopClient pb.LaptopServiceClient) runtime.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request, pathParams map[string]string) {
+		form, err := r.MultipartReader()
+		if err != nil {
+			http.Error(w, "request must be multipart/form-data", http.StatusUnsupportedMediaType)
+			return
+		}
+
+		laptopID := ""
+		for {
+			part, err := form.NextPart()
+			if err == io.EOF {
+				http.Error(w, "image file is not provided", http.StatusBadRequest)
+				return
+			}
+			if err != nil {
+				http.Error(w, "cannot read multipart form", http.StatusBadRequest)
+				return
+			}
+
+			switch part.FormName() {
+			case "laptop_id":
+				value, err := io.ReadAll(io.LimitReader(part, maxFormFieldSize))
+				if err != nil {
+					http.Error(w, "cannot read laptop_id", http.StatusBadRequest)
+					return
+				}
+				laptopID = string(value)
+			case "image":
+				if laptopID == "" {
+					http.Error(w, "laptop_id must be sent before the image file", http.StatusBadRequest)
+					return
+				}
+				uploadImage(w, r, laptopClient, laptopID, filepath.Ext(part.FileName()), part)
+				return
+			}
+		}
+	}
+}
+
+// uploadImage sends the image data to the UploadImage RPC and writes its response
+func uploadImage(
+	w http.ResponseWriter,
+	r *http.Request,
+	laptopClient pb.LaptopServiceClient,
+	laptopID string,
+	imageType string,
+	imageData io.Reader,
+) {
+	// cancelling the context aborts the upload if the request body cannot be read
+	ctx, cancel := context.WithCancel(outgoingContext(r))
+	defer cancel()
+
+	stream, err := laptopClient.UploadImage(ctx)
+	if err != nil {
+		writeHTTPError(w, err)
+		return
+	}
+
+	req := &pb.UploadImageRequest{
+		Data: &pb.UploadImageRequest_Info{
+			Info: &pb.ImageInfo{
+				LaptopId:  laptopID,
+				ImageType: imageType,
+			},
+		},
+	}
+
+	err = stream.Send(req)
+	buffer := make([]byte, imageChunkSize)
+
+	for err == nil {
+		n, readErr := imageData.Read(buffer)
+		if n > 0 {
+			req := &pb.UploadImageRequest{
+				Data: &pb.UploadImageRequest_ChunkData{
+					ChunkData: buffer[:n],
+				},
+			}
+			err = stream.Send(req)
+		}
+
+		if readErr == io.EOF {
+			break
+		}
+		if readErr != nil {
+			http.Error(w, "cannot read image file", http.StatusBadRequest)
+			return
+		}
+	}
+
+	// the server has ended the stream if Send fails, and CloseAndRecv returns its error
+	res, err := stream.CloseAndRecv()
+	if err != nil {
+		writeHTTPError(w, err)
+		return
+	}
+
+	body, err := protojson.MarshalOptions{EmitUnpopulated: true}.Marshal(res)
+	if err != nil {
+		writeHTTPError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Write(body)
+}
+
+// outgoingContext returns the request context with its Authorization header forwarded as gRPC metadata
+func outgoingContext(r *http.Request) context.Context {
+	ctx := r.Context()
+
+	token := r.Header.Get("Authorization")
+	if token != "" {
+		ctx = metadata.AppendToOutgoingContext(ctx, "authorization", token)
+	}
+	return ctx
+}
+
+// writeHTTPError writes a gRPC error with the matching HTTP status code
+func writeHTTPError(w http.ResponseWriter, err error) {
+	st := status.Convert(err)
+	http.Error(w, st.Message(), runtime.HTTPStatusFromCode(st.Code()))
+}
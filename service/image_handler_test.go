@@ -0,0 +1,155 @@
+package service_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/grpc-ecosystem/grpc-gateway/v2/runtime"
+	"github.com/stretchr/testify/require"
+	"gitlab.com/brucemig/pcbook/sample"
+	"gitlab.com/brucemig/pcbook/service"
+)
+
+func TestDownloadImageHandler(t *testing.T) {
+	t.Parallel()
+
+	imageStore := newTestDiskImageStore(t)
+
+	imageData, err := os.ReadFile("../tmp/laptop.jpeg")
+	require.NoError(t, err)
+
+	imageID, err := imageStore.Save(&service.ImageInfo{LaptopID: sample.NewLaptop().GetId(), Type: ".jpeg"}, bytes.NewReader(imageData))
+	require.NoError(t, err)
+
+	serverAddress := startTestLaptopServer(t, nil, imageStore, nil)
+	laptopClient := newTestLaptopClient(t, serverAddress)
+
+	mux := runtime.NewServeMux()
+	err = mux.HandlePath("GET", "/v1/laptop/image/{id}", service.DownloadImageHandler(laptopClient))
+	require.NoError(t, err)
+
+	restServer := httptest.NewServer(mux)
+	defer restServer.Close()
+
+	res, err := http.Get(restServer.URL + "/v1/laptop/image/" + imageID)
+	require.NoError(t, err)
+	defer res.Body.Close()
+
+	require.Equal(t, http.StatusOK, res.StatusCode)
+	require.Equal(t, "image/jpeg", res.Header.Get("Content-Type"))
+
+	body, err := io.ReadAll(res.Body)
+	require.NoError(t, err)
+	require.Equal(t, imageData, body)
+
+	res, err = http.Get(restServer.URL + "/v1/laptop/image/unknown")
+	require.NoError(t, err)
+	defer res.Body.Close()
+	require.Equal(t, http.StatusNotFound, res.StatusCode)
+}
+
+func TestUploadImageHandler(t *testing.T) {
+	t.Parallel()
+
+	laptopStore := service.NewInMemoryLaptopStore()
+	laptop := sample.NewLaptop()
+	require.NoError(t, laptopStore.Save(laptop))
+
+	imageStore := newTestDiskImageStore(t)
+
+	accessToken := newTestAccessToken(t, "admin", "admin")
+	serverAddress := startTestAuthLaptopServer(t, service.NewLaptopServer(laptopStore, imageStore, nil), map[string][]string{
+		"/brucemig.pcbook.LaptopService/UploadImage": {"admin"},
+	})
+
+	mux := runtime.NewServeMux()
+	err := mux.HandlePath("POST", "/v1/laptop/upload_image", service.UploadImageHandler(newTestLaptopClient(t, serverAddress)))
+	require.NoError(t, err)
+
+	restServer := httptest.NewServer(mux)
+	defer restServer.Close()
+
+	imageData, err := os.ReadFile("../tmp/laptop.jpeg")
+	require.NoError(t, err)
+
+	upload := func(accessToken string, contentType string, body io.Reader) *http.Response {
+		req, err := http.NewRequest(http.MethodPost, restServer.URL+"/v1/laptop/upload_image", body)
+		require.NoError(t, err)
+		req.Header.Set("Content-Type", contentType)
+		if accessToken != "" {
+			req.Header.Set("Authorization", accessToken)
+		}
+
+		res, err := http.DefaultClient.Do(req)
+		require.NoError(t, err)
+		t.Cleanup(func() { res.Body.Close() })
+		return res
+	}
+
+	contentType, body := newImageForm(t, laptop.GetId(), "laptop.jpeg", imageData)
+	res := upload(accessToken, contentType, body)
+	require.Equal(t, http.StatusOK, res.StatusCode)
+	require.Equal(t, "application/json", res.Header.Get("Content-Type"))
+
+	uploaded := struct {
+		ID   string `json:"id"`
+		Size uint32 `json:"size"`
+	}{}
+	require.NoError(t, json.NewDecoder(res.Body).Decode(&uploaded))
+	require.NotEmpty(t, uploaded.ID)
+	require.Equal(t, uint32(len(imageData)), uploaded.Size)
+
+	info, stored, err := imageStore.Open(uploaded.ID)
+	require.NoError(t, err)
+	defer stored.Close()
+	require.Equal(t, laptop.GetId(), info.LaptopID)
+	require.Equal(t, ".jpeg", info.Type)
+	require.Equal(t, int64(len(imageData)), info.Size)
+	require.Equal(t, "admin", info.Uploader)
+
+	contentType, body = newImageForm(t, laptop.GetId(), "laptop.jpeg", imageData)
+	res = upload("", contentType, body)
+	require.Equal(t, http.StatusUnauthorized, res.StatusCode)
+
+	contentType, body = newImageForm(t, laptop.GetId(), "laptop.png", imageData)
+	res = upload(accessToken, contentType, body)
+	require.Equal(t, http.StatusBadRequest, res.StatusCode)
+
+	contentType, body = newImageForm(t, "", "laptop.jpeg", imageData)
+	res = upload(accessToken, contentType, body)
+	require.Equal(t, http.StatusBadRequest, res.StatusCode)
+
+	res = upload(accessToken, "application/json", strings.NewReader("{}"))
+	require.Equal(t, http.StatusUnsupportedMediaType, res.StatusCode)
+
+	images, err := imageStore.List(laptop.GetId())
+	require.NoError(t, err)
+	for _, image := range images {
+		require.True(t, image.ID == uploaded.ID || image.OriginalID == uploaded.ID)
+	}
+}
+
+// newImageForm returns the content type and body of a multipart form to upload an image
+func newImageForm(t *testing.T, laptopID string, fileName string, imageData []byte) (string, io.Reader) {
+	body := &bytes.Buffer{}
+	form := multipart.NewWriter(body)
+
+	if laptopID != "" {
+		require.NoError(t, form.WriteField("laptop_id", laptopID))
+	}
+
+	file, err := form.CreateFormFile("image", fileName)
+	require.NoError(t, err)
+	_, err = file.Write(imageData)
+	require.NoError(t, err)
+	require.NoError(t, form.Close())
+
+	return form.FormDataContentType(), body
+}
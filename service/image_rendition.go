@@ -0,0 +1,108 @@
+package service
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	"image/jpeg"
+	"image/png"
+	"io"
+	"sort"
+
+	"golang.org/x/image/draw"
+)
+
+// DefaultRenditionSizes are the default long edge sizes of the image renditions, in pixels
+var DefaultRenditionSizes = []int{128, 512, 1024}
+
+// maximum number of pixels of the images that are decoded to generate renditions,
+// as a decoded image takes 4 bytes per pixel, 64 MiB at this limit
+const maxRenditionPixels = 4096 * 4096
+
+// Rendition is a resized copy of an image
+type Rendition struct {
+	// Size is the long edge of the rendition, in pixels
+	Size   int
+	Type   string
+	Width  int
+	Height int
+	Data   bytes.Buffer
+}
+
+// RenditionGenerator generates resized renditions of the uploaded images
+type RenditionGenerator struct {
+	sizes []int
+}
+
+// NewRenditionGenerator returns a new rendition generator for the given long edge sizes
+func NewRenditionGenerator(sizes ...int) *RenditionGenerator {
+	sorted := append([]int(nil), sizes...)
+	sort.Ints(sorted)
+
+	return &RenditionGenerator{
+		sizes: sorted,
+	}
+}
+
+// HasSize returns true if the generator makes renditions of the given long edge size
+func (generator *RenditionGenerator) HasSize(size int) bool {
+	i := sort.SearchInts(generator.sizes, size)
+	return i < len(generator.sizes) && generator.sizes[i] == size
+}
+
+// Generate decodes an image and returns its renditions.
+// Images are never upscaled, so there is no rendition for the sizes larger than the long edge of the image.
+// JPEG images give JPEG renditions, the other formats give PNG renditions.
+// The images with more than 4096x4096 pixels are rejected before they are decoded.
+func (generator *RenditionGenerator) Generate(imageType string, imageData io.Reader) ([]*Rendition, error) {
+	// the header read to check the dimensions is decoded again with the rest of the image
+	var header bytes.Buffer
+	config, _, err := image.DecodeConfig(io.TeeReader(imageData, &header))
+	if err != nil {
+		return nil, fmt.Errorf("cannot decode image config: %w", err)
+	}
+	if config.Width*config.Height > maxRenditionPixels {
+		return nil, fmt.Errorf("image of %dx%d pixels is too large for renditions", config.Width, config.Height)
+	}
+
+	src, format, err := image.Decode(io.MultiReader(&header, imageData))
+	if err != nil {
+		return nil, fmt.Errorf("cannot decode image: %w", err)
+	}
+
+	bounds := src.Bounds()
+	longEdge := max(bounds.Dx(), bounds.Dy())
+
+	var renditions []*Rendition
+	for _, size := range generator.sizes {
+		if size >= longEdge {
+			break
+		}
+
+		width := bounds.Dx() * size / longEdge
+		height := bounds.Dy() * size / longEdge
+		dst := image.NewRGBA(image.Rect(0, 0, max(width, 1), max(height, 1)))
+		draw.CatmullRom.Scale(dst, dst.Bounds(), src, bounds, draw.Src, nil)
+
+		rendition := &Rendition{
+			Size:   size,
+			Width:  dst.Bounds().Dx(),
+			Height: dst.Bounds().Dy(),
+		}
+
+		if format == "jpeg" {
+			rendition.Type = imageType
+			err = jpeg.Encode(&rendition.Data, dst, &jpeg.Options{Quality: 85})
+		} else {
+			rendition.Type = ".png"
+			err = png.Encode(&rendition.Data, dst)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("cannot encode rendition: %w", err)
+		}
+
+		renditions = append(renditions, rendition)
+	}
+
+	return renditions, nil
+}
@@ -0,0 +1,74 @@
+package service_test
+
+import (
+	"bytes"
+	"encoding/binary"
+	"hash/crc32"
+	"image"
+	"image/png"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"gitlab.com/brucemig/pcbook/service"
+)
+
+func TestRenditionGenerator(t *testing.T) {
+	t.Parallel()
+
+	generator := service.NewRenditionGenerator(512, 64, 128)
+	require.True(t, generator.HasSize(128))
+	require.False(t, generator.HasSize(100))
+
+	// JPEG images give JPEG renditions, and are never upscaled
+	jpegData, err := os.ReadFile("../tmp/laptop.jpeg")
+	require.NoError(t, err)
+
+	renditions, err := generator.Generate(".jpeg", bytes.NewReader(jpegData))
+	require.NoError(t, err)
+	require.Len(t, renditions, 2)
+
+	require.Equal(t, 64, renditions[0].Size)
+	require.Equal(t, ".jpeg", renditions[0].Type)
+	require.Equal(t, 64, renditions[0].Width)
+	require.Equal(t, 59, renditions[0].Height)
+
+	require.Equal(t, 128, renditions[1].Size)
+	config, format, err := image.DecodeConfig(&renditions[1].Data)
+	require.NoError(t, err)
+	require.Equal(t, "jpeg", format)
+	require.Equal(t, 128, config.Width)
+	require.Equal(t, 119, config.Height)
+
+	// the other formats give PNG renditions
+	pngData := bytes.Buffer{}
+	require.NoError(t, png.Encode(&pngData, image.NewRGBA(image.Rect(0, 0, 100, 300))))
+
+	renditions, err = generator.Generate(".png", &pngData)
+	require.NoError(t, err)
+	require.Len(t, renditions, 2)
+	require.Equal(t, ".png", renditions[1].Type)
+	require.Equal(t, 42, renditions[1].Width)
+	require.Equal(t, 128, renditions[1].Height)
+
+	_, err = generator.Generate(".png", bytes.NewBufferString("not an image"))
+	require.Error(t, err)
+
+	// a huge image is rejected from its header, before its pixels are decoded
+	_, err = generator.Generate(".png", bytes.NewReader(newTestPNGHeader(8192, 8192)))
+	require.ErrorContains(t, err, "too large")
+}
+
+// newTestPNGHeader returns the signature and header chunk of a PNG image of the given dimensions, without any pixel
+func newTestPNGHeader(width uint32, height uint32) []byte {
+	chunk := []byte("IHDR")
+	chunk = binary.BigEndian.AppendUint32(chunk, width)
+	chunk = binary.BigEndian.AppendUint32(chunk, height)
+	// 8 bits RGBA, default compression, filter and interlace methods
+	chunk = append(chunk, 8, 6, 0, 0, 0)
+
+	data := []byte("\x89PNG\r\n\x1a\n")
+	data = binary.BigEndian.AppendUint32(data, uint32(len(chunk)-4))
+	data = append(data, chunk...)
+	return binary.BigEndian.AppendUint32(data, crc32.ChecksumIEEE(chunk))
+}
@@ -1,73 +1,453 @@
 package service
 
 import (
-	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
 	"os"
+	"path/filepath"
+	"sort"
 	"sync"
+	"time"
 
 	"github.com/google/uuid"
 )
 
+// ErrQuotaExceeded is returned when saving an image would exceed a quota of the image store
+var ErrQuotaExceeded = errors.New("quota exceeded")
+
 // ImageStore is an interface to store laptop images
 type ImageStore interface {
-	// Save saves a new laptop image to the store
-	Save(laptopID string, imageType string, imageData bytes.Buffer) (string, error)
+	// Save reads a new laptop image until EOF, saves it to the store and returns its ID.
+	// The laptop ID, image type, uploader, dimensions and rendition are taken from info.
+	// If reading the image data fails, nothing is saved.
+	Save(info *ImageInfo, imageData io.Reader) (string, error)
+	// Open returns the info of an image and a reader of its data.
+	// It returns ErrNotFound if the image doesn't exist.
+	Open(imageID string) (*ImageInfo, io.ReadCloser, error)
+	// Stat returns the info of an image without reading its data.
+	// It returns ErrNotFound if the image doesn't exist.
+	Stat(imageID string) (*ImageInfo, error)
+	// List returns the info of all images of a laptop, renditions included, in upload order
+	List(laptopID string) ([]*ImageInfo, error)
+	// Delete deletes an image from the store.
+	// It returns ErrNotFound if the image doesn't exist.
+	Delete(imageID string) error
 }
 
-// DIskImageStore stores images on disk and its info on memory
+// DiskImageStore stores images on disk, each with a JSON metadata file.
+// The image data is stored in blob files named by their SHA-256 checksum,
+// so images with the same content share the same blob.
 type DiskImageStore struct {
 	mutex       sync.RWMutex
 	imageFolder string
 	images      map[string]*ImageInfo
+	// refs counts the images that point to each blob, by checksum
+	refs map[string]int
+	// usage is the total size of the blobs, in bytes
+	usage int64
+	// laptopUsage is the total size of the images of each laptop, renditions included, in bytes
+	laptopUsage map[string]int64
+	laptopQuota int64
+	totalQuota  int64
+}
+
+// DiskImageStoreOption configures a DiskImageStore
+type DiskImageStoreOption func(store *DiskImageStore)
+
+// WithDiskQuota limits the total size of the images of each laptop, renditions included,
+// and the total size of the blobs of the store, in bytes. A zero quota means no limit.
+// Save returns ErrQuotaExceeded if the image would exceed one of them.
+func WithDiskQuota(laptopQuota int64, totalQuota int64) DiskImageStoreOption {
+	return func(store *DiskImageStore) {
+		store.laptopQuota = laptopQuota
+		store.totalQuota = totalQuota
+	}
 }
 
 // ImageInfo contains information of the laptop image
 type ImageInfo struct {
-	LaptopID string
-	Type     string
-	Path     string
+	ID         string    `json:"id"`
+	LaptopID   string    `json:"laptop_id"`
+	Type       string    `json:"type"`
+	Size       int64     `json:"size"`
+	Checksum   string    `json:"checksum"`
+	UploadedAt time.Time `json:"uploaded_at"`
+	Uploader   string    `json:"uploader"`
+	Width      int       `json:"width"`
+	Height     int       `json:"height"`
+	// OriginalID is the ID of the image this image is a rendition of, or empty for an original image
+	OriginalID string `json:"original_id,omitempty"`
+	// Rendition is the long edge of the rendition, in pixels, or 0 for an original image
+	Rendition int    `json:"rendition,omitempty"`
+	Path      string `json:"-"`
 }
 
-// NewDiskImageStore returns a new DiskImageStore
-func NewDiskImageStore(imageFolder string) *DiskImageStore {
-	return &DiskImageStore{
+// metadataExt is the extension of the image metadata files
+const metadataExt = ".meta.json"
+
+// tmpExt is the extension of the files that are being written
+const tmpExt = ".tmp"
+
+// blobFolder is the folder of the image blobs, inside the image folder
+const blobFolder = "blobs"
+
+// NewDiskImageStore returns a new DiskImageStore.
+// It creates the image folder if needed, and loads the metadata of the images already in it.
+// The images saved next to their metadata file by older versions are moved to their blob.
+func NewDiskImageStore(imageFolder string, options ...DiskImageStoreOption) (*DiskImageStore, error) {
+	err := os.MkdirAll(filepath.Join(imageFolder, blobFolder), 0755)
+	if err != nil {
+		return nil, fmt.Errorf("cannot create image folder: %w", err)
+	}
+
+	store := &DiskImageStore{
 		imageFolder: imageFolder,
 		images:      make(map[string]*ImageInfo),
+		refs:        make(map[string]int),
+		laptopUsage: make(map[string]int64),
+	}
+
+	for _, option := range options {
+		option(store)
+	}
+
+	// files that were being written when the server stopped are incomplete
+	tmpFiles, err := filepath.Glob(filepath.Join(imageFolder, "*"+tmpExt))
+	if err != nil {
+		return nil, fmt.Errorf("cannot list temporary files: %w", err)
 	}
+
+	for _, tmpFile := range tmpFiles {
+		err := os.Remove(tmpFile)
+		if err != nil {
+			return nil, fmt.Errorf("cannot delete temporary file: %w", err)
+		}
+	}
+
+	metadataFiles, err := filepath.Glob(filepath.Join(imageFolder, "*"+metadataExt))
+	if err != nil {
+		return nil, fmt.Errorf("cannot list image metadata files: %w", err)
+	}
+
+	for _, metadataFile := range metadataFiles {
+		info, err := readImageMetadata(metadataFile)
+		if err != nil {
+			return nil, err
+		}
+
+		info.Path = store.blobPath(info.Checksum)
+		err = store.migrateImage(info)
+		if err != nil {
+			return nil, err
+		}
+
+		store.add(info)
+	}
+
+	return store, nil
 }
 
-// Save saves a new laptop image to the store
+// migrateImage moves an image saved next to its metadata file to its blob
+func (store *DiskImageStore) migrateImage(info *ImageInfo) error {
+	legacyPath := filepath.Join(store.imageFolder, info.ID+info.Type)
+	if _, err := os.Stat(legacyPath); os.IsNotExist(err) {
+		return nil
+	}
+
+	if _, err := os.Stat(info.Path); err == nil {
+		// the blob already exists, another image has the same content
+		err = os.Remove(legacyPath)
+		if err != nil {
+			return fmt.Errorf("cannot delete image file: %w", err)
+		}
+		return nil
+	}
+
+	err := os.Rename(legacyPath, info.Path)
+	if err != nil {
+		return fmt.Errorf("cannot move image file to its blob: %w", err)
+	}
+	return nil
+}
+
+// Save writes a new laptop image to a temporary file, which becomes the blob of the image
+// once the whole image is written. If a blob with the same checksum already exists,
+// the temporary file is deleted and the image points to the existing blob.
 func (store *DiskImageStore) Save(
-	laptopID string,
-	imageType string,
-	imageData bytes.Buffer,
+	info *ImageInfo,
+	imageData io.Reader,
 ) (string, error) {
 	imageID, err := uuid.NewRandom()
 	if err != nil {
 		return "", fmt.Errorf("cannot generate image id: %w", err)
 	}
 
-	imagePath := fmt.Sprintf("%s/%s%s", store.imageFolder, imageID, imageType)
-
-	file, err := os.Create(imagePath)
+	file, err := os.CreateTemp(store.imageFolder, "upload-*"+tmpExt)
 	if err != nil {
 		return "", fmt.Errorf("cannot create image file: %w", err)
 	}
+	defer os.Remove(file.Name())
+	defer file.Close()
+
+	// the upload is aborted as soon as it exceeds the laptop quota, without reading the rest of the image
+	maxSize := store.laptopRemaining(info.LaptopID)
+	if maxSize >= 0 {
+		imageData = io.LimitReader(imageData, maxSize+1)
+	}
+
+	hash := sha256.New()
+	size, err := io.Copy(io.MultiWriter(file, hash), imageData)
+	if err != nil {
+		return "", fmt.Errorf("cannot write image to file: %w", err)
+	}
+	if maxSize >= 0 && size > maxSize {
+		return "", fmt.Errorf("%w: laptop %s has %d bytes left", ErrQuotaExceeded, info.LaptopID, maxSize)
+	}
 
-	_, err = imageData.WriteTo(file)
+	err = file.Close()
 	if err != nil {
 		return "", fmt.Errorf("cannot write image to file: %w", err)
 	}
 
+	checksum := hex.EncodeToString(hash.Sum(nil))
+	other := &ImageInfo{
+		ID:         imageID.String(),
+		LaptopID:   info.LaptopID,
+		Type:       info.Type,
+		Size:       size,
+		Checksum:   checksum,
+		UploadedAt: time.Now().UTC(),
+		Uploader:   info.Uploader,
+		Width:      info.Width,
+		Height:     info.Height,
+		OriginalID: info.OriginalID,
+		Rendition:  info.Rendition,
+		Path:       store.blobPath(checksum),
+	}
+
+	// the blob must not be deleted by another image between the check of its references and the new one
 	store.mutex.Lock()
 	defer store.mutex.Unlock()
 
-	store.images[imageID.String()] = &ImageInfo{
-		LaptopID: laptopID,
-		Type:     imageType,
-		Path:     imagePath,
+	// other uploads may have used the quotas while the image was being written
+	err = store.checkQuota(other)
+	if err != nil {
+		return "", err
+	}
+
+	if store.refs[checksum] == 0 {
+		err = os.Rename(file.Name(), other.Path)
+		if err != nil {
+			return "", fmt.Errorf("cannot rename image file: %w", err)
+		}
 	}
 
-	return imageID.String(), nil
+	err = writeImageMetadata(store.metadataPath(other.ID), other)
+	if err != nil {
+		if store.refs[checksum] == 0 {
+			os.Remove(other.Path)
+		}
+		return "", err
+	}
+
+	store.add(other)
+	return other.ID, nil
+}
+
+// Open returns the info of an image and a reader of its data.
+// The blob is opened under the lock, so that a concurrent Delete can't remove it in between,
+// and the open file stays readable after its blob is deleted.
+func (store *DiskImageStore) Open(imageID string) (*ImageInfo, io.ReadCloser, error) {
+	store.mutex.RLock()
+	defer store.mutex.RUnlock()
+
+	info := store.images[imageID]
+	if info == nil {
+		return nil, nil, ErrNotFound
+	}
+
+	file, err := os.Open(info.Path)
+	if os.IsNotExist(err) {
+		return nil, nil, fmt.Errorf("%w: image file of %s", ErrNotFound, imageID)
+	}
+	if err != nil {
+		return nil, nil, fmt.Errorf("cannot open image file: %w", err)
+	}
+
+	other := *info
+	return &other, file, nil
+}
+
+// Stat returns the info of an image
+func (store *DiskImageStore) Stat(imageID string) (*ImageInfo, error) {
+	store.mutex.RLock()
+	defer store.mutex.RUnlock()
+
+	info := store.images[imageID]
+	if info == nil {
+		return nil, ErrNotFound
+	}
+
+	other := *info
+	return &other, nil
+}
+
+// List returns the info of all images of a laptop, in upload order
+func (store *DiskImageStore) List(laptopID string) ([]*ImageInfo, error) {
+	store.mutex.RLock()
+	defer store.mutex.RUnlock()
+
+	var images []*ImageInfo
+	for _, info := range store.images {
+		if info.LaptopID == laptopID {
+			other := *info
+			images = append(images, &other)
+		}
+	}
+
+	sortImages(images)
+	return images, nil
+}
+
+// Delete deletes the metadata of an image from the disk,
+// and its blob if no other image points to it
+func (store *DiskImageStore) Delete(imageID string) error {
+	store.mutex.Lock()
+	defer store.mutex.Unlock()
+
+	info := store.images[imageID]
+	if info == nil {
+		return ErrNotFound
+	}
+
+	err := os.Remove(store.metadataPath(imageID))
+	if err != nil {
+		return fmt.Errorf("cannot delete image metadata file: %w", err)
+	}
+
+	if !store.remove(info) {
+		return nil
+	}
+
+	err = os.Remove(info.Path)
+	if err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("cannot delete image file: %w", err)
+	}
+	return nil
+}
+
+// add adds an image to the indexes and usages of the store.
+// The caller must hold the store mutex.
+func (store *DiskImageStore) add(info *ImageInfo) {
+	if store.refs[info.Checksum] == 0 {
+		store.usage += info.Size
+	}
+	store.images[info.ID] = info
+	store.refs[info.Checksum]++
+	store.laptopUsage[info.LaptopID] += info.Size
+}
+
+// remove removes an image from the indexes and usages of the store,
+// and returns true if no other image points to its blob anymore.
+// The caller must hold the store mutex.
+func (store *DiskImageStore) remove(info *ImageInfo) bool {
+	delete(store.images, info.ID)
+
+	store.laptopUsage[info.LaptopID] -= info.Size
+	if store.laptopUsage[info.LaptopID] <= 0 {
+		delete(store.laptopUsage, info.LaptopID)
+	}
+
+	store.refs[info.Checksum]--
+	if store.refs[info.Checksum] > 0 {
+		return false
+	}
+
+	delete(store.refs, info.Checksum)
+	store.usage -= info.Size
+	return true
+}
+
+// laptopRemaining returns the number of bytes left in the quota of a laptop, or -1 if there is no quota
+func (store *DiskImageStore) laptopRemaining(laptopID string) int64 {
+	if store.laptopQuota <= 0 {
+		return -1
+	}
+
+	store.mutex.RLock()
+	defer store.mutex.RUnlock()
+
+	return max(store.laptopQuota-store.laptopUsage[laptopID], 0)
+}
+
+// checkQuota returns ErrQuotaExceeded if adding an image would exceed a quota.
+// An image whose blob already exists doesn't use more of the total quota.
+// The caller must hold the store mutex.
+func (store *DiskImageStore) checkQuota(info *ImageInfo) error {
+	if store.laptopQuota > 0 && store.laptopUsage[info.LaptopID]+info.Size > store.laptopQuota {
+		return fmt.Errorf("%w: laptop %s uses %d of %d bytes", ErrQuotaExceeded, info.LaptopID, store.laptopUsage[info.LaptopID], store.laptopQuota)
+	}
+
+	if store.totalQuota > 0 && store.refs[info.Checksum] == 0 && store.usage+info.Size > store.totalQuota {
+		return fmt.Errorf("%w: the store uses %d of %d bytes", ErrQuotaExceeded, store.usage, store.totalQuota)
+	}
+	return nil
+}
+
+// sortImages sorts images in upload order, and images uploaded at the same time by ID,
+// so that the order is the same for every call
+func sortImages(images []*ImageInfo) {
+	sort.SliceStable(images, func(i, j int) bool {
+		if !images[i].UploadedAt.Equal(images[j].UploadedAt) {
+			return images[i].UploadedAt.Before(images[j].UploadedAt)
+		}
+		return images[i].ID < images[j].ID
+	})
+}
+
+func (store *DiskImageStore) blobPath(checksum string) string {
+	return filepath.Join(store.imageFolder, blobFolder, checksum)
+}
+
+func (store *DiskImageStore) metadataPath(imageID string) string {
+	return filepath.Join(store.imageFolder, imageID+metadataExt)
+}
+
+func readImageMetadata(filename string) (*ImageInfo, error) {
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		return nil, fmt.Errorf("cannot read image metadata file: %w", err)
+	}
+
+	info := &ImageInfo{}
+	err = json.Unmarshal(data, info)
+	if err != nil {
+		return nil, fmt.Errorf("cannot parse image metadata file %s: %w", filename, err)
+	}
+	return info, nil
+}
+
+// writeImageMetadata writes the metadata to a temporary file first,
+// so that a crash never leaves a half-written metadata file behind
+func writeImageMetadata(filename string, info *ImageInfo) error {
+	data, err := json.MarshalIndent(info, "", "  ")
+	if err != nil {
+		return fmt.Errorf("cannot marshal image metadata: %w", err)
+	}
+
+	tmpFilename := filename + tmpExt
+	err = os.WriteFile(tmpFilename, data, 0644)
+	if err != nil {
+		return fmt.Errorf("cannot write image metadata file: %w", err)
+	}
+
+	err = os.Rename(tmpFilename, filename)
+	if err != nil {
+		return fmt.Errorf("cannot rename image metadata file: %w", err)
+	}
+	return nil
 }
@@ -0,0 +1,214 @@
+package service_test
+
+import (
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"testing/iotest"
+
+	"github.com/stretchr/testify/require"
+	"gitlab.com/brucemig/pcbook/sample"
+	"gitlab.com/brucemig/pcbook/service"
+)
+
+func TestDiskImageStoreReload(t *testing.T) {
+	t.Parallel()
+
+	imageFolder := t.TempDir()
+	laptopID := sample.NewLaptop().GetId()
+
+	store, err := service.NewDiskImageStore(imageFolder)
+	require.NoError(t, err)
+
+	info := &service.ImageInfo{LaptopID: laptopID, Type: ".png", Uploader: "admin1"}
+	imageID, err := store.Save(info, strings.NewReader("image data"))
+	require.NoError(t, err)
+
+	deletedID, err := store.Save(info, strings.NewReader("deleted"))
+	require.NoError(t, err)
+	require.NoError(t, store.Delete(deletedID))
+
+	saved, imageData, err := store.Open(imageID)
+	require.NoError(t, err)
+	require.NoError(t, imageData.Close())
+
+	// a new store on the same folder knows about the saved images
+	reloaded, err := service.NewDiskImageStore(imageFolder)
+	require.NoError(t, err)
+
+	images, err := reloaded.List(laptopID)
+	require.NoError(t, err)
+	require.Len(t, images, 1)
+	require.Equal(t, saved, images[0])
+
+	_, imageData, err = reloaded.Open(imageID)
+	require.NoError(t, err)
+	defer imageData.Close()
+
+	data, err := io.ReadAll(imageData)
+	require.NoError(t, err)
+	require.Equal(t, "image data", string(data))
+}
+
+func TestDiskImageStoreSaveReadError(t *testing.T) {
+	t.Parallel()
+
+	imageFolder := t.TempDir()
+	store, err := service.NewDiskImageStore(imageFolder)
+	require.NoError(t, err)
+
+	imageData := io.MultiReader(strings.NewReader("partial data"), iotest.ErrReader(errors.New("connection lost")))
+	_, err = store.Save(&service.ImageInfo{LaptopID: "laptop", Type: ".png"}, imageData)
+	require.Error(t, err)
+
+	// the partial image is not left behind
+	requireNoFiles(t, imageFolder)
+
+	// nor are the temporary files of a server that stopped while writing
+	require.NoError(t, os.WriteFile(filepath.Join(imageFolder, "upload-123.tmp"), []byte("partial"), 0644))
+
+	_, err = service.NewDiskImageStore(imageFolder)
+	require.NoError(t, err)
+	requireNoFiles(t, imageFolder)
+}
+
+func TestDiskImageStoreDeduplication(t *testing.T) {
+	t.Parallel()
+
+	imageFolder := t.TempDir()
+	store, err := service.NewDiskImageStore(imageFolder)
+	require.NoError(t, err)
+
+	imageID1, err := store.Save(&service.ImageInfo{LaptopID: "laptop1", Type: ".png"}, strings.NewReader("product shot"))
+	require.NoError(t, err)
+
+	imageID2, err := store.Save(&service.ImageInfo{LaptopID: "laptop2", Type: ".png"}, strings.NewReader("product shot"))
+	require.NoError(t, err)
+	require.NotEqual(t, imageID1, imageID2)
+
+	info1, imageData, err := store.Open(imageID1)
+	require.NoError(t, err)
+	require.NoError(t, imageData.Close())
+
+	info2, imageData, err := store.Open(imageID2)
+	require.NoError(t, err)
+	require.NoError(t, imageData.Close())
+
+	// both images point to the same blob
+	require.Equal(t, info1.Checksum, info2.Checksum)
+	require.Equal(t, info1.Path, info2.Path)
+
+	blobs, err := filepath.Glob(filepath.Join(imageFolder, "blobs", "*"))
+	require.NoError(t, err)
+	require.Equal(t, []string{info1.Path}, blobs)
+
+	// the blob is kept until its last image is deleted, even after a reload
+	require.NoError(t, store.Delete(imageID1))
+	require.FileExists(t, info1.Path)
+
+	store, err = service.NewDiskImageStore(imageFolder)
+	require.NoError(t, err)
+
+	_, imageData, err = store.Open(imageID2)
+	require.NoError(t, err)
+	data, err := io.ReadAll(imageData)
+	require.NoError(t, err)
+	require.NoError(t, imageData.Close())
+	require.Equal(t, "product shot", string(data))
+
+	require.NoError(t, store.Delete(imageID2))
+	requireNoFiles(t, imageFolder)
+}
+
+func TestDiskImageStoreMigration(t *testing.T) {
+	t.Parallel()
+
+	imageFolder := t.TempDir()
+
+	// an image saved next to its metadata file by an older version
+	checksum := sha256.Sum256([]byte("legacy image"))
+	metadata := fmt.Sprintf(`{"id": "legacy", "laptop_id": "laptop", "type": ".png", "size": 12, "checksum": "%x"}`, checksum)
+	require.NoError(t, os.WriteFile(filepath.Join(imageFolder, "legacy.meta.json"), []byte(metadata), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(imageFolder, "legacy.png"), []byte("legacy image"), 0644))
+
+	store, err := service.NewDiskImageStore(imageFolder)
+	require.NoError(t, err)
+	require.NoFileExists(t, filepath.Join(imageFolder, "legacy.png"))
+
+	_, imageData, err := store.Open("legacy")
+	require.NoError(t, err)
+	defer imageData.Close()
+
+	data, err := io.ReadAll(imageData)
+	require.NoError(t, err)
+	require.Equal(t, "legacy image", string(data))
+}
+
+// requireNoFiles checks that a folder and its subfolders don't contain any file
+func TestDiskImageStoreQuota(t *testing.T) {
+	t.Parallel()
+
+	imageFolder := t.TempDir()
+	store, err := service.NewDiskImageStore(imageFolder, service.WithDiskQuota(10, 25))
+	require.NoError(t, err)
+
+	laptopID1 := sample.NewLaptop().GetId()
+	laptopID2 := sample.NewLaptop().GetId()
+	laptopID3 := sample.NewLaptop().GetId()
+
+	imageID, err := store.Save(&service.ImageInfo{LaptopID: laptopID1, Type: ".png"}, strings.NewReader("123456"))
+	require.NoError(t, err)
+
+	// the laptop quota is exceeded while the image is being written
+	_, err = store.Save(&service.ImageInfo{LaptopID: laptopID1, Type: ".png"}, strings.NewReader("12345"))
+	require.ErrorIs(t, err, service.ErrQuotaExceeded)
+
+	tmpFiles, err := filepath.Glob(filepath.Join(imageFolder, "*.tmp"))
+	require.NoError(t, err)
+	require.Empty(t, tmpFiles)
+
+	// a shared blob counts for each laptop, but only once for the store
+	_, err = store.Save(&service.ImageInfo{LaptopID: laptopID2, Type: ".png"}, strings.NewReader("123456"))
+	require.NoError(t, err)
+	_, err = store.Save(&service.ImageInfo{LaptopID: laptopID2, Type: ".png"}, strings.NewReader("abcdefghij"))
+	require.ErrorIs(t, err, service.ErrQuotaExceeded)
+
+	_, err = store.Save(&service.ImageInfo{LaptopID: laptopID3, Type: ".png"}, strings.NewReader("abcdefghij"))
+	require.NoError(t, err)
+	_, err = store.Save(&service.ImageInfo{LaptopID: laptopID1, Type: ".png"}, strings.NewReader("ABCD"))
+	require.NoError(t, err)
+
+	// the store quota is exceeded by a new blob only
+	laptopID4 := sample.NewLaptop().GetId()
+	_, err = store.Save(&service.ImageInfo{LaptopID: laptopID4, Type: ".png"}, strings.NewReader("ABCDEFGHIJ"))
+	require.ErrorIs(t, err, service.ErrQuotaExceeded)
+	_, err = store.Save(&service.ImageInfo{LaptopID: laptopID4, Type: ".png"}, strings.NewReader("abcdefghij"))
+	require.NoError(t, err)
+
+	// the usages are rebuilt from the metadata
+	store, err = service.NewDiskImageStore(imageFolder, service.WithDiskQuota(10, 25))
+	require.NoError(t, err)
+
+	_, err = store.Save(&service.ImageInfo{LaptopID: laptopID1, Type: ".png"}, strings.NewReader("x"))
+	require.ErrorIs(t, err, service.ErrQuotaExceeded)
+
+	// deleting an image frees its quota
+	require.NoError(t, store.Delete(imageID))
+	_, err = store.Save(&service.ImageInfo{LaptopID: laptopID1, Type: ".png"}, strings.NewReader("x"))
+	require.NoError(t, err)
+}
+
+func requireNoFiles(t *testing.T, folder string) {
+	err := filepath.WalkDir(folder, func(path string, entry fs.DirEntry, err error) error {
+		require.NoError(t, err)
+		require.True(t, entry.IsDir(), "unexpected file %s", path)
+		return nil
+	})
+	require.NoError(t, err)
+}
@@ -0,0 +1,81 @@
+package service
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"image"
+	"net/http"
+	"strings"
+
+	// register the decoders of the supported image formats
+	_ "image/gif"
+	_ "image/jpeg"
+	_ "image/png"
+
+	_ "golang.org/x/image/webp"
+)
+
+// ErrInvalidImage is returned when the image data doesn't match its image type, or can't be decoded
+var ErrInvalidImage = errors.New("invalid image")
+
+// imageFormats maps the supported image types (file extensions) to their format and MIME type
+var imageFormats = map[string]struct {
+	format   string
+	mimeType string
+}{
+	".jpg":  {"jpeg", "image/jpeg"},
+	".jpeg": {"jpeg", "image/jpeg"},
+	".png":  {"png", "image/png"},
+	".gif":  {"gif", "image/gif"},
+	".webp": {"webp", "image/webp"},
+}
+
+// ImageValidator checks that uploaded image data is really an image of its declared type
+type ImageValidator struct {
+	maxWidth  int
+	maxHeight int
+}
+
+// NewImageValidator returns a new image validator that accepts images up to maxWidth x maxHeight pixels
+func NewImageValidator(maxWidth int, maxHeight int) *ImageValidator {
+	return &ImageValidator{
+		maxWidth:  maxWidth,
+		maxHeight: maxHeight,
+	}
+}
+
+// Validate sniffs the magic bytes of the image data and decodes its header.
+// It returns the width and height of the image, or an error wrapping ErrInvalidImage
+// if the data doesn't match the image type, can't be decoded or is too large.
+func (validator *ImageValidator) Validate(imageType string, imageData []byte) (int, int, error) {
+	expected, ok := imageFormats[strings.ToLower(imageType)]
+	if !ok {
+		return 0, 0, fmt.Errorf("%w: unsupported image type %q", ErrInvalidImage, imageType)
+	}
+
+	mimeType := http.DetectContentType(imageData)
+	if mimeType != expected.mimeType {
+		return 0, 0, fmt.Errorf("%w: image data is %s, not %s", ErrInvalidImage, mimeType, expected.mimeType)
+	}
+
+	config, format, err := image.DecodeConfig(bytes.NewReader(imageData))
+	if err != nil {
+		return 0, 0, fmt.Errorf("%w: cannot decode image: %v", ErrInvalidImage, err)
+	}
+	if format != expected.format {
+		return 0, 0, fmt.Errorf("%w: image data is %s, not %s", ErrInvalidImage, format, expected.format)
+	}
+
+	if config.Width <= 0 || config.Height <= 0 {
+		return 0, 0, fmt.Errorf("%w: image is empty", ErrInvalidImage)
+	}
+	if config.Width > validator.maxWidth || config.Height > validator.maxHeight {
+		return 0, 0, fmt.Errorf(
+			"%w: image is too large: %dx%d > %dx%d",
+			ErrInvalidImage, config.Width, config.Height, validator.maxWidth, validator.maxHeight,
+		)
+	}
+
+	return config.Width, config.Height, nil
+}
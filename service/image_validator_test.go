@@ -0,0 +1,75 @@
+package service_test
+
+import (
+	"bytes"
+	"encoding/base64"
+	"image"
+	"image/gif"
+	"image/png"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"gitlab.com/brucemig/pcbook/service"
+)
+
+// a 1x1 lossless WebP image
+const webpImage = "UklGRhoAAABXRUJQVlA4TA0AAAAvAAAAEAcQERGIiP4HAA=="
+
+func TestImageValidator(t *testing.T) {
+	t.Parallel()
+
+	jpegData, err := os.ReadFile("../tmp/laptop.jpeg")
+	require.NoError(t, err)
+
+	pngData := bytes.Buffer{}
+	require.NoError(t, png.Encode(&pngData, image.NewRGBA(image.Rect(0, 0, 30, 20))))
+
+	largeData := bytes.Buffer{}
+	require.NoError(t, png.Encode(&largeData, image.NewRGBA(image.Rect(0, 0, 300, 20))))
+
+	gifData := bytes.Buffer{}
+	require.NoError(t, gif.Encode(&gifData, image.NewRGBA(image.Rect(0, 0, 10, 10)), nil))
+
+	webpData, err := base64.StdEncoding.DecodeString(webpImage)
+	require.NoError(t, err)
+
+	testCases := []struct {
+		name      string
+		imageType string
+		imageData []byte
+		width     int
+		height    int
+		valid     bool
+	}{
+		{"jpeg", ".jpeg", jpegData, 233, 217, true},
+		{"jpg", ".JPG", jpegData, 233, 217, true},
+		{"png", ".png", pngData.Bytes(), 30, 20, true},
+		{"gif", ".gif", gifData.Bytes(), 10, 10, true},
+		{"webp", ".webp", webpData, 1, 1, true},
+		{"type_mismatch", ".jpeg", pngData.Bytes(), 0, 0, false},
+		{"unsupported_type", ".exe", jpegData, 0, 0, false},
+		{"not_an_image", ".png", []byte("MZ\x90\x00 this is an executable"), 0, 0, false},
+		{"truncated_header", ".png", pngData.Bytes()[:20], 0, 0, false},
+		{"too_large", ".png", largeData.Bytes(), 0, 0, false},
+	}
+
+	validator := service.NewImageValidator(240, 220)
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			width, height, err := validator.Validate(tc.imageType, tc.imageData)
+			if tc.valid {
+				require.NoError(t, err)
+				require.Equal(t, tc.width, width)
+				require.Equal(t, tc.height, height)
+			} else {
+				require.ErrorIs(t, err, service.ErrInvalidImage)
+			}
+		})
+	}
+}
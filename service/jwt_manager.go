@@ -29,7 +29,7 @@ func NewJWTManager(secretKey string, tokenDuration time.Duration) *JWTManager {
 func (manager *JWTManager) Generate(user *User) (string, error) {
 	claims := UserClaims{
 		StandardClaims: jwt.StandardClaims{
-			ExpiresAt: &jwt.Time{time.Now().Add(manager.tokenDuration)},
+			ExpiresAt: &jwt.Time{Time: time.Now().Add(manager.tokenDuration)},
 		},
 		Username: user.Username,
 		Role:     user.Role,
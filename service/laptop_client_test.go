@@ -2,21 +2,33 @@ package service_test
 
 import (
 	"bufio"
+	"bytes"
 	"context"
+	"crypto/sha256"
+	"encoding/hex"
 	"fmt"
+	"image"
 	"io"
+	"math"
 	"net"
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/require"
 	"gitlab.com/brucemig/pcbook/pb"
 	"gitlab.com/brucemig/pcbook/sample"
 	"gitlab.com/brucemig/pcbook/serializer"
 	"gitlab.com/brucemig/pcbook/service"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
 )
 
 func TestClientCreateLaptop(t *testing.T) {
@@ -46,8 +58,16 @@ func TestClientCreateLaptop(t *testing.T) {
 	requireSameLaptop(t, laptop, other)
 }
 
-func startTestLaptopServer(t *testing.T, laptopStore service.LaptopStore, imageStore service.ImageStore, ratingStore service.RatingStore) string {
-	laptopServer := service.NewLaptopServer(laptopStore, imageStore, ratingStore)
+func startTestLaptopServer(
+	t testing.TB,
+	laptopStore service.LaptopStore,
+	imageStore service.ImageStore,
+	ratingStore service.RatingStore,
+	options ...service.LaptopServerOption,
+) string {
+	laptopServer := service.NewLaptopServer(laptopStore, imageStore, ratingStore, options...)
+	// the renditions must not be saved once the test folders are deleted
+	t.Cleanup(laptopServer.Close)
 
 	grpcServer := grpc.NewServer()
 	pb.RegisterLaptopServiceServer(grpcServer, laptopServer)
@@ -60,12 +80,58 @@ func startTestLaptopServer(t *testing.T, laptopStore service.LaptopStore, imageS
 	return listener.Addr().String()
 }
 
-func newTestLaptopClient(t *testing.T, serverAddress string) pb.LaptopServiceClient {
+// testSecretKey signs the access tokens of the servers started by startTestAuthLaptopServer
+const testSecretKey = "secret"
+
+// startTestAuthLaptopServer starts a laptop server that authorizes the users with their access token.
+// The methods of accessibleRoles require an access token returned by newTestAccessToken.
+func startTestAuthLaptopServer(t testing.TB, laptopServer pb.LaptopServiceServer, accessibleRoles map[string][]string) string {
+	if server, ok := laptopServer.(*service.LaptopServer); ok {
+		t.Cleanup(server.Close)
+	}
+
+	interceptor := service.NewAuthInterceptor(service.NewJWTManager(testSecretKey, time.Minute), accessibleRoles)
+	grpcServer := grpc.NewServer(
+		grpc.UnaryInterceptor(interceptor.Unary()),
+		grpc.StreamInterceptor(interceptor.Stream()),
+	)
+	pb.RegisterLaptopServiceServer(grpcServer, laptopServer)
+
+	listener, err := net.Listen("tcp", ":0") // random available port
+	require.NoError(t, err)
+
+	go grpcServer.Serve(listener)
+	t.Cleanup(grpcServer.Stop)
+
+	return listener.Addr().String()
+}
+
+func newTestAccessToken(t testing.TB, username string, role string) string {
+	user, err := service.NewUser(username, "secret", role)
+	require.NoError(t, err)
+
+	accessToken, err := service.NewJWTManager(testSecretKey, time.Minute).Generate(user)
+	require.NoError(t, err)
+	return accessToken
+}
+
+// withAccessToken returns a context that sends the access token to the server
+func withAccessToken(accessToken string) context.Context {
+	return metadata.AppendToOutgoingContext(context.Background(), "authorization", accessToken)
+}
+
+func newTestLaptopClient(t testing.TB, serverAddress string) pb.LaptopServiceClient {
 	conn, err := grpc.Dial(serverAddress, grpc.WithTransportCredentials(insecure.NewCredentials()))
 	require.NoError(t, err)
 	return pb.NewLaptopServiceClient(conn)
 }
 
+func newTestDiskImageStore(t *testing.T) *service.DiskImageStore {
+	imageStore, err := service.NewDiskImageStore(t.TempDir())
+	require.NoError(t, err)
+	return imageStore
+}
+
 func requireSameLaptop(t *testing.T, laptop1 *pb.Laptop, laptop2 *pb.Laptop) {
 	json1, err := serializer.ProtobufToJSON(laptop1)
 	require.NoError(t, err)
@@ -148,12 +214,14 @@ func TestClientUploadImage(t *testing.T) {
 	t.Parallel()
 
 	testImageFolder := "../tmp"
+	imageFolder := t.TempDir()
 
 	laptopStore := service.NewInMemoryLaptopStore()
-	imageStore := service.NewDiskImageStore(testImageFolder)
+	imageStore, err := service.NewDiskImageStore(imageFolder)
+	require.NoError(t, err)
 
 	laptop := sample.NewLaptop()
-	err := laptopStore.Save(laptop)
+	err = laptopStore.Save(laptop)
 	require.NoError(t, err)
 
 	serverAddress := startTestLaptopServer(t, laptopStore, imageStore, nil)
@@ -208,9 +276,295 @@ func TestClientUploadImage(t *testing.T) {
 	require.NotZero(t, res.GetId())
 	require.EqualValues(t, size, res.GetSize())
 
-	savedImagePath := fmt.Sprintf("%s/%s%s", testImageFolder, res.GetId(), imageType)
-	require.FileExists(t, savedImagePath)
-	require.NoError(t, os.Remove(savedImagePath))
+	images := waitTestImages(t, imageStore, laptop.GetId(), 2)
+	require.Equal(t, res.GetId(), images[0].ID)
+	require.FileExists(t, images[0].Path)
+	require.EqualValues(t, size, images[0].Size)
+	require.Equal(t, 233, images[0].Width)
+	require.Equal(t, 217, images[0].Height)
+
+	// the image is smaller than the larger default renditions
+	require.Equal(t, res.GetId(), images[1].OriginalID)
+	require.Equal(t, 128, images[1].Rendition)
+	require.Equal(t, 128, images[1].Width)
+	require.Equal(t, 119, images[1].Height)
+}
+
+func TestClientUploadInvalidImage(t *testing.T) {
+	t.Parallel()
+
+	laptopStore := service.NewInMemoryLaptopStore()
+	imageStore := newTestDiskImageStore(t)
+
+	laptop := sample.NewLaptop()
+	err := laptopStore.Save(laptop)
+	require.NoError(t, err)
+
+	serverAddress := startTestLaptopServer(t, laptopStore, imageStore, nil)
+	laptopClient := newTestLaptopClient(t, serverAddress)
+
+	stream, err := laptopClient.UploadImage(context.Background())
+	require.NoError(t, err)
+
+	req := &pb.UploadImageRequest{
+		Data: &pb.UploadImageRequest_Info{
+			Info: &pb.ImageInfo{
+				LaptopId:  laptop.GetId(),
+				ImageType: ".jpeg",
+			},
+		},
+	}
+	require.NoError(t, stream.Send(req))
+
+	req = &pb.UploadImageRequest{
+		Data: &pb.UploadImageRequest_ChunkData{
+			ChunkData: []byte("MZ\x90\x00 this is an executable"),
+		},
+	}
+	require.NoError(t, stream.Send(req))
+
+	_, err = stream.CloseAndRecv()
+	require.Equal(t, codes.InvalidArgument, status.Code(err))
+
+	images, err := imageStore.List(laptop.GetId())
+	require.NoError(t, err)
+	require.Empty(t, images)
+}
+
+func TestClientUploadImageLimits(t *testing.T) {
+	t.Parallel()
+
+	laptopStore := service.NewInMemoryLaptopStore()
+	imageFolder := t.TempDir()
+	imageStore, err := service.NewDiskImageStore(imageFolder)
+	require.NoError(t, err)
+
+	laptop := sample.NewLaptop()
+	require.NoError(t, laptopStore.Save(laptop))
+
+	imageData, err := os.ReadFile("../tmp/laptop.jpeg")
+	require.NoError(t, err)
+
+	serverAddress := startTestLaptopServer(
+		t, laptopStore, imageStore, nil,
+		service.WithMaxImageSize(int64(len(imageData))),
+		service.WithMaxConcurrentUploads(1),
+	)
+	laptopClient := newTestLaptopClient(t, serverAddress)
+
+	// the image is too large by one byte, and is never saved
+	_, err = sendTestImage(laptopClient, laptop.GetId(), ".jpeg", append(imageData, 0))
+	require.Equal(t, codes.InvalidArgument, status.Code(err))
+
+	requireNoFiles(t, imageFolder)
+
+	// an upload is in progress while the others are rejected
+	stream, err := laptopClient.UploadImage(context.Background())
+	require.NoError(t, err)
+	require.NoError(t, stream.Send(&pb.UploadImageRequest{
+		Data: &pb.UploadImageRequest_Info{
+			Info: &pb.ImageInfo{LaptopId: laptop.GetId(), ImageType: ".jpeg"},
+		},
+	}))
+
+	require.Eventually(t, func() bool {
+		_, err := sendTestImage(laptopClient, laptop.GetId(), ".jpeg", nil)
+		return status.Code(err) == codes.ResourceExhausted
+	}, time.Second, 10*time.Millisecond)
+
+	require.NoError(t, stream.Send(&pb.UploadImageRequest{
+		Data: &pb.UploadImageRequest_ChunkData{ChunkData: imageData},
+	}))
+	_, err = stream.CloseAndRecv()
+	require.NoError(t, err)
+
+	// the upload is finished, so there is room for a new one
+	_, err = sendTestImage(laptopClient, laptop.GetId(), ".jpeg", imageData)
+	require.NoError(t, err)
+
+	// the open upload sessions count against the concurrent uploads too
+	startRequest := &pb.StartUploadRequest{Info: &pb.ImageInfo{LaptopId: laptop.GetId(), ImageType: ".jpeg"}}
+	_, err = laptopClient.StartUpload(context.Background(), startRequest)
+	require.NoError(t, err)
+	_, err = laptopClient.StartUpload(context.Background(), startRequest)
+	require.Equal(t, codes.ResourceExhausted, status.Code(err))
+}
+
+func TestClientUploadImageQuota(t *testing.T) {
+	t.Parallel()
+
+	laptopStore := service.NewInMemoryLaptopStore()
+	laptop1 := sample.NewLaptop()
+	require.NoError(t, laptopStore.Save(laptop1))
+	laptop2 := sample.NewLaptop()
+	require.NoError(t, laptopStore.Save(laptop2))
+
+	imageData, err := os.ReadFile("../tmp/laptop.jpeg")
+	require.NoError(t, err)
+
+	// the quota has room for the original image only, so its renditions are not saved
+	imageStore, err := service.NewDiskImageStore(t.TempDir(), service.WithDiskQuota(int64(len(imageData)), 0))
+	require.NoError(t, err)
+
+	serverAddress := startTestLaptopServer(t, laptopStore, imageStore, nil)
+	laptopClient := newTestLaptopClient(t, serverAddress)
+
+	uploadTestImage(t, laptopClient, laptop1.GetId(), ".jpeg", imageData)
+
+	_, err = sendTestImage(laptopClient, laptop1.GetId(), ".jpeg", imageData)
+	require.Equal(t, codes.ResourceExhausted, status.Code(err))
+
+	uploadTestImage(t, laptopClient, laptop2.GetId(), ".jpeg", imageData)
+
+	images, err := imageStore.List(laptop1.GetId())
+	require.NoError(t, err)
+	require.Len(t, images, 1)
+}
+
+func TestClientUploadImageChecksum(t *testing.T) {
+	t.Parallel()
+
+	laptopStore := service.NewInMemoryLaptopStore()
+	imageFolder := t.TempDir()
+	imageStore, err := service.NewDiskImageStore(imageFolder)
+	require.NoError(t, err)
+
+	laptop := sample.NewLaptop()
+	require.NoError(t, laptopStore.Save(laptop))
+
+	serverAddress := startTestLaptopServer(t, laptopStore, imageStore, nil)
+	laptopClient := newTestLaptopClient(t, serverAddress)
+
+	imageData, err := os.ReadFile("../tmp/laptop.jpeg")
+	require.NoError(t, err)
+	checksum := sha256.Sum256(imageData)
+
+	info := &pb.ImageInfo{
+		LaptopId:  laptop.GetId(),
+		ImageType: ".jpeg",
+		Size:      uint64(len(imageData)),
+		Checksum:  hex.EncodeToString(checksum[:]),
+	}
+
+	// a truncated upload is not saved
+	_, err = sendTestImageInfo(laptopClient, info, imageData[:len(imageData)-10])
+	require.Equal(t, codes.DataLoss, status.Code(err))
+
+	// neither is a corrupted one
+	corrupted := append([]byte(nil), imageData...)
+	corrupted[len(corrupted)-10] ^= 0xff
+	_, err = sendTestImageInfo(laptopClient, info, corrupted)
+	require.Equal(t, codes.DataLoss, status.Code(err))
+
+	_, err = sendTestImageInfo(laptopClient, info, append(imageData, 0))
+	require.Equal(t, codes.DataLoss, status.Code(err))
+
+	images, err := imageStore.List(laptop.GetId())
+	require.NoError(t, err)
+	require.Empty(t, images)
+	requireNoFiles(t, imageFolder)
+
+	res, err := sendTestImageInfo(laptopClient, info, imageData)
+	require.NoError(t, err)
+	require.Equal(t, info.GetChecksum(), res.GetChecksum())
+	require.EqualValues(t, len(imageData), res.GetSize())
+
+	// the downloaded image info has the same checksum
+	stream, err := laptopClient.DownloadImage(context.Background(), &pb.DownloadImageRequest{ImageId: res.GetId()})
+	require.NoError(t, err)
+
+	downloaded, err := stream.Recv()
+	require.NoError(t, err)
+	require.Equal(t, info.GetChecksum(), downloaded.GetInfo().GetChecksum())
+	require.Equal(t, info.GetSize(), downloaded.GetInfo().GetSize())
+}
+
+func TestClientGallery(t *testing.T) {
+	t.Parallel()
+
+	laptopStore := service.NewInMemoryLaptopStore()
+	imageStore := newTestDiskImageStore(t)
+
+	laptop := sample.NewLaptop()
+	require.NoError(t, laptopStore.Save(laptop))
+
+	serverAddress := startTestLaptopServer(t, laptopStore, imageStore, nil, service.WithMaxImagesPerLaptop(3))
+	laptopClient := newTestLaptopClient(t, serverAddress)
+	ctx := context.Background()
+
+	imageData, err := os.ReadFile("../tmp/laptop.jpeg")
+	require.NoError(t, err)
+
+	res, err := laptopClient.GetLaptop(ctx, &pb.GetLaptopRequest{Id: laptop.GetId()})
+	require.NoError(t, err)
+	require.Empty(t, res.GetLaptop().GetPrimaryImageId())
+	require.Zero(t, res.GetLaptop().GetImageCount())
+
+	imageIDs := make([]string, 3)
+	for i := range imageIDs {
+		imageIDs[i] = uploadTestImage(t, laptopClient, laptop.GetId(), ".jpeg", imageData)
+	}
+
+	_, err = sendTestImage(laptopClient, laptop.GetId(), ".jpeg", imageData)
+	require.Equal(t, codes.ResourceExhausted, status.Code(err))
+
+	res, err = laptopClient.GetLaptop(ctx, &pb.GetLaptopRequest{Id: laptop.GetId()})
+	require.NoError(t, err)
+	require.Equal(t, imageIDs[0], res.GetLaptop().GetPrimaryImageId())
+	require.EqualValues(t, 3, res.GetLaptop().GetImageCount())
+
+	_, err = laptopClient.SetPrimaryImage(ctx, &pb.SetPrimaryImageRequest{LaptopId: laptop.GetId(), ImageId: imageIDs[2]})
+	require.NoError(t, err)
+	requireGallery(t, laptopClient, laptop.GetId(), imageIDs[2], imageIDs[0], imageIDs[1])
+
+	_, err = laptopClient.SetPrimaryImage(ctx, &pb.SetPrimaryImageRequest{LaptopId: laptop.GetId(), ImageId: "unknown"})
+	require.Equal(t, codes.NotFound, status.Code(err))
+
+	_, err = laptopClient.ReorderImages(ctx, &pb.ReorderImagesRequest{LaptopId: laptop.GetId(), ImageIds: imageIDs[:2]})
+	require.Equal(t, codes.InvalidArgument, status.Code(err))
+
+	_, err = laptopClient.ReorderImages(ctx, &pb.ReorderImagesRequest{
+		LaptopId: laptop.GetId(),
+		ImageIds: []string{imageIDs[1], imageIDs[2], imageIDs[0]},
+	})
+	require.NoError(t, err)
+	requireGallery(t, laptopClient, laptop.GetId(), imageIDs[1], imageIDs[2], imageIDs[0])
+
+	// deleting the primary image makes the next image primary, and leaves room for a new image
+	_, err = laptopClient.DeleteImage(ctx, &pb.DeleteImageRequest{ImageId: imageIDs[1]})
+	require.NoError(t, err)
+	requireGallery(t, laptopClient, laptop.GetId(), imageIDs[2], imageIDs[0])
+
+	imageID := uploadTestImage(t, laptopClient, laptop.GetId(), ".jpeg", imageData)
+	requireGallery(t, laptopClient, laptop.GetId(), imageIDs[2], imageIDs[0], imageID)
+
+	stream, err := laptopClient.SearchLaptop(ctx, &pb.SearchLaptopRequest{Filter: &pb.Filter{MaxPriceUsd: laptop.GetPriceUsd()}})
+	require.NoError(t, err)
+
+	found, err := stream.Recv()
+	require.NoError(t, err)
+	require.Equal(t, imageIDs[2], found.GetLaptop().GetPrimaryImageId())
+	require.EqualValues(t, 3, found.GetLaptop().GetImageCount())
+
+	_, err = laptopClient.GetLaptop(ctx, &pb.GetLaptopRequest{Id: "unknown"})
+	require.Equal(t, codes.NotFound, status.Code(err))
+}
+
+// requireGallery checks the order of the images listed for a laptop, and its primary image
+func requireGallery(t *testing.T, laptopClient pb.LaptopServiceClient, laptopID string, imageIDs ...string) {
+	res, err := laptopClient.ListImages(context.Background(), &pb.ListImagesRequest{LaptopId: laptopID})
+	require.NoError(t, err)
+
+	listed := make([]string, len(res.GetImages()))
+	for i, image := range res.GetImages() {
+		listed[i] = image.GetId()
+	}
+	require.Equal(t, imageIDs, listed)
+
+	laptop, err := laptopClient.GetLaptop(context.Background(), &pb.GetLaptopRequest{Id: laptopID})
+	require.NoError(t, err)
+	require.Equal(t, imageIDs[0], laptop.GetLaptop().GetPrimaryImageId())
+	require.EqualValues(t, len(imageIDs), laptop.GetLaptop().GetImageCount())
 }
 
 func TestClientRateLaptop(t *testing.T) {
@@ -260,3 +614,1127 @@ func TestClientRateLaptop(t *testing.T) {
 	}
 
 }
+
+func TestClientRateLaptopPerUser(t *testing.T) {
+	t.Parallel()
+
+	laptopStore := service.NewInMemoryLaptopStore()
+	laptop1 := sample.NewLaptop()
+	require.NoError(t, laptopStore.Save(laptop1))
+	laptop2 := sample.NewLaptop()
+	require.NoError(t, laptopStore.Save(laptop2))
+
+	laptopServer := service.NewLaptopServer(laptopStore, nil, service.NewInMemoryRatingStore())
+	serverAddress := startTestAuthLaptopServer(t, laptopServer, map[string][]string{
+		"/brucemig.pcbook.LaptopService/RateLaptop":   {"user"},
+		"/brucemig.pcbook.LaptopService/GetMyRatings": {"user"},
+	})
+	laptopClient := newTestLaptopClient(t, serverAddress)
+
+	ctx1 := withAccessToken(newTestAccessToken(t, "user1", "user"))
+	ctx2 := withAccessToken(newTestAccessToken(t, "user2", "user"))
+
+	rate := func(ctx context.Context, laptopID string, score float64) *pb.RateLaptopResponse {
+		stream, err := laptopClient.RateLaptop(ctx)
+		require.NoError(t, err)
+		require.NoError(t, stream.Send(&pb.RateLaptopRequest{LaptopId: laptopID, Score: score}))
+		require.NoError(t, stream.CloseSend())
+
+		res, err := stream.Recv()
+		require.NoError(t, err)
+		return res
+	}
+
+	res := rate(ctx1, laptop1.GetId(), 8)
+	require.Equal(t, uint32(1), res.GetRatedCount())
+
+	// a user rating a laptop again replaces the previous score
+	for _, score := range []float64{10, 10, 10, 2} {
+		res = rate(ctx1, laptop1.GetId(), score)
+		require.Equal(t, uint32(1), res.GetRatedCount())
+		require.Equal(t, score, res.GetAverageScore())
+	}
+
+	res = rate(ctx2, laptop1.GetId(), 6)
+	require.Equal(t, uint32(2), res.GetRatedCount())
+	require.Equal(t, 4.0, res.GetAverageScore())
+
+	rate(ctx1, laptop2.GetId(), 7)
+
+	ratings, err := laptopClient.GetMyRatings(ctx1, &pb.GetMyRatingsRequest{})
+	require.NoError(t, err)
+
+	expected := []*pb.MyRating{
+		{LaptopId: laptop1.GetId(), Score: 2},
+		{LaptopId: laptop2.GetId(), Score: 7},
+	}
+	if laptop2.GetId() < laptop1.GetId() {
+		expected[0], expected[1] = expected[1], expected[0]
+	}
+	require.Len(t, ratings.GetRatings(), 2)
+	for i, rating := range ratings.GetRatings() {
+		require.Equal(t, expected[i].GetLaptopId(), rating.GetLaptopId())
+		require.Equal(t, expected[i].GetScore(), rating.GetScore())
+	}
+
+	ratings, err = laptopClient.GetMyRatings(ctx2, &pb.GetMyRatingsRequest{})
+	require.NoError(t, err)
+	require.Len(t, ratings.GetRatings(), 1)
+	require.WithinDuration(t, time.Now(), ratings.GetRatings()[0].GetRatedAt().AsTime(), time.Minute)
+
+	_, err = laptopClient.GetMyRatings(context.Background(), &pb.GetMyRatingsRequest{})
+	require.Equal(t, codes.Unauthenticated, status.Code(err))
+}
+
+func TestClientRateLaptopInvalidScore(t *testing.T) {
+	t.Parallel()
+
+	laptopStore := service.NewInMemoryLaptopStore()
+	ratingStore := service.NewInMemoryRatingStore()
+
+	laptop := sample.NewLaptop()
+	require.NoError(t, laptopStore.Save(laptop))
+
+	serverAddress := startTestLaptopServer(t, laptopStore, nil, ratingStore)
+	laptopClient := newTestLaptopClient(t, serverAddress)
+
+	stream, err := laptopClient.RateLaptop(context.Background())
+	require.NoError(t, err)
+
+	testCases := []struct {
+		laptopID string
+		score    float64
+		code     codes.Code
+	}{
+		{laptop.GetId(), 8, codes.OK},
+		{laptop.GetId(), math.NaN(), codes.InvalidArgument},
+		{laptop.GetId(), math.Inf(1), codes.InvalidArgument},
+		{laptop.GetId(), -1, codes.InvalidArgument},
+		{laptop.GetId(), 1e9, codes.InvalidArgument},
+		{laptop.GetId(), 7.25, codes.InvalidArgument},
+		{sample.NewLaptop().GetId(), 5, codes.NotFound},
+		{laptop.GetId(), 0.3 + 6.6, codes.OK},
+	}
+
+	// the invalid requests get an error response, and don't stop the stream
+	for _, tc := range testCases {
+		require.NoError(t, stream.Send(&pb.RateLaptopRequest{LaptopId: tc.laptopID, Score: tc.score}))
+
+		res, err := stream.Recv()
+		require.NoError(t, err)
+		require.Equal(t, tc.laptopID, res.GetLaptopId())
+		require.Equal(t, tc.code, codes.Code(res.GetError().GetCode()), "score %v", tc.score)
+	}
+	require.NoError(t, stream.CloseSend())
+
+	_, err = stream.Recv()
+	require.Equal(t, io.EOF, err)
+
+	rating, err := ratingStore.Find(laptop.GetId())
+	require.NoError(t, err)
+	require.Equal(t, uint32(2), rating.Count)
+	require.InDelta(t, 14.9, rating.Sum, 1e-9)
+}
+
+func TestClientRateLaptops(t *testing.T) {
+	t.Parallel()
+
+	laptopStore := service.NewInMemoryLaptopStore()
+	ratingStore := service.NewInMemoryRatingStore(service.WithRatingScoreRange(0, 5))
+
+	laptop1 := sample.NewLaptop()
+	require.NoError(t, laptopStore.Save(laptop1))
+	laptop2 := sample.NewLaptop()
+	require.NoError(t, laptopStore.Save(laptop2))
+
+	laptopServer := service.NewLaptopServer(laptopStore, nil, ratingStore,
+		service.WithScoreRange(0, 5),
+		service.WithScorePrecision(0),
+	)
+	serverAddress := startTestAuthLaptopServer(t, laptopServer, nil)
+	laptopClient := newTestLaptopClient(t, serverAddress)
+
+	// a batch with any invalid rating is rejected as a whole
+	unknownID := sample.NewLaptop().GetId()
+	_, err := laptopClient.RateLaptops(context.Background(), &pb.RateLaptopsRequest{
+		Ratings: []*pb.RateLaptopRequest{
+			{LaptopId: laptop1.GetId(), Score: 4},
+			{LaptopId: laptop2.GetId(), Score: 3.5},
+			{LaptopId: unknownID, Score: 6},
+		},
+	})
+	require.Equal(t, codes.InvalidArgument, status.Code(err))
+
+	details := status.Convert(err).Details()
+	require.Len(t, details, 1)
+	badRequest, ok := details[0].(*errdetails.BadRequest)
+	require.True(t, ok)
+
+	var fields []string
+	for _, violation := range badRequest.GetFieldViolations() {
+		fields = append(fields, violation.GetField())
+	}
+	require.Equal(t, []string{"ratings[1].score", "ratings[2].score", "ratings[2].laptop_id"}, fields)
+
+	for _, laptopID := range []string{laptop1.GetId(), laptop2.GetId()} {
+		rating, err := ratingStore.Find(laptopID)
+		require.NoError(t, err)
+		require.Nil(t, rating)
+	}
+
+	res, err := laptopClient.RateLaptops(context.Background(), &pb.RateLaptopsRequest{
+		Ratings: []*pb.RateLaptopRequest{
+			{LaptopId: laptop1.GetId(), Score: 4},
+			{LaptopId: laptop2.GetId(), Score: 0},
+			{LaptopId: laptop1.GetId(), Score: 5},
+		},
+	})
+	require.NoError(t, err)
+	require.Len(t, res.GetRatings(), 3)
+	require.Equal(t, laptop2.GetId(), res.GetRatings()[1].GetLaptopId())
+	require.Equal(t, uint32(2), res.GetRatings()[2].GetRatedCount())
+	require.Equal(t, 4.5, res.GetRatings()[2].GetAverageScore())
+
+	// the histogram has a bucket for each score of the range
+	statistics := res.GetRatings()[1].GetStatistics()
+	require.Equal(t, int32(0), statistics.GetHistogramMinScore())
+	require.Equal(t, []uint32{1, 0, 0, 0, 0, 0}, statistics.GetHistogram())
+	statistics = res.GetRatings()[2].GetStatistics()
+	require.Equal(t, []uint32{0, 0, 0, 0, 1, 1}, statistics.GetHistogram())
+}
+
+func TestClientGetRating(t *testing.T) {
+	t.Parallel()
+
+	laptopStore := service.NewInMemoryLaptopStore()
+	ratingStore := service.NewInMemoryRatingStore(service.WithRatingPrior(2, 5))
+
+	laptop := sample.NewLaptop()
+	require.NoError(t, laptopStore.Save(laptop))
+	unrated := sample.NewLaptop()
+	require.NoError(t, laptopStore.Save(unrated))
+
+	serverAddress := startTestLaptopServer(t, laptopStore, nil, ratingStore)
+	laptopClient := newTestLaptopClient(t, serverAddress)
+
+	stream, err := laptopClient.RateLaptop(context.Background())
+	require.NoError(t, err)
+
+	var rated *pb.RateLaptopResponse
+	for _, score := range []float64{6, 8, 10} {
+		require.NoError(t, stream.Send(&pb.RateLaptopRequest{LaptopId: laptop.GetId(), Score: score}))
+		rated, err = stream.Recv()
+		require.NoError(t, err)
+	}
+	require.NoError(t, stream.CloseSend())
+
+	res, err := laptopClient.GetRating(context.Background(), &pb.GetRatingRequest{LaptopId: laptop.GetId()})
+	require.NoError(t, err)
+	require.Equal(t, uint32(3), res.GetRatedCount())
+	require.Equal(t, 8.0, res.GetAverageScore())
+
+	statistics := res.GetStatistics()
+	require.Equal(t, []uint32{0, 0, 0, 0, 0, 1, 0, 1, 0, 1}, statistics.GetHistogram())
+	require.Equal(t, int32(service.MinScore), statistics.GetHistogramMinScore())
+	require.Equal(t, 8.0, statistics.GetMedianScore())
+	require.InDelta(t, math.Sqrt(8.0/3), statistics.GetStandardDeviation(), 1e-9)
+	require.InDelta(t, (2*5+24)/5.0, statistics.GetBayesianAverage(), 1e-9)
+	require.True(t, proto.Equal(rated.GetStatistics(), statistics))
+
+	res, err = laptopClient.GetRating(context.Background(), &pb.GetRatingRequest{LaptopId: unrated.GetId()})
+	require.NoError(t, err)
+	require.Zero(t, res.GetRatedCount())
+	require.Nil(t, res.GetStatistics())
+
+	_, err = laptopClient.GetRating(context.Background(), &pb.GetRatingRequest{LaptopId: sample.NewLaptop().GetId()})
+	require.Equal(t, codes.NotFound, status.Code(err))
+}
+
+func TestClientReviews(t *testing.T) {
+	t.Parallel()
+
+	laptopStore := service.NewInMemoryLaptopStore()
+	laptop := sample.NewLaptop()
+	require.NoError(t, laptopStore.Save(laptop))
+	ratingStore := service.NewInMemoryRatingStore()
+
+	laptopServer := service.NewLaptopServer(laptopStore, nil, ratingStore)
+	serverAddress := startTestAuthLaptopServer(t, laptopServer, map[string][]string{
+		"/brucemig.pcbook.LaptopService/SubmitReview":       {"admin", "user"},
+		"/brucemig.pcbook.LaptopService/ListPendingReviews": {"admin"},
+		"/brucemig.pcbook.LaptopService/ApproveReview":      {"admin"},
+		"/brucemig.pcbook.LaptopService/RejectReview":       {"admin"},
+	})
+	laptopClient := newTestLaptopClient(t, serverAddress)
+
+	adminCtx := withAccessToken(newTestAccessToken(t, "admin1", "admin"))
+	submit := func(username string, title string, score float64) (*pb.Review, error) {
+		ctx := withAccessToken(newTestAccessToken(t, username, "user"))
+		res, err := laptopClient.SubmitReview(ctx, &pb.SubmitReviewRequest{
+			LaptopId: laptop.GetId(),
+			Title:    title,
+			Body:     "the body of " + title,
+			Score:    score,
+		})
+		return res.GetReview(), err
+	}
+
+	good, err := submit("user1", "good", 8)
+	require.NoError(t, err)
+	require.Equal(t, "user1", good.GetUsername())
+	require.Equal(t, pb.Review_PENDING, good.GetStatus())
+	bad, err := submit("user2", "bad", 2)
+	require.NoError(t, err)
+	great, err := submit("user3", "great", 10)
+	require.NoError(t, err)
+
+	_, err = submit("user1", "   ", 8)
+	require.Equal(t, codes.InvalidArgument, status.Code(err))
+	_, err = submit("user1", "too high", 11)
+	require.Equal(t, codes.InvalidArgument, status.Code(err))
+	_, err = laptopClient.SubmitReview(context.Background(), &pb.SubmitReviewRequest{LaptopId: laptop.GetId(), Title: "anonymous", Score: 5})
+	require.Equal(t, codes.Unauthenticated, status.Code(err))
+
+	// the pending reviews are hidden, and their scores don't count
+	reviews, err := laptopClient.ListReviews(context.Background(), &pb.ListReviewsRequest{LaptopId: laptop.GetId()})
+	require.NoError(t, err)
+	require.Empty(t, reviews.GetReviews())
+
+	rating, err := laptopClient.GetRating(context.Background(), &pb.GetRatingRequest{LaptopId: laptop.GetId()})
+	require.NoError(t, err)
+	require.Zero(t, rating.GetRatedCount())
+
+	pending, err := laptopClient.ListPendingReviews(adminCtx, &pb.ListPendingReviewsRequest{PageSize: 2})
+	require.NoError(t, err)
+	require.Len(t, pending.GetReviews(), 2)
+	require.Equal(t, good.GetId(), pending.GetReviews()[0].GetId())
+	require.NotEmpty(t, pending.GetNextPageToken())
+
+	_, err = laptopClient.ListPendingReviews(withAccessToken(newTestAccessToken(t, "user1", "user")), &pb.ListPendingReviewsRequest{})
+	require.Equal(t, codes.PermissionDenied, status.Code(err))
+	_, err = laptopClient.ApproveReview(withAccessToken(newTestAccessToken(t, "user2", "user")), &pb.ApproveReviewRequest{ReviewId: good.GetId()})
+	require.Equal(t, codes.PermissionDenied, status.Code(err))
+
+	for _, review := range []*pb.Review{good, great} {
+		approved, err := laptopClient.ApproveReview(adminCtx, &pb.ApproveReviewRequest{ReviewId: review.GetId()})
+		require.NoError(t, err)
+		require.Equal(t, pb.Review_APPROVED, approved.GetReview().GetStatus())
+		require.Equal(t, "admin1", approved.GetReview().GetModerator())
+	}
+
+	rejected, err := laptopClient.RejectReview(adminCtx, &pb.RejectReviewRequest{ReviewId: bad.GetId()})
+	require.NoError(t, err)
+	require.Equal(t, pb.Review_REJECTED, rejected.GetReview().GetStatus())
+
+	_, err = laptopClient.ApproveReview(adminCtx, &pb.ApproveReviewRequest{ReviewId: bad.GetId()})
+	require.Equal(t, codes.FailedPrecondition, status.Code(err))
+
+	// a retried moderation returns the review, and the score of an approved review is only counted once
+	approved, err := laptopClient.ApproveReview(adminCtx, &pb.ApproveReviewRequest{ReviewId: good.GetId()})
+	require.NoError(t, err)
+	require.Equal(t, pb.Review_APPROVED, approved.GetReview().GetStatus())
+	rejected, err = laptopClient.RejectReview(adminCtx, &pb.RejectReviewRequest{ReviewId: bad.GetId()})
+	require.NoError(t, err)
+	require.Equal(t, pb.Review_REJECTED, rejected.GetReview().GetStatus())
+	_, err = laptopClient.RejectReview(adminCtx, &pb.RejectReviewRequest{ReviewId: sample.NewLaptop().GetId()})
+	require.Equal(t, codes.NotFound, status.Code(err))
+
+	// a moderator can't moderate their own review
+	own, err := laptopClient.SubmitReview(adminCtx, &pb.SubmitReviewRequest{LaptopId: laptop.GetId(), Title: "mine", Score: 9})
+	require.NoError(t, err)
+	_, err = laptopClient.ApproveReview(adminCtx, &pb.ApproveReviewRequest{ReviewId: own.GetReview().GetId()})
+	require.Equal(t, codes.PermissionDenied, status.Code(err))
+
+	// only the approved scores count
+	rating, err = laptopClient.GetRating(context.Background(), &pb.GetRatingRequest{LaptopId: laptop.GetId()})
+	require.NoError(t, err)
+	require.Equal(t, uint32(2), rating.GetRatedCount())
+	require.Equal(t, 9.0, rating.GetAverageScore())
+
+	reviews, err = laptopClient.ListReviews(context.Background(), &pb.ListReviewsRequest{
+		LaptopId: laptop.GetId(),
+		Order:    pb.ListReviewsRequest_HIGHEST_SCORE,
+		PageSize: 1,
+	})
+	require.NoError(t, err)
+	require.Len(t, reviews.GetReviews(), 1)
+	require.Equal(t, great.GetId(), reviews.GetReviews()[0].GetId())
+	require.Equal(t, "the body of great", reviews.GetReviews()[0].GetBody())
+
+	reviews, err = laptopClient.ListReviews(context.Background(), &pb.ListReviewsRequest{
+		LaptopId:  laptop.GetId(),
+		Order:     pb.ListReviewsRequest_HIGHEST_SCORE,
+		PageSize:  1,
+		PageToken: reviews.GetNextPageToken(),
+	})
+	require.NoError(t, err)
+	require.Len(t, reviews.GetReviews(), 1)
+	require.Equal(t, good.GetId(), reviews.GetReviews()[0].GetId())
+	require.Empty(t, reviews.GetNextPageToken())
+
+	_, err = laptopClient.ListReviews(context.Background(), &pb.ListReviewsRequest{LaptopId: laptop.GetId(), PageToken: "unknown"})
+	require.Equal(t, codes.InvalidArgument, status.Code(err))
+	_, err = laptopClient.ListReviews(context.Background(), &pb.ListReviewsRequest{LaptopId: sample.NewLaptop().GetId()})
+	require.Equal(t, codes.NotFound, status.Code(err))
+}
+
+func TestClientGetTopRatedLaptops(t *testing.T) {
+	t.Parallel()
+
+	laptopStore := service.NewInMemoryLaptopStore()
+	ratingStore := service.NewInMemoryRatingStore(service.WithRatingPrior(2, 5))
+
+	cheap := sample.NewLaptop()
+	cheap.PriceUsd = 1000
+	expensive := sample.NewLaptop()
+	expensive.PriceUsd = 3000
+	unrated := sample.NewLaptop()
+	deleted := sample.NewLaptop()
+	for _, laptop := range []*pb.Laptop{cheap, expensive, unrated, deleted} {
+		require.NoError(t, laptopStore.Save(laptop))
+	}
+
+	rate := func(laptop *pb.Laptop, scores ...float64) {
+		for i, score := range scores {
+			_, err := ratingStore.Add(&service.Vote{LaptopID: laptop.GetId(), Username: fmt.Sprintf("user%d", i), Score: score})
+			require.NoError(t, err)
+		}
+	}
+	rate(cheap, 6, 7, 8)
+	rate(expensive, 9, 10)
+	rate(deleted, 10, 10, 10)
+	require.NoError(t, laptopStore.Delete(deleted.GetId()))
+
+	serverAddress := startTestLaptopServer(t, laptopStore, nil, ratingStore)
+	laptopClient := newTestLaptopClient(t, serverAddress)
+
+	topRated := func(req *pb.GetTopRatedLaptopsRequest) []string {
+		res, err := laptopClient.GetTopRatedLaptops(context.Background(), req)
+		require.NoError(t, err)
+
+		var laptopIDs []string
+		for _, rated := range res.GetLaptops() {
+			laptopIDs = append(laptopIDs, rated.GetLaptop().GetId())
+		}
+		return laptopIDs
+	}
+
+	res, err := laptopClient.GetTopRatedLaptops(context.Background(), &pb.GetTopRatedLaptopsRequest{})
+	require.NoError(t, err)
+	require.Len(t, res.GetLaptops(), 2)
+	requireSameLaptop(t, expensive, res.GetLaptops()[0].GetLaptop())
+	require.Equal(t, uint32(2), res.GetLaptops()[0].GetRatedCount())
+	require.Equal(t, 9.5, res.GetLaptops()[0].GetAverageScore())
+	require.InDelta(t, (2*5+19)/4.0, res.GetLaptops()[0].GetStatistics().GetBayesianAverage(), 1e-9)
+
+	require.Equal(t, []string{expensive.GetId()}, topRated(&pb.GetTopRatedLaptopsRequest{Limit: 1}))
+	require.Equal(t, []string{cheap.GetId()}, topRated(&pb.GetTopRatedLaptopsRequest{MinRatedCount: 3}))
+
+	filter := &pb.Filter{
+		MaxPriceUsd: 2000,
+		MinRam:      &pb.Memory{Value: 0, Unit: pb.Memory_BIT},
+	}
+	require.Equal(t, []string{cheap.GetId()}, topRated(&pb.GetTopRatedLaptopsRequest{Filter: filter}))
+}
+
+func TestClientQuarantinedRatingBatch(t *testing.T) {
+	t.Parallel()
+
+	laptopStore := service.NewInMemoryLaptopStore()
+	ratingStore := service.NewInMemoryRatingStore()
+	quarantineStore := service.NewInMemoryQuarantineStore()
+
+	laptopIDs := make([]string, 4)
+	for i := range laptopIDs {
+		laptop := sample.NewLaptop()
+		require.NoError(t, laptopStore.Save(laptop))
+		laptopIDs[i] = laptop.GetId()
+	}
+
+	detector := service.NewVelocityAbuseDetector(service.AbuseThresholds{Window: time.Hour, MaxUserVotes: 3})
+	laptopServer := service.NewLaptopServer(
+		laptopStore,
+		nil,
+		ratingStore,
+		service.WithAbuseDetector(detector),
+		service.WithQuarantineStore(quarantineStore),
+	)
+	serverAddress := startTestAuthLaptopServer(t, laptopServer, map[string][]string{
+		"/brucemig.pcbook.LaptopService/RateLaptops": {"user"},
+	})
+	laptopClient := newTestLaptopClient(t, serverAddress)
+	ctx := withAccessToken(newTestAccessToken(t, "user1", "user"))
+
+	rate := func(laptopIDs ...string) *pb.RateLaptopsResponse {
+		req := &pb.RateLaptopsRequest{}
+		for _, laptopID := range laptopIDs {
+			req.Ratings = append(req.Ratings, &pb.RateLaptopRequest{LaptopId: laptopID, Score: 7})
+		}
+		res, err := laptopClient.RateLaptops(ctx, req)
+		require.NoError(t, err)
+		require.Len(t, res.GetRatings(), len(laptopIDs))
+		return res
+	}
+
+	for _, rating := range rate(laptopIDs[0], laptopIDs[1]).GetRatings() {
+		require.False(t, rating.GetQuarantined())
+		require.Equal(t, uint32(1), rating.GetRatedCount())
+	}
+
+	// the batch that exceeds the threshold is quarantined as a whole, not from the vote over the threshold on
+	for _, rating := range rate(laptopIDs[2], laptopIDs[0]).GetRatings() {
+		require.True(t, rating.GetQuarantined())
+	}
+	for i, count := range []uint32{1, 1, 0} {
+		rating, err := ratingStore.Find(laptopIDs[i])
+		require.NoError(t, err)
+		if count == 0 {
+			require.Nil(t, rating)
+		} else {
+			require.Equal(t, count, rating.Count)
+		}
+	}
+
+	quarantined, err := quarantineStore.List("")
+	require.NoError(t, err)
+	require.Len(t, quarantined, 2)
+	require.Equal(t, quarantined[0].Reason, quarantined[1].Reason)
+}
+
+func TestClientQuarantinedRatings(t *testing.T) {
+	t.Parallel()
+
+	laptopStore := service.NewInMemoryLaptopStore()
+	ratingStore := service.NewInMemoryRatingStore()
+
+	laptop1 := sample.NewLaptop()
+	require.NoError(t, laptopStore.Save(laptop1))
+	laptop2 := sample.NewLaptop()
+	require.NoError(t, laptopStore.Save(laptop2))
+
+	detector := service.NewVelocityAbuseDetector(service.AbuseThresholds{
+		Window:              time.Hour,
+		MaxUserExtremeVotes: 1,
+		MinScore:            service.MinScore,
+		MaxScore:            service.MaxScore,
+	})
+	laptopServer := service.NewLaptopServer(laptopStore, nil, ratingStore, service.WithAbuseDetector(detector))
+	serverAddress := startTestAuthLaptopServer(t, laptopServer, map[string][]string{
+		"/brucemig.pcbook.LaptopService/RateLaptop":               {"user"},
+		"/brucemig.pcbook.LaptopService/RateLaptops":              {"user"},
+		"/brucemig.pcbook.LaptopService/ListQuarantinedRatings":   {"admin"},
+		"/brucemig.pcbook.LaptopService/ReleaseQuarantinedRating": {"admin"},
+		"/brucemig.pcbook.LaptopService/DiscardQuarantinedRating": {"admin"},
+	})
+	laptopClient := newTestLaptopClient(t, serverAddress)
+
+	ctx := withAccessToken(newTestAccessToken(t, "user1", "user"))
+	adminCtx := withAccessToken(newTestAccessToken(t, "admin1", "admin"))
+
+	// only admins review the quarantined scores
+	_, err := laptopClient.ListQuarantinedRatings(ctx, &pb.ListQuarantinedRatingsRequest{})
+	require.Equal(t, codes.PermissionDenied, status.Code(err))
+
+	// the second extreme score of the user is quarantined instead of counted
+	res, err := laptopClient.RateLaptops(ctx, &pb.RateLaptopsRequest{
+		Ratings: []*pb.RateLaptopRequest{{LaptopId: laptop1.GetId(), Score: 10}},
+	})
+	require.NoError(t, err)
+	require.False(t, res.GetRatings()[0].GetQuarantined())
+	require.Equal(t, uint32(1), res.GetRatings()[0].GetRatedCount())
+
+	res, err = laptopClient.RateLaptops(ctx, &pb.RateLaptopsRequest{
+		Ratings: []*pb.RateLaptopRequest{{LaptopId: laptop2.GetId(), Score: 10}},
+	})
+	require.NoError(t, err)
+	require.True(t, res.GetRatings()[0].GetQuarantined())
+	require.Zero(t, res.GetRatings()[0].GetRatedCount())
+
+	stream, err := laptopClient.RateLaptop(ctx)
+	require.NoError(t, err)
+	require.NoError(t, stream.Send(&pb.RateLaptopRequest{LaptopId: laptop1.GetId(), Score: 1}))
+	require.NoError(t, stream.CloseSend())
+
+	streamed, err := stream.Recv()
+	require.NoError(t, err)
+	require.True(t, streamed.GetQuarantined())
+	require.Equal(t, uint32(1), streamed.GetRatedCount())
+	require.Equal(t, 10.0, streamed.GetAverageScore())
+
+	list, err := laptopClient.ListQuarantinedRatings(adminCtx, &pb.ListQuarantinedRatingsRequest{})
+	require.NoError(t, err)
+	require.Len(t, list.GetRatings(), 2)
+	quarantined2, quarantined1 := list.GetRatings()[0], list.GetRatings()[1]
+	require.Equal(t, laptop2.GetId(), quarantined2.GetLaptopId())
+	require.Equal(t, "user1", quarantined2.GetUsername())
+	require.Equal(t, 10.0, quarantined2.GetScore())
+	require.Contains(t, quarantined2.GetReason(), "extreme")
+	require.Equal(t, laptop1.GetId(), quarantined1.GetLaptopId())
+
+	list, err = laptopClient.ListQuarantinedRatings(adminCtx, &pb.ListQuarantinedRatingsRequest{LaptopId: laptop1.GetId()})
+	require.NoError(t, err)
+	require.Len(t, list.GetRatings(), 1)
+	require.Equal(t, quarantined1.GetId(), list.GetRatings()[0].GetId())
+
+	// a released score is counted
+	released, err := laptopClient.ReleaseQuarantinedRating(
+		adminCtx,
+		&pb.ReleaseQuarantinedRatingRequest{QuarantineId: quarantined1.GetId()},
+	)
+	require.NoError(t, err)
+	require.Equal(t, uint32(1), released.GetRating().GetRatedCount())
+	require.Equal(t, 1.0, released.GetRating().GetAverageScore())
+
+	_, err = laptopClient.ReleaseQuarantinedRating(
+		adminCtx,
+		&pb.ReleaseQuarantinedRatingRequest{QuarantineId: quarantined1.GetId()},
+	)
+	require.Equal(t, codes.NotFound, status.Code(err))
+
+	// a released score doesn't replace a more recent score of the user
+	res, err = laptopClient.RateLaptops(ctx, &pb.RateLaptopsRequest{
+		Ratings: []*pb.RateLaptopRequest{{LaptopId: laptop2.GetId(), Score: 5}},
+	})
+	require.NoError(t, err)
+	require.False(t, res.GetRatings()[0].GetQuarantined())
+
+	released, err = laptopClient.ReleaseQuarantinedRating(
+		adminCtx,
+		&pb.ReleaseQuarantinedRatingRequest{QuarantineId: quarantined2.GetId()},
+	)
+	require.NoError(t, err)
+	require.Equal(t, uint32(1), released.GetRating().GetRatedCount())
+	require.Equal(t, 5.0, released.GetRating().GetAverageScore())
+
+	// a discarded score is never counted
+	stream, err = laptopClient.RateLaptop(ctx)
+	require.NoError(t, err)
+	require.NoError(t, stream.Send(&pb.RateLaptopRequest{LaptopId: laptop2.GetId(), Score: 1}))
+	require.NoError(t, stream.CloseSend())
+	streamed, err = stream.Recv()
+	require.NoError(t, err)
+	require.True(t, streamed.GetQuarantined())
+
+	list, err = laptopClient.ListQuarantinedRatings(adminCtx, &pb.ListQuarantinedRatingsRequest{})
+	require.NoError(t, err)
+	require.Len(t, list.GetRatings(), 1)
+
+	_, err = laptopClient.DiscardQuarantinedRating(
+		adminCtx,
+		&pb.DiscardQuarantinedRatingRequest{QuarantineId: list.GetRatings()[0].GetId()},
+	)
+	require.NoError(t, err)
+	_, err = laptopClient.DiscardQuarantinedRating(
+		adminCtx,
+		&pb.DiscardQuarantinedRatingRequest{QuarantineId: list.GetRatings()[0].GetId()},
+	)
+	require.Equal(t, codes.NotFound, status.Code(err))
+
+	list, err = laptopClient.ListQuarantinedRatings(adminCtx, &pb.ListQuarantinedRatingsRequest{})
+	require.NoError(t, err)
+	require.Empty(t, list.GetRatings())
+
+	rating, err := ratingStore.Find(laptop2.GetId())
+	require.NoError(t, err)
+	require.Equal(t, 5.0, rating.Average())
+}
+
+func TestClientGetRatingWindow(t *testing.T) {
+	t.Parallel()
+
+	laptopStore := service.NewInMemoryLaptopStore()
+	ratingStore := service.NewInMemoryRatingStore()
+
+	laptop1 := sample.NewLaptop()
+	require.NoError(t, laptopStore.Save(laptop1))
+	laptop2 := sample.NewLaptop()
+	require.NoError(t, laptopStore.Save(laptop2))
+
+	now := time.Now()
+	for _, vote := range []*service.Vote{
+		{LaptopID: laptop1.GetId(), Username: "user1", Score: 10, Time: now.AddDate(0, 0, -100)},
+		{LaptopID: laptop1.GetId(), Username: "user2", Score: 4, Time: now.Add(-service.DefaultRatingHalfLife).AddDate(0, 0, -100)},
+		{LaptopID: laptop2.GetId(), Username: "user1", Score: 6, Time: now.AddDate(0, 0, -10)},
+	} {
+		_, err := ratingStore.Add(vote)
+		require.NoError(t, err)
+	}
+
+	serverAddress := startTestLaptopServer(t, laptopStore, nil, ratingStore)
+	laptopClient := newTestLaptopClient(t, serverAddress)
+
+	res, err := laptopClient.GetRating(context.Background(), &pb.GetRatingRequest{LaptopId: laptop1.GetId()})
+	require.NoError(t, err)
+	require.Equal(t, uint32(2), res.GetRatedCount())
+	require.Equal(t, 7.0, res.GetAverageScore())
+	// the older score weighs half as much
+	require.InDelta(t, (10+0.5*4)/1.5, res.GetStatistics().GetDecayedAverage(), 1e-9)
+
+	res, err = laptopClient.GetRating(context.Background(), &pb.GetRatingRequest{LaptopId: laptop1.GetId(), WindowDays: 90})
+	require.NoError(t, err)
+	require.Zero(t, res.GetRatedCount())
+	require.Nil(t, res.GetStatistics())
+
+	res, err = laptopClient.GetRating(context.Background(), &pb.GetRatingRequest{LaptopId: laptop1.GetId(), WindowDays: 120})
+	require.NoError(t, err)
+	require.Equal(t, uint32(1), res.GetRatedCount())
+	require.Equal(t, 10.0, res.GetAverageScore())
+
+	topRated := func(windowDays uint32) []string {
+		res, err := laptopClient.GetTopRatedLaptops(context.Background(), &pb.GetTopRatedLaptopsRequest{WindowDays: windowDays})
+		require.NoError(t, err)
+
+		var laptopIDs []string
+		for _, rated := range res.GetLaptops() {
+			laptopIDs = append(laptopIDs, rated.GetLaptop().GetId())
+		}
+		return laptopIDs
+	}
+	require.Equal(t, []string{laptop1.GetId(), laptop2.GetId()}, topRated(0))
+	require.Equal(t, []string{laptop2.GetId()}, topRated(30))
+}
+
+func TestClientWatchRatings(t *testing.T) {
+	t.Parallel()
+
+	laptopStore := service.NewInMemoryLaptopStore()
+	broker := service.NewRatingBroker()
+	ratingStore := service.NewInMemoryRatingStore(service.WithRatingObserver(broker.Publish))
+
+	laptop1 := sample.NewLaptop()
+	require.NoError(t, laptopStore.Save(laptop1))
+	laptop2 := sample.NewLaptop()
+	require.NoError(t, laptopStore.Save(laptop2))
+
+	serverAddress := startTestLaptopServer(
+		t, laptopStore, nil, ratingStore,
+		service.WithRatingBroker(broker),
+		service.WithRatingUpdateInterval(10*time.Millisecond),
+	)
+	laptopClient := newTestLaptopClient(t, serverAddress)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	watch := func(laptopIDs ...string) pb.LaptopService_WatchRatingsClient {
+		stream, err := laptopClient.WatchRatings(ctx, &pb.WatchRatingsRequest{LaptopIds: laptopIDs})
+		require.NoError(t, err)
+
+		// the ratings added once the header is received are sent
+		_, err = stream.Header()
+		require.NoError(t, err)
+		return stream
+	}
+	all := watch()
+	watched := watch(laptop2.GetId())
+
+	rate := func(laptopID string, username string, score float64) {
+		_, err := ratingStore.Add(&service.Vote{LaptopID: laptopID, Username: username, Score: score})
+		require.NoError(t, err)
+	}
+	rate(laptop1.GetId(), "user1", 8)
+	rate(laptop2.GetId(), "user1", 6)
+
+	received := make(map[string]*pb.WatchRatingsResponse)
+	for len(received) < 2 {
+		res, err := all.Recv()
+		require.NoError(t, err)
+		received[res.GetLaptopId()] = res
+	}
+	require.Equal(t, 8.0, received[laptop1.GetId()].GetAverageScore())
+	require.Equal(t, uint32(1), received[laptop2.GetId()].GetRatedCount())
+
+	res, err := watched.Recv()
+	require.NoError(t, err)
+	require.Equal(t, laptop2.GetId(), res.GetLaptopId())
+	require.Equal(t, 6.0, res.GetAverageScore())
+
+	// the ratings of a deleted laptop aren't sent
+	require.NoError(t, laptopStore.Delete(laptop1.GetId()))
+	rate(laptop1.GetId(), "user2", 4)
+	rate(laptop2.GetId(), "user2", 10)
+
+	res, err = all.Recv()
+	require.NoError(t, err)
+	require.Equal(t, laptop2.GetId(), res.GetLaptopId())
+	require.Equal(t, uint32(2), res.GetRatedCount())
+	require.Equal(t, 8.0, res.GetAverageScore())
+
+	stream, err := laptopClient.WatchRatings(ctx, &pb.WatchRatingsRequest{LaptopIds: []string{sample.NewLaptop().GetId()}})
+	require.NoError(t, err)
+	_, err = stream.Recv()
+	require.Equal(t, codes.NotFound, status.Code(err))
+}
+
+func TestClientDownloadImage(t *testing.T) {
+	t.Parallel()
+
+	imageStore := newTestDiskImageStore(t)
+
+	imageData, err := os.ReadFile("../tmp/laptop.jpeg")
+	require.NoError(t, err)
+
+	laptopID := sample.NewLaptop().GetId()
+	imageID, err := imageStore.Save(&service.ImageInfo{LaptopID: laptopID, Type: ".jpeg"}, bytes.NewReader(imageData))
+	require.NoError(t, err)
+
+	serverAddress := startTestLaptopServer(t, nil, imageStore, nil)
+	laptopClient := newTestLaptopClient(t, serverAddress)
+
+	stream, err := laptopClient.DownloadImage(context.Background(), &pb.DownloadImageRequest{ImageId: imageID})
+	require.NoError(t, err)
+
+	res, err := stream.Recv()
+	require.NoError(t, err)
+	require.Equal(t, laptopID, res.GetInfo().GetLaptopId())
+	require.Equal(t, ".jpeg", res.GetInfo().GetImageType())
+
+	downloaded := bytes.Buffer{}
+	for {
+		res, err := stream.Recv()
+		if err == io.EOF {
+			break
+		}
+
+		require.NoError(t, err)
+		downloaded.Write(res.GetChunkData())
+	}
+	require.Equal(t, imageData, downloaded.Bytes())
+
+	// unknown image
+	stream, err = laptopClient.DownloadImage(context.Background(), &pb.DownloadImageRequest{ImageId: "unknown"})
+	require.NoError(t, err)
+
+	_, err = stream.Recv()
+	require.Equal(t, codes.NotFound, status.Code(err))
+}
+
+func TestClientDownloadImageRendition(t *testing.T) {
+	t.Parallel()
+
+	laptopStore := service.NewInMemoryLaptopStore()
+	imageStore := newTestDiskImageStore(t)
+
+	laptop := sample.NewLaptop()
+	require.NoError(t, laptopStore.Save(laptop))
+
+	serverAddress := startTestLaptopServer(t, laptopStore, imageStore, nil)
+	laptopClient := newTestLaptopClient(t, serverAddress)
+
+	imageData, err := os.ReadFile("../tmp/laptop.jpeg")
+	require.NoError(t, err)
+
+	imageID := uploadTestImage(t, laptopClient, laptop.GetId(), ".jpeg", imageData)
+	waitTestImages(t, imageStore, laptop.GetId(), 2)
+
+	res, err := laptopClient.ListImages(context.Background(), &pb.ListImagesRequest{LaptopId: laptop.GetId()})
+	require.NoError(t, err)
+	require.Len(t, res.GetImages(), 1)
+	require.Equal(t, []uint32{128}, res.GetImages()[0].GetRenditions())
+
+	downloaded, err := downloadTestImage(laptopClient, imageID, 128)
+	require.NoError(t, err)
+	config, format, err := image.DecodeConfig(bytes.NewReader(downloaded))
+	require.NoError(t, err)
+	require.Equal(t, "jpeg", format)
+	require.Equal(t, 128, config.Width)
+	require.Equal(t, 119, config.Height)
+
+	// the image is never upscaled, so the original image is downloaded instead
+	downloaded, err = downloadTestImage(laptopClient, imageID, 512)
+	require.NoError(t, err)
+	require.Equal(t, imageData, downloaded)
+
+	_, err = downloadTestImage(laptopClient, imageID, 100)
+	require.Equal(t, codes.InvalidArgument, status.Code(err))
+
+	// deleting the image deletes its renditions too
+	_, err = laptopClient.DeleteImage(context.Background(), &pb.DeleteImageRequest{ImageId: imageID})
+	require.NoError(t, err)
+
+	images, err := imageStore.List(laptop.GetId())
+	require.NoError(t, err)
+	require.Empty(t, images)
+}
+
+// waitTestImages waits until a laptop has the given number of images, renditions included,
+// as the renditions are saved in the background
+func waitTestImages(t *testing.T, imageStore service.ImageStore, laptopID string, count int) []*service.ImageInfo {
+	var images []*service.ImageInfo
+	require.Eventually(t, func() bool {
+		var err error
+		images, err = imageStore.List(laptopID)
+		require.NoError(t, err)
+		return len(images) == count
+	}, time.Second, 10*time.Millisecond)
+	return images
+}
+
+func uploadTestImage(t *testing.T, laptopClient pb.LaptopServiceClient, laptopID string, imageType string, imageData []byte) string {
+	res, err := sendTestImage(laptopClient, laptopID, imageType, imageData)
+	require.NoError(t, err)
+	return res.GetId()
+}
+
+// sendTestImage uploads an image in a single chunk
+func sendTestImage(laptopClient pb.LaptopServiceClient, laptopID string, imageType string, imageData []byte) (*pb.UploadImageResponse, error) {
+	return sendTestImageInfo(laptopClient, &pb.ImageInfo{LaptopId: laptopID, ImageType: imageType}, imageData)
+}
+
+// sendTestImageInfo uploads an image with the given info in a single chunk
+func sendTestImageInfo(laptopClient pb.LaptopServiceClient, info *pb.ImageInfo, imageData []byte) (*pb.UploadImageResponse, error) {
+	stream, err := laptopClient.UploadImage(context.Background())
+	if err != nil {
+		return nil, err
+	}
+
+	err = stream.Send(&pb.UploadImageRequest{
+		Data: &pb.UploadImageRequest_Info{Info: info},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if len(imageData) > 0 {
+		err = stream.Send(&pb.UploadImageRequest{
+			Data: &pb.UploadImageRequest_ChunkData{ChunkData: imageData},
+		})
+		if err != nil && err != io.EOF {
+			return nil, err
+		}
+	}
+
+	return stream.CloseAndRecv()
+}
+
+func downloadTestImage(laptopClient pb.LaptopServiceClient, imageID string, rendition uint32) ([]byte, error) {
+	req := &pb.DownloadImageRequest{ImageId: imageID, Rendition: rendition}
+	stream, err := laptopClient.DownloadImage(context.Background(), req)
+	if err != nil {
+		return nil, err
+	}
+
+	downloaded := bytes.Buffer{}
+	for {
+		res, err := stream.Recv()
+		if err == io.EOF {
+			return downloaded.Bytes(), nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		downloaded.Write(res.GetChunkData())
+	}
+}
+
+func TestClientResumableUpload(t *testing.T) {
+	t.Parallel()
+
+	laptopStore := service.NewInMemoryLaptopStore()
+	imageStore := newTestDiskImageStore(t)
+
+	laptop := sample.NewLaptop()
+	require.NoError(t, laptopStore.Save(laptop))
+
+	serverAddress := startTestLaptopServer(t, laptopStore, imageStore, nil)
+	laptopClient := newTestLaptopClient(t, serverAddress)
+	ctx := context.Background()
+
+	imageData, err := os.ReadFile("../tmp/laptop.jpeg")
+	require.NoError(t, err)
+	checksum := sha256.Sum256(imageData)
+
+	start, err := laptopClient.StartUpload(ctx, &pb.StartUploadRequest{
+		Info: &pb.ImageInfo{LaptopId: laptop.GetId(), ImageType: ".jpeg"},
+	})
+	require.NoError(t, err)
+	uploadID := start.GetUploadId()
+
+	half := len(imageData) / 2
+	chunk, err := laptopClient.UploadChunk(ctx, &pb.UploadChunkRequest{UploadId: uploadID, ChunkData: imageData[:half]})
+	require.NoError(t, err)
+	require.EqualValues(t, half, chunk.GetCommittedOffset())
+
+	// the client lost the response and retries the same chunk
+	chunk, err = laptopClient.UploadChunk(ctx, &pb.UploadChunkRequest{UploadId: uploadID, ChunkData: imageData[:half]})
+	require.NoError(t, err)
+	require.EqualValues(t, half, chunk.GetCommittedOffset())
+
+	_, err = laptopClient.UploadChunk(ctx, &pb.UploadChunkRequest{UploadId: uploadID, Offset: uint64(half + 1), ChunkData: imageData[half+1:]})
+	require.Equal(t, codes.FailedPrecondition, status.Code(err))
+
+	// the client resumes from the committed offset
+	uploadStatus, err := laptopClient.GetUploadStatus(ctx, &pb.GetUploadStatusRequest{UploadId: uploadID})
+	require.NoError(t, err)
+	require.EqualValues(t, half, uploadStatus.GetCommittedOffset())
+
+	_, err = laptopClient.UploadChunk(ctx, &pb.UploadChunkRequest{
+		UploadId:  uploadID,
+		Offset:    uploadStatus.GetCommittedOffset(),
+		ChunkData: imageData[uploadStatus.GetCommittedOffset():],
+	})
+	require.NoError(t, err)
+
+	_, err = laptopClient.CompleteUpload(ctx, &pb.CompleteUploadRequest{UploadId: uploadID, Size: uint64(len(imageData)), Checksum: "bad"})
+	require.Equal(t, codes.DataLoss, status.Code(err))
+
+	res, err := laptopClient.CompleteUpload(ctx, &pb.CompleteUploadRequest{
+		UploadId: uploadID,
+		Size:     uint64(len(imageData)),
+		Checksum: hex.EncodeToString(checksum[:]),
+	})
+	require.NoError(t, err)
+	require.EqualValues(t, len(imageData), res.GetSize())
+
+	downloaded, err := downloadTestImage(laptopClient, res.GetId(), 0)
+	require.NoError(t, err)
+	require.Equal(t, imageData, downloaded)
+
+	_, err = laptopClient.GetUploadStatus(ctx, &pb.GetUploadStatusRequest{UploadId: uploadID})
+	require.Equal(t, codes.NotFound, status.Code(err))
+}
+
+func TestClientResumableUploadRetry(t *testing.T) {
+	t.Parallel()
+
+	laptopStore := service.NewInMemoryLaptopStore()
+	laptop := sample.NewLaptop()
+	require.NoError(t, laptopStore.Save(laptop))
+
+	imageData, err := os.ReadFile("../tmp/laptop.jpeg")
+	require.NoError(t, err)
+	checksum := sha256.Sum256(imageData)
+
+	// the laptop quota has room for one image
+	imageStore, err := service.NewDiskImageStore(t.TempDir(), service.WithDiskQuota(int64(len(imageData)), 0))
+	require.NoError(t, err)
+
+	serverAddress := startTestLaptopServer(t, laptopStore, imageStore, nil)
+	laptopClient := newTestLaptopClient(t, serverAddress)
+	ctx := context.Background()
+
+	imageID := uploadTestImage(t, laptopClient, laptop.GetId(), ".jpeg", imageData)
+
+	start, err := laptopClient.StartUpload(ctx, &pb.StartUploadRequest{
+		Info: &pb.ImageInfo{LaptopId: laptop.GetId(), ImageType: ".jpeg"},
+	})
+	require.NoError(t, err)
+	uploadID := start.GetUploadId()
+	_, err = laptopClient.UploadChunk(ctx, &pb.UploadChunkRequest{UploadId: uploadID, ChunkData: imageData})
+	require.NoError(t, err)
+
+	complete := &pb.CompleteUploadRequest{
+		UploadId: uploadID,
+		Size:     uint64(len(imageData)),
+		Checksum: hex.EncodeToString(checksum[:]),
+	}
+	_, err = laptopClient.CompleteUpload(ctx, complete)
+	require.Equal(t, codes.ResourceExhausted, status.Code(err))
+
+	// the uploaded data is kept, so the client retries once there is room, without uploading it again
+	uploadStatus, err := laptopClient.GetUploadStatus(ctx, &pb.GetUploadStatusRequest{UploadId: uploadID})
+	require.NoError(t, err)
+	require.EqualValues(t, len(imageData), uploadStatus.GetCommittedOffset())
+
+	_, err = laptopClient.DeleteImage(ctx, &pb.DeleteImageRequest{ImageId: imageID})
+	require.NoError(t, err)
+
+	res, err := laptopClient.CompleteUpload(ctx, complete)
+	require.NoError(t, err)
+	downloaded, err := downloadTestImage(laptopClient, res.GetId(), 0)
+	require.NoError(t, err)
+	require.Equal(t, imageData, downloaded)
+}
+
+func TestClientResumableUploadExpectedSizeAndChecksum(t *testing.T) {
+	t.Parallel()
+
+	laptopStore := service.NewInMemoryLaptopStore()
+	imageStore := newTestDiskImageStore(t)
+
+	laptop := sample.NewLaptop()
+	require.NoError(t, laptopStore.Save(laptop))
+
+	serverAddress := startTestLaptopServer(t, laptopStore, imageStore, nil)
+	laptopClient := newTestLaptopClient(t, serverAddress)
+	ctx := context.Background()
+
+	imageData, err := os.ReadFile("../tmp/laptop.jpeg")
+	require.NoError(t, err)
+	checksum := sha256.Sum256(imageData)
+
+	_, err = laptopClient.StartUpload(ctx, &pb.StartUploadRequest{
+		Info: &pb.ImageInfo{LaptopId: laptop.GetId(), ImageType: ".jpeg", Size: 1 << 30},
+	})
+	require.Equal(t, codes.InvalidArgument, status.Code(err))
+
+	start, err := laptopClient.StartUpload(ctx, &pb.StartUploadRequest{
+		Info: &pb.ImageInfo{
+			LaptopId:  laptop.GetId(),
+			ImageType: ".jpeg",
+			Size:      uint64(len(imageData)),
+			Checksum:  hex.EncodeToString(checksum[:]),
+		},
+	})
+	require.NoError(t, err)
+	uploadID := start.GetUploadId()
+
+	// a chunk past the expected size is rejected
+	_, err = laptopClient.UploadChunk(ctx, &pb.UploadChunkRequest{UploadId: uploadID, ChunkData: append(imageData, 0)})
+	require.Equal(t, codes.DataLoss, status.Code(err))
+
+	_, err = laptopClient.UploadChunk(ctx, &pb.UploadChunkRequest{UploadId: uploadID, ChunkData: imageData})
+	require.NoError(t, err)
+
+	// the completion must match the size and checksum given at the start
+	otherChecksum := sha256.Sum256([]byte("other"))
+	_, err = laptopClient.CompleteUpload(ctx, &pb.CompleteUploadRequest{
+		UploadId: uploadID,
+		Size:     uint64(len(imageData)),
+		Checksum: hex.EncodeToString(otherChecksum[:]),
+	})
+	require.Equal(t, codes.DataLoss, status.Code(err))
+
+	res, err := laptopClient.CompleteUpload(ctx, &pb.CompleteUploadRequest{
+		UploadId: uploadID,
+		Size:     uint64(len(imageData)),
+		Checksum: hex.EncodeToString(checksum[:]),
+	})
+	require.NoError(t, err)
+	require.EqualValues(t, len(imageData), res.GetSize())
+}
+
+func TestClientListAndDeleteImages(t *testing.T) {
+	t.Parallel()
+
+	imageStore := newTestDiskImageStore(t)
+	laptopID := sample.NewLaptop().GetId()
+
+	imageIDs := make([]string, 2)
+	for i := range imageIDs {
+		var err error
+		imageIDs[i], err = imageStore.Save(&service.ImageInfo{LaptopID: laptopID, Type: ".png"}, strings.NewReader("image"))
+		require.NoError(t, err)
+	}
+
+	serverAddress := startTestLaptopServer(t, nil, imageStore, nil)
+	laptopClient := newTestLaptopClient(t, serverAddress)
+
+	res, err := laptopClient.ListImages(context.Background(), &pb.ListImagesRequest{LaptopId: laptopID})
+	require.NoError(t, err)
+	require.Len(t, res.GetImages(), 2)
+	require.Equal(t, imageIDs[0], res.GetImages()[0].GetId())
+	require.Equal(t, laptopID, res.GetImages()[0].GetLaptopId())
+	require.Equal(t, uint64(5), res.GetImages()[0].GetSize())
+
+	_, err = laptopClient.DeleteImage(context.Background(), &pb.DeleteImageRequest{ImageId: imageIDs[0]})
+	require.NoError(t, err)
+
+	_, err = laptopClient.DeleteImage(context.Background(), &pb.DeleteImageRequest{ImageId: imageIDs[0]})
+	require.Equal(t, codes.NotFound, status.Code(err))
+
+	res, err = laptopClient.ListImages(context.Background(), &pb.ListImagesRequest{LaptopId: laptopID})
+	require.NoError(t, err)
+	require.Len(t, res.GetImages(), 1)
+	require.Equal(t, imageIDs[1], res.GetImages()[0].GetId())
+}
@@ -1,36 +1,305 @@
 package service
 
 import (
-	"bytes"
+	"bufio"
 	"context"
+	"crypto/sha256"
+	"encoding/hex"
 	"errors"
+	"fmt"
+	"hash"
 	"io"
 	"log"
+	"math"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
 
 	"github.com/google/uuid"
 	"gitlab.com/brucemig/pcbook/pb"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
 	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
 	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
 )
 
-// maximum 1 megabyte
-const maxImageSize = 1 << 20
+// default maximum size of the uploaded images: 1 megabyte
+const defaultMaxImageSize = 1 << 20
+
+// default maximum number of images that are uploaded at the same time
+const defaultMaxConcurrentUploads = 64
+
+// default maximum number of open upload sessions of a user
+const defaultMaxUserUploadSessions = 8
+
+// size of the beginning of the image data that is checked before the image is saved
+const imageHeaderSize = 64 << 10
+
+// size of the image chunks sent by DownloadImage
+const imageChunkSize = 1024
+
+// default time after which an upload session that doesn't receive any chunk expires
+const defaultUploadSessionTTL = time.Hour
+
+// default maximum number of images in a laptop gallery
+const defaultMaxImagesPerLaptop = 20
+
+// default maximum width and height of the uploaded images, in pixels
+const defaultMaxImageDimension = 8192
+
+// default number of workers that generate the renditions of the uploaded images,
+// and of images waiting for them
+const (
+	defaultRenditionWorkers   = 2
+	defaultRenditionQueueSize = 64
+)
+
+// default and maximum number of reviews in a page
+const (
+	defaultReviewPageSize = 20
+	maxReviewPageSize     = 100
+)
+
+// default maximum number of decimals of the rating and review scores
+const defaultScoreDecimals = 1
+
+// tolerance of the check of the score decimals, for the rounding errors of the decimal scores
+const scoreDecimalsTolerance = 1e-6
+
+// maximum number of ratings in a RateLaptops batch
+const maxRatingBatchSize = 100
+
+// default and maximum number of laptops returned by GetTopRatedLaptops
+const (
+	defaultTopRatedLimit = 10
+	maxTopRatedLimit     = 100
+)
+
+// errEnoughLaptops stops the iteration over the top rated laptops once the response is full
+var errEnoughLaptops = errors.New("enough laptops")
+
+// default time between the updates sent by WatchRatings, during which the updates of a laptop are coalesced
+const defaultRatingUpdateInterval = 500 * time.Millisecond
+
+// maximum number of laptops watched by a WatchRatings request
+const maxWatchedLaptops = 100
+
+// maximum length of the title and body of a review, in characters
+const (
+	maxReviewTitleLength = 200
+	maxReviewBodyLength  = 10000
+)
 
 // LaptopServer is the server that provides laptop services
 type LaptopServer struct {
 	pb.UnimplementedLaptopServiceServer
-	laptopStore LaptopStore
-	imageStore  ImageStore
-	ratingStore RatingStore
+	laptopStore        LaptopStore
+	imageStore         ImageStore
+	ratingStore        RatingStore
+	uploadSessionStore UploadSessionStore
+	galleryStore       GalleryStore
+	reviewStore        ReviewStore
+	ratingBroker       *RatingBroker
+	abuseDetector      AbuseDetector
+	quarantineStore    QuarantineStore
+	ratingInterval     time.Duration
+	maxImagesPerLaptop int
+	minScore           float64
+	maxScore           float64
+	scoreDecimals      int
+	imageValidator     *ImageValidator
+	renditionGenerator *RenditionGenerator
+	renditionWorkers   int
+	renditionQueue     chan string
+	maxImageSize       int64
+	uploads            chan struct{}
+	// maxUserUploadSessions is the maximum number of open upload sessions of a user
+	maxUserUploadSessions int
+
+	// renditionMutex keeps the images from being queued once the rendition queue is closed
+	renditionMutex sync.RWMutex
+	renditionsDone sync.WaitGroup
+	closed         bool
+}
+
+// LaptopServerOption configures optional settings of a LaptopServer
+type LaptopServerOption func(server *LaptopServer)
+
+// WithImageValidator sets the validator of the uploaded images
+func WithImageValidator(imageValidator *ImageValidator) LaptopServerOption {
+	return func(server *LaptopServer) {
+		server.imageValidator = imageValidator
+	}
+}
+
+// WithUploadSessionStore sets the store of the resumable upload sessions
+func WithUploadSessionStore(uploadSessionStore UploadSessionStore) LaptopServerOption {
+	return func(server *LaptopServer) {
+		server.uploadSessionStore = uploadSessionStore
+	}
+}
+
+// WithGalleryStore sets the store of the laptop galleries
+func WithGalleryStore(galleryStore GalleryStore) LaptopServerOption {
+	return func(server *LaptopServer) {
+		server.galleryStore = galleryStore
+	}
+}
+
+// WithReviewStore sets the store of the laptop reviews
+func WithReviewStore(reviewStore ReviewStore) LaptopServerOption {
+	return func(server *LaptopServer) {
+		server.reviewStore = reviewStore
+	}
+}
+
+// WithRatingBroker sets the broker of the new ratings watched with WatchRatings.
+// The broker must observe the rating store of the server, see WithRatingObserver.
+func WithRatingBroker(ratingBroker *RatingBroker) LaptopServerOption {
+	return func(server *LaptopServer) {
+		server.ratingBroker = ratingBroker
+	}
+}
+
+// WithRatingUpdateInterval sets the minimum time between the updates sent by WatchRatings.
+// The updates of a laptop rated several times during the interval are coalesced into its latest rating.
+func WithRatingUpdateInterval(interval time.Duration) LaptopServerOption {
+	return func(server *LaptopServer) {
+		server.ratingInterval = interval
+	}
+}
+
+// WithAbuseDetector sets the detector of the suspicious ratings, which are quarantined instead of counted.
+// Without a detector, every valid rating is counted.
+func WithAbuseDetector(abuseDetector AbuseDetector) LaptopServerOption {
+	return func(server *LaptopServer) {
+		server.abuseDetector = abuseDetector
+	}
+}
+
+// WithQuarantineStore sets the store of the quarantined ratings
+func WithQuarantineStore(quarantineStore QuarantineStore) LaptopServerOption {
+	return func(server *LaptopServer) {
+		server.quarantineStore = quarantineStore
+	}
+}
+
+// WithScoreRange sets the range of the valid rating and review scores.
+// The rating store must have the same range, set with WithRatingScoreRange, for its histograms to have a bucket for each score.
+func WithScoreRange(minScore float64, maxScore float64) LaptopServerOption {
+	return func(server *LaptopServer) {
+		server.minScore = minScore
+		server.maxScore = maxScore
+	}
+}
+
+// WithScorePrecision sets the maximum number of decimals of the valid rating and review scores
+func WithScorePrecision(decimals int) LaptopServerOption {
+	return func(server *LaptopServer) {
+		server.scoreDecimals = decimals
+	}
+}
+
+// WithMaxImagesPerLaptop sets the maximum number of images in a laptop gallery
+func WithMaxImagesPerLaptop(maxImagesPerLaptop int) LaptopServerOption {
+	return func(server *LaptopServer) {
+		server.maxImagesPerLaptop = maxImagesPerLaptop
+	}
+}
+
+// WithRenditionSizes sets the long edge sizes, in pixels, of the renditions generated for the uploaded images
+func WithRenditionSizes(sizes ...int) LaptopServerOption {
+	return func(server *LaptopServer) {
+		server.renditionGenerator = NewRenditionGenerator(sizes...)
+	}
+}
+
+// WithRenditionWorkers sets the number of workers that generate the renditions of the uploaded images,
+// and the number of images that wait for a worker. The renditions of the images over this limit are not generated.
+func WithRenditionWorkers(workers int, queueSize int) LaptopServerOption {
+	return func(server *LaptopServer) {
+		server.renditionWorkers = workers
+		server.renditionQueue = make(chan string, queueSize)
+	}
+}
+
+// WithMaxImageSize sets the maximum size of the uploaded images, in bytes
+func WithMaxImageSize(maxImageSize int64) LaptopServerOption {
+	return func(server *LaptopServer) {
+		server.maxImageSize = maxImageSize
+	}
+}
+
+// WithMaxConcurrentUploads sets the maximum number of images that are uploaded at the same time.
+// Uploads over this limit are rejected with a ResourceExhausted error.
+func WithMaxConcurrentUploads(maxConcurrentUploads int) LaptopServerOption {
+	return func(server *LaptopServer) {
+		server.uploads = make(chan struct{}, maxConcurrentUploads)
+	}
+}
+
+// WithMaxUserUploadSessions sets the maximum number of open upload sessions of a user.
+// The sessions of all users are limited to the maximum number of concurrent uploads.
+func WithMaxUserUploadSessions(maxUserUploadSessions int) LaptopServerOption {
+	return func(server *LaptopServer) {
+		server.maxUserUploadSessions = maxUserUploadSessions
+	}
 }
 
 // NewLaptopServer returns a new LaptopServer
-func NewLaptopServer(laptopStore LaptopStore, imageStore ImageStore, ratingStore RatingStore) *LaptopServer {
-	return &LaptopServer{
-		laptopStore: laptopStore,
-		imageStore:  imageStore,
-		ratingStore: ratingStore,
+func NewLaptopServer(
+	laptopStore LaptopStore,
+	imageStore ImageStore,
+	ratingStore RatingStore,
+	options ...LaptopServerOption,
+) *LaptopServer {
+	server := &LaptopServer{
+		laptopStore:        laptopStore,
+		imageStore:         imageStore,
+		ratingStore:        ratingStore,
+		uploadSessionStore: NewInMemoryUploadSessionStore(defaultUploadSessionTTL),
+		galleryStore:       NewInMemoryGalleryStore(),
+		reviewStore:        NewInMemoryReviewStore(),
+		ratingBroker:       NewRatingBroker(),
+		ratingInterval:     defaultRatingUpdateInterval,
+		quarantineStore:    NewInMemoryQuarantineStore(),
+		maxImagesPerLaptop: defaultMaxImagesPerLaptop,
+		minScore:           MinScore,
+		maxScore:           MaxScore,
+		scoreDecimals:      defaultScoreDecimals,
+		imageValidator:     NewImageValidator(defaultMaxImageDimension, defaultMaxImageDimension),
+		renditionGenerator: NewRenditionGenerator(DefaultRenditionSizes...),
+		renditionWorkers:   defaultRenditionWorkers,
+		renditionQueue:     make(chan string, defaultRenditionQueueSize),
+		maxImageSize:       defaultMaxImageSize,
+		uploads:            make(chan struct{}, defaultMaxConcurrentUploads),
+
+		maxUserUploadSessions: defaultMaxUserUploadSessions,
 	}
+
+	for _, option := range options {
+		option(server)
+	}
+
+	for i := 0; i < server.renditionWorkers; i++ {
+		server.renditionsDone.Add(1)
+		go server.generateRenditions()
+	}
+	return server
+}
+
+// Close stops generating renditions, once the renditions of the queued images are saved
+func (server *LaptopServer) Close() {
+	server.renditionMutex.Lock()
+	if !server.closed {
+		server.closed = true
+		close(server.renditionQueue)
+	}
+	server.renditionMutex.Unlock()
+
+	server.renditionsDone.Wait()
 }
 
 // CreateLaptop is a unary RPC to create a new laptop
@@ -64,6 +333,10 @@ func (server *LaptopServer) CreateLaptop(
 		return nil, err
 	}
 
+	// the gallery fields are computed for every response
+	laptop.PrimaryImageId = ""
+	laptop.ImageCount = 0
+
 	//save the laptop to store
 	err := server.laptopStore.Save(laptop)
 	if err != nil {
@@ -82,6 +355,33 @@ func (server *LaptopServer) CreateLaptop(
 	return res, nil
 }
 
+// GetLaptop is a unary RPC to get a laptop by ID
+func (server *LaptopServer) GetLaptop(
+	ctx context.Context,
+	req *pb.GetLaptopRequest,
+) (*pb.GetLaptopResponse, error) {
+	laptopID := req.GetId()
+	log.Printf("receive a get-laptop request with id: %s", laptopID)
+
+	laptop, err := server.laptopStore.Find(laptopID)
+	if err != nil {
+		return nil, logError(status.Errorf(codes.Internal, "cannot find laptop: %v", err))
+	}
+	if laptop == nil {
+		return nil, logError(status.Errorf(codes.NotFound, "laptop %s doesn't exist", laptopID))
+	}
+
+	err = server.setGalleryFields(laptop)
+	if err != nil {
+		return nil, logError(err)
+	}
+
+	res := &pb.GetLaptopResponse{
+		Laptop: laptop,
+	}
+	return res, nil
+}
+
 // SearchLaptop is a server-streaming RPC to search for laptops
 func (server *LaptopServer) SearchLaptop(
 	req *pb.SearchLaptopRequest,
@@ -94,9 +394,14 @@ func (server *LaptopServer) SearchLaptop(
 		stream.Context(),
 		filter,
 		func(laptop *pb.Laptop) error {
+			err := server.setGalleryFields(laptop)
+			if err != nil {
+				return err
+			}
+
 			res := &pb.SearchLaptopResponse{Laptop: laptop}
 
-			err := stream.Send(res)
+			err = stream.Send(res)
 			if err != nil {
 				return err
 			}
@@ -116,7 +421,9 @@ func (server *LaptopServer) SearchLaptop(
 	return nil
 }
 
-// UploadImage is a client-streaming RPC to upload a laptop image
+// UploadImage is a client-streaming RPC to upload a laptop image.
+// The image data is streamed to the image store as the chunks are received.
+// If the client sends the expected size and checksum of the image, the image is only saved if they match.
 func (server *LaptopServer) UploadImage(stream pb.LaptopService_UploadImageServer) error {
 	req, err := stream.Recv()
 	if err != nil {
@@ -136,53 +443,48 @@ func (server *LaptopServer) UploadImage(stream pb.LaptopService_UploadImageServe
 		return logError(status.Errorf(codes.InvalidArgument, "laptop %s doesnt exist", laptopID))
 	}
 
-	imageData := bytes.Buffer{}
-	imageSize := 0
-
-	for {
-		// check context error
-		if err := contextError(stream.Context()); err != nil {
-			return err
-		}
-
-		log.Print("waiting to receive more data")
-
-		req, err := stream.Recv()
-		if err == io.EOF {
-			log.Print("no more data")
-			break
-		}
-		if err != nil {
-			return logError(status.Errorf(codes.Unknown, "cannot receive chunk data: %v", err))
-		}
-
-		chunk := req.GetChunkData()
-		size := len(chunk)
+	err = server.checkGalleryLimit(laptopID)
+	if err != nil {
+		return logError(err)
+	}
 
-		log.Printf("received a chunk with size: %d", size)
+	release, err := server.acquireUpload()
+	if err != nil {
+		return logError(err)
+	}
+	defer release()
 
-		imageSize += size
-		if imageSize > maxImageSize {
-			return logError(status.Errorf(codes.InvalidArgument, "image is too large: %d > %d", imageSize, maxImageSize))
-		}
+	uploader := ""
+	if claims, ok := ClaimsFromContext(stream.Context()); ok {
+		uploader = claims.Username
+	}
 
-		// write slowly
-		// time.Sleep(time.Second)
+	expectedSize := int64(req.GetInfo().GetSize())
+	if expectedSize > server.maxImageSize {
+		return logError(status.Errorf(codes.InvalidArgument, "image is too large: %d > %d", expectedSize, server.maxImageSize))
+	}
 
-		_, err = imageData.Write(chunk)
-		if err != nil {
-			return logError(status.Errorf(codes.Internal, "cannot write chunk data: %v", err))
-		}
+	imageData := &uploadStreamReader{
+		stream:           stream,
+		maxSize:          server.maxImageSize,
+		expectedSize:     expectedSize,
+		expectedChecksum: req.GetInfo().GetChecksum(),
+		hash:             sha256.New(),
+	}
 
+	imageID, err := server.saveImage(laptopID, imageType, uploader, imageData)
+	if imageData.err != nil {
+		// the image store only sees a failed read, the reader knows why it failed
+		return logError(imageData.err)
 	}
-	imageID, err := server.imageStore.Save(laptopID, imageType, imageData)
 	if err != nil {
-		return logError(status.Errorf(codes.Internal, "cannot save image to the store: %v", err))
+		return logError(err)
 	}
 
 	res := &pb.UploadImageResponse{
-		Id:   imageID,
-		Size: uint32(imageSize),
+		Id:       imageID,
+		Size:     uint32(imageData.size),
+		Checksum: imageData.checksum(),
 	}
 
 	err = stream.SendAndClose(res)
@@ -190,74 +492,1547 @@ func (server *LaptopServer) UploadImage(stream pb.LaptopService_UploadImageServe
 		return logError(status.Errorf(codes.Unknown, "cannot send response: %v", err))
 	}
 
-	log.Printf("saved image with id: %s, size: %d", imageID, imageSize)
+	log.Printf("saved image with id: %s, size: %d", imageID, imageData.size)
 	return nil
 
 }
 
-// RateLaptop is a bidirectional-streaming RPC that allows client to rate a stream of laptops
-// with a score, and returns a stream of average score for each of them
-func (server *LaptopServer) RateLaptop(stream pb.LaptopService_RateLaptopServer) error {
-	for {
-		err := contextError(stream.Context())
-		if err != nil {
-			return nil
-		}
+// StartUpload is a unary RPC to start a resumable image upload
+func (server *LaptopServer) StartUpload(
+	ctx context.Context,
+	req *pb.StartUploadRequest,
+) (*pb.StartUploadResponse, error) {
+	laptopID := req.GetInfo().GetLaptopId()
+	imageType := req.GetInfo().GetImageType()
+	log.Printf("receive a start-upload request for laptop %s with image type %s", laptopID, imageType)
 
-		req, err := stream.Recv()
-		if err == io.EOF {
-			log.Print("no more data")
-			break
-		}
-		if err != nil {
-			return logError(status.Errorf(
-				codes.Unknown,
-				"cannot receive stream request: %v", err,
-			))
-		}
+	laptop, err := server.laptopStore.Find(laptopID)
+	if err != nil {
+		return nil, logError(status.Errorf(codes.Internal, "cannot find laptop: %v", err))
+	}
+	if laptop == nil {
+		return nil, logError(status.Errorf(codes.InvalidArgument, "laptop %s doesnt exist", laptopID))
+	}
 
-		laptopID := req.GetLaptopId()
-		score := req.GetScore()
+	expectedSize := int64(req.GetInfo().GetSize())
+	if expectedSize > server.maxImageSize {
+		return nil, logError(status.Errorf(codes.InvalidArgument, "image is too large: %d > %d", expectedSize, server.maxImageSize))
+	}
 
-		log.Printf("received a rate-laptop request: [ id = %s | score = %.2f ]", laptopID, score)
+	err = server.checkGalleryLimit(laptopID)
+	if err != nil {
+		return nil, logError(err)
+	}
 
-		found, err := server.laptopStore.Find(laptopID)
-		if err != nil {
-			return logError(status.Errorf(
-				codes.Internal,
-				"cannot find laptop: %v", err,
-			))
-		}
-		if found == nil {
-			return logError(status.Errorf(
-				codes.NotFound,
-				"laptopID %s is not found", laptopID,
-			))
+	// the expected size and checksum are checked by UploadChunk and CompleteUpload
+	info := &ImageInfo{
+		LaptopID: laptopID,
+		Type:     imageType,
+		Size:     expectedSize,
+		Checksum: req.GetInfo().GetChecksum(),
+	}
+	if claims, ok := ClaimsFromContext(ctx); ok {
+		info.Uploader = claims.Username
+	}
+
+	// the open sessions count against the concurrent uploads, as each of them holds a file
+	session, err := server.uploadSessionStore.Start(info, cap(server.uploads), server.maxUserUploadSessions)
+	if errors.Is(err, ErrTooManySessions) {
+		return nil, logError(status.Errorf(codes.ResourceExhausted, "cannot start upload: %v", err))
+	}
+	if err != nil {
+		return nil, logError(status.Errorf(codes.Internal, "cannot start upload: %v", err))
+	}
+
+	log.Printf("started upload with id: %s", session.ID)
+
+	res := &pb.StartUploadResponse{
+		UploadId:  session.ID,
+		ExpiresAt: timestamppb.New(session.ExpiresAt),
+	}
+	return res, nil
+}
+
+// UploadChunk is a unary RPC to upload a chunk of a resumable image upload at a given offset.
+// Uploading a chunk again is a no-op, so the client can retry any chunk that isn't acknowledged.
+func (server *LaptopServer) UploadChunk(
+	ctx context.Context,
+	req *pb.UploadChunkRequest,
+) (*pb.UploadChunkResponse, error) {
+	uploadID := req.GetUploadId()
+	offset := req.GetOffset()
+	chunk := req.GetChunkData()
+	log.Printf("receive an upload-chunk request for upload %s at offset %d with size %d", uploadID, offset, len(chunk))
+
+	session, err := server.findUploadSession(ctx, uploadID)
+	if err != nil {
+		return nil, logError(err)
+	}
+
+	imageSize := int64(offset) + int64(len(chunk))
+	if imageSize > server.maxImageSize {
+		return nil, logError(status.Errorf(codes.InvalidArgument, "image is too large: %d > %d", imageSize, server.maxImageSize))
+	}
+	if expectedSize := session.Info.Size; expectedSize > 0 && imageSize > expectedSize {
+		return nil, logError(status.Errorf(codes.DataLoss, "received %d bytes, expected %d", imageSize, expectedSize))
+	}
+
+	committed, err := server.uploadSessionStore.Write(uploadID, int64(offset), chunk)
+	if err != nil {
+		return nil, logError(uploadSessionError(err))
+	}
+
+	res := &pb.UploadChunkResponse{
+		CommittedOffset: uint64(committed),
+	}
+	return res, nil
+}
+
+// GetUploadStatus is a unary RPC to get the committed offset of a resumable image upload,
+// which is where the client must resume the upload from
+func (server *LaptopServer) GetUploadStatus(
+	ctx context.Context,
+	req *pb.GetUploadStatusRequest,
+) (*pb.GetUploadStatusResponse, error) {
+	uploadID := req.GetUploadId()
+	log.Printf("receive a get-upload-status request for upload %s", uploadID)
+
+	session, err := server.findUploadSession(ctx, uploadID)
+	if err != nil {
+		return nil, logError(err)
+	}
+
+	res := &pb.GetUploadStatusResponse{
+		UploadId:        session.ID,
+		CommittedOffset: uint64(session.CommittedOffset),
+		ExpiresAt:       timestamppb.New(session.ExpiresAt),
+	}
+	return res, nil
+}
+
+// CompleteUpload is a unary RPC to complete a resumable image upload.
+// It checks the total size and checksum of the uploaded data before saving the image.
+func (server *LaptopServer) CompleteUpload(
+	ctx context.Context,
+	req *pb.CompleteUploadRequest,
+) (*pb.CompleteUploadResponse, error) {
+	uploadID := req.GetUploadId()
+	log.Printf("receive a complete-upload request for upload %s", uploadID)
+
+	session, err := server.findUploadSession(ctx, uploadID)
+	if err != nil {
+		return nil, logError(err)
+	}
+
+	// the size and checksum given when the upload started must be the ones of the completed image
+	if expectedSize := session.Info.Size; expectedSize > 0 && int64(req.GetSize()) != expectedSize {
+		return nil, logError(status.Errorf(codes.DataLoss, "completed %d bytes, expected %d", req.GetSize(), expectedSize))
+	}
+	if expectedChecksum := session.Info.Checksum; expectedChecksum != "" && !strings.EqualFold(req.GetChecksum(), expectedChecksum) {
+		return nil, logError(status.Errorf(codes.DataLoss, "completed image has checksum %s, expected %s", req.GetChecksum(), expectedChecksum))
+	}
+
+	release, err := server.acquireUpload()
+	if err != nil {
+		return nil, logError(err)
+	}
+	defer release()
+
+	// the session is kept if the image can't be saved, so that the client can retry without uploading it again
+	var imageID string
+	var imageSize int64
+	err = server.uploadSessionStore.Complete(
+		uploadID,
+		int64(req.GetSize()),
+		req.GetChecksum(),
+		func(session *UploadSession, imageData io.Reader) error {
+			var err error
+			imageSize = session.CommittedOffset
+			imageID, err = server.saveImage(session.Info.LaptopID, session.Info.Type, session.Info.Uploader, imageData)
+			return err
+		},
+	)
+	if err != nil {
+		// the errors of saveImage are already status errors
+		if _, ok := status.FromError(err); !ok {
+			err = uploadSessionError(err)
 		}
+		return nil, logError(err)
+	}
+
+	log.Printf("saved image with id: %s, size: %d", imageID, imageSize)
+
+	res := &pb.CompleteUploadResponse{
+		Id:   imageID,
+		Size: uint64(imageSize),
+	}
+	return res, nil
+}
+
+// DownloadImage is a server-streaming RPC to download a laptop image.
+// It sends the image info first, then the image data in chunks.
+func (server *LaptopServer) DownloadImage(
+	req *pb.DownloadImageRequest,
+	stream pb.LaptopService_DownloadImageServer,
+) error {
+	imageID := req.GetImageId()
+	rendition := int(req.GetRendition())
+	log.Printf("receive a download-image request for image %s with rendition %d", imageID, rendition)
+
+	if rendition != 0 && !server.renditionGenerator.HasSize(rendition) {
+		return logError(status.Errorf(codes.InvalidArgument, "unknown rendition %d", rendition))
+	}
+
+	info, imageData, err := server.imageStore.Open(imageID)
+	if errors.Is(err, ErrNotFound) {
+		return logError(status.Errorf(codes.NotFound, "image %s doesn't exist", imageID))
+	}
+	if err != nil {
+		return logError(status.Errorf(codes.Internal, "cannot open image: %v", err))
+	}
+	defer func() {
+		imageData.Close()
+	}()
 
-		rating, err := server.ratingStore.Add(laptopID, score)
+	if rendition != 0 {
+		renditions, err := server.renditions(info)
 		if err != nil {
-			return logError(status.Errorf(
-				codes.Internal,
-				"cannot add rating to the store: %v", err,
-			))
+			return logError(status.Errorf(codes.Internal, "cannot list renditions: %v", err))
 		}
 
-		res := &pb.RateLaptopResponse{
-			LaptopId:     laptopID,
-			RatedCount:   rating.Count,
-			AverageScore: rating.Sum / float64(rating.Count),
-		}
+		// images are never upscaled, so the original image is served
+		// when it is smaller than the requested rendition
+		for _, other := range renditions {
+			if other.Rendition != rendition {
+				continue
+			}
 
-		err = stream.Send(res)
-		if err != nil {
-			return logError(status.Errorf(
-				codes.Unknown,
-				"cannot send stream response: %v", err,
-			))
+			renditionInfo, renditionData, err := server.imageStore.Open(other.ID)
+			if err != nil {
+				return logError(status.Errorf(codes.Internal, "cannot open rendition: %v", err))
+			}
+
+			imageData.Close()
+			info, imageData = renditionInfo, renditionData
+			break
 		}
 	}
-	return nil
+
+	res := &pb.DownloadImageResponse{
+		Data: &pb.DownloadImageResponse_Info{
+			Info: &pb.ImageInfo{
+				LaptopId:  info.LaptopID,
+				ImageType: info.Type,
+				Size:      uint64(info.Size),
+				Checksum:  info.Checksum,
+			},
+		},
+	}
+
+	err = stream.Send(res)
+	if err != nil {
+		return logError(status.Errorf(codes.Unknown, "cannot send image info: %v", err))
+	}
+
+	buffer := make([]byte, imageChunkSize)
+	imageSize := 0
+
+	for {
+		if err := contextError(stream.Context()); err != nil {
+			return err
+		}
+
+		n, err := imageData.Read(buffer)
+		if n > 0 {
+			res := &pb.DownloadImageResponse{
+				Data: &pb.DownloadImageResponse_ChunkData{
+					ChunkData: buffer[:n],
+				},
+			}
+
+			err := stream.Send(res)
+			if err != nil {
+				return logError(status.Errorf(codes.Unknown, "cannot send chunk data: %v", err))
+			}
+			imageSize += n
+		}
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return logError(status.Errorf(codes.Internal, "cannot read image data: %v", err))
+		}
+	}
+
+	log.Printf("sent image with id: %s, size: %d", imageID, imageSize)
+	return nil
+}
+
+// ListImages is a unary RPC to list the images of a laptop
+func (server *LaptopServer) ListImages(
+	ctx context.Context,
+	req *pb.ListImagesRequest,
+) (*pb.ListImagesResponse, error) {
+	laptopID := req.GetLaptopId()
+	log.Printf("receive a list-images request for laptop %s", laptopID)
+
+	images, err := server.imageStore.List(laptopID)
+	if err != nil {
+		return nil, logError(status.Errorf(codes.Internal, "cannot list images: %v", err))
+	}
+
+	gallery, err := server.galleryStore.Images(laptopID)
+	if err != nil {
+		return nil, logError(status.Errorf(codes.Internal, "cannot find gallery: %v", err))
+	}
+
+	metadata := make(map[string]*pb.ImageMetadata)
+	for _, info := range images {
+		if info.OriginalID == "" {
+			metadata[info.ID] = toImageMetadata(info)
+		}
+	}
+
+	for _, info := range images {
+		if original := metadata[info.OriginalID]; original != nil {
+			original.Renditions = append(original.Renditions, uint32(info.Rendition))
+		}
+	}
+
+	// the images are listed in gallery order, then the images that are not
+	// in the gallery because they were uploaded before it existed, in upload order
+	res := &pb.ListImagesResponse{}
+	for _, imageID := range gallery {
+		if image := metadata[imageID]; image != nil {
+			res.Images = append(res.Images, image)
+			delete(metadata, imageID)
+		}
+	}
+
+	for _, info := range images {
+		if image := metadata[info.ID]; image != nil {
+			res.Images = append(res.Images, image)
+		}
+	}
+	return res, nil
+}
+
+// DeleteImage is a unary RPC to delete a laptop image
+func (server *LaptopServer) DeleteImage(
+	ctx context.Context,
+	req *pb.DeleteImageRequest,
+) (*pb.DeleteImageResponse, error) {
+	imageID := req.GetImageId()
+	log.Printf("receive a delete-image request for image %s", imageID)
+
+	info, err := server.imageStore.Stat(imageID)
+	if errors.Is(err, ErrNotFound) {
+		return nil, logError(status.Errorf(codes.NotFound, "image %s doesn't exist", imageID))
+	}
+	if err != nil {
+		return nil, logError(status.Errorf(codes.Internal, "cannot find image: %v", err))
+	}
+
+	renditions, err := server.renditions(info)
+	if err != nil {
+		return nil, logError(status.Errorf(codes.Internal, "cannot list renditions: %v", err))
+	}
+
+	err = server.imageStore.Delete(imageID)
+	if errors.Is(err, ErrNotFound) {
+		return nil, logError(status.Errorf(codes.NotFound, "image %s doesn't exist", imageID))
+	}
+	if err != nil {
+		return nil, logError(status.Errorf(codes.Internal, "cannot delete image: %v", err))
+	}
+
+	for _, rendition := range renditions {
+		err = server.imageStore.Delete(rendition.ID)
+		if err != nil && !errors.Is(err, ErrNotFound) {
+			return nil, logError(status.Errorf(codes.Internal, "cannot delete rendition: %v", err))
+		}
+	}
+
+	err = server.galleryStore.Remove(info.LaptopID, imageID)
+	if err != nil && !errors.Is(err, ErrNotFound) {
+		return nil, logError(status.Errorf(codes.Internal, "cannot remove image from the gallery: %v", err))
+	}
+
+	log.Printf("deleted image with id: %s", imageID)
+	return &pb.DeleteImageResponse{}, nil
+}
+
+// SetPrimaryImage is a unary RPC to make an image the primary image of its laptop,
+// by moving it to the front of the laptop gallery
+func (server *LaptopServer) SetPrimaryImage(
+	ctx context.Context,
+	req *pb.SetPrimaryImageRequest,
+) (*pb.SetPrimaryImageResponse, error) {
+	laptopID := req.GetLaptopId()
+	imageID := req.GetImageId()
+	log.Printf("receive a set-primary-image request for laptop %s with image %s", laptopID, imageID)
+
+	err := server.galleryStore.SetPrimary(laptopID, imageID)
+	if err != nil {
+		return nil, logError(galleryError(err, laptopID))
+	}
+
+	return &pb.SetPrimaryImageResponse{}, nil
+}
+
+// ReorderImages is a unary RPC to change the order of a laptop gallery.
+// The request must list all images of the gallery, in their new order.
+func (server *LaptopServer) ReorderImages(
+	ctx context.Context,
+	req *pb.ReorderImagesRequest,
+) (*pb.ReorderImagesResponse, error) {
+	laptopID := req.GetLaptopId()
+	log.Printf("receive a reorder-images request for laptop %s", laptopID)
+
+	err := server.galleryStore.Reorder(laptopID, req.GetImageIds())
+	if err != nil {
+		return nil, logError(galleryError(err, laptopID))
+	}
+
+	return &pb.ReorderImagesResponse{}, nil
+}
+
+// RateLaptop is a bidirectional-streaming RPC that allows client to rate a stream of laptops
+// with a score, and returns a stream of average score for each of them.
+// A user has one score per laptop, which is replaced when the user rates the laptop again.
+func (server *LaptopServer) RateLaptop(stream pb.LaptopService_RateLaptopServer) error {
+	// without authentication, the scores are anonymous and never replaced
+	username := ""
+	if claims, ok := ClaimsFromContext(stream.Context()); ok {
+		username = claims.Username
+	}
+
+	for {
+		err := contextError(stream.Context())
+		if err != nil {
+			return nil
+		}
+
+		req, err := stream.Recv()
+		if err == io.EOF {
+			log.Print("no more data")
+			break
+		}
+		if err != nil {
+			return logError(status.Errorf(
+				codes.Unknown,
+				"cannot receive stream request: %v", err,
+			))
+		}
+
+		laptopID := req.GetLaptopId()
+		score := req.GetScore()
+
+		log.Printf("received a rate-laptop request: [ id = %s | score = %.2f ]", laptopID, score)
+
+		// an invalid request gets an error response, and the stream goes on
+		err = server.validateScore(score)
+		if err != nil {
+			err = status.Errorf(codes.InvalidArgument, "invalid score: %v", err)
+		} else {
+			err = server.checkRatedLaptop(laptopID)
+		}
+		if status.Code(err) == codes.InvalidArgument || status.Code(err) == codes.NotFound {
+			log.Print(err)
+
+			err = stream.Send(&pb.RateLaptopResponse{
+				LaptopId: laptopID,
+				Error:    status.Convert(err).Proto(),
+			})
+			if err != nil {
+				return logError(status.Errorf(
+					codes.Unknown,
+					"cannot send stream response: %v", err,
+				))
+			}
+			continue
+		}
+		if err != nil {
+			return logError(err)
+		}
+
+		rating, quarantined, err := server.countVote(&Vote{
+			LaptopID: laptopID,
+			Username: username,
+			Score:    score,
+			Time:     time.Now(),
+		})
+		if err != nil {
+			return logError(err)
+		}
+
+		res := toRateLaptopResponse(laptopID, rating)
+		res.Quarantined = quarantined
+
+		err = stream.Send(res)
+		if err != nil {
+			return logError(status.Errorf(
+				codes.Unknown,
+				"cannot send stream response: %v", err,
+			))
+		}
+	}
+	return nil
+}
+
+// RateLaptops is a unary RPC to rate a batch of laptops atomically:
+// the ratings are all counted with one operation of the rating store, or all rejected with the violations of the invalid ones.
+// The abuse detector checks the batch as a whole, and a flagged batch is quarantined with one operation instead.
+func (server *LaptopServer) RateLaptops(
+	ctx context.Context,
+	req *pb.RateLaptopsRequest,
+) (*pb.RateLaptopsResponse, error) {
+	// without authentication, the scores are anonymous and never replaced
+	username := ""
+	if claims, ok := ClaimsFromContext(ctx); ok {
+		username = claims.Username
+	}
+
+	ratings := req.GetRatings()
+	log.Printf("receive a rate-laptops request with %d ratings", len(ratings))
+
+	if len(ratings) > maxRatingBatchSize {
+		return nil, logError(status.Errorf(codes.InvalidArgument, "a batch has at most %d ratings", maxRatingBatchSize))
+	}
+
+	// every rating is checked before any of them is counted
+	var violations []*errdetails.BadRequest_FieldViolation
+	for i, rating := range ratings {
+		err := server.validateScore(rating.GetScore())
+		if err != nil {
+			violations = append(violations, &errdetails.BadRequest_FieldViolation{
+				Field:       fmt.Sprintf("ratings[%d].score", i),
+				Description: err.Error(),
+			})
+		}
+
+		err = server.checkRatedLaptop(rating.GetLaptopId())
+		if status.Code(err) == codes.NotFound {
+			violations = append(violations, &errdetails.BadRequest_FieldViolation{
+				Field:       fmt.Sprintf("ratings[%d].laptop_id", i),
+				Description: status.Convert(err).Message(),
+			})
+		} else if err != nil {
+			return nil, logError(err)
+		}
+	}
+
+	if len(violations) > 0 {
+		st, err := status.New(codes.InvalidArgument, "the batch has invalid ratings").
+			WithDetails(&errdetails.BadRequest{FieldViolations: violations})
+		if err != nil {
+			return nil, logError(status.Errorf(codes.Internal, "cannot add error details: %v", err))
+		}
+		return nil, logError(st.Err())
+	}
+
+	res := &pb.RateLaptopsResponse{}
+	if len(ratings) == 0 {
+		return res, nil
+	}
+
+	// the ratings of a batch have the same time
+	now := time.Now()
+	votes := make([]*Vote, len(ratings))
+	for i, rating := range ratings {
+		votes[i] = &Vote{
+			LaptopID: rating.GetLaptopId(),
+			Username: username,
+			Score:    rating.GetScore(),
+			Time:     now,
+		}
+	}
+
+	reason := ""
+	if server.abuseDetector != nil {
+		reason = server.abuseDetector.Check(votes...)
+	}
+
+	var counted []*Rating
+	var err error
+	if reason == "" {
+		counted, err = server.ratingStore.AddAll(votes)
+		if err != nil {
+			return nil, logError(status.Errorf(codes.Internal, "cannot add ratings to the store: %v", err))
+		}
+	} else {
+		counted, err = server.quarantineVotes(votes, reason)
+		if err != nil {
+			return nil, logError(err)
+		}
+	}
+
+	for i, vote := range votes {
+		response := toRateLaptopResponse(vote.LaptopID, counted[i])
+		response.Quarantined = reason != ""
+		res.Ratings = append(res.Ratings, response)
+	}
+	return res, nil
+}
+
+// countVote adds a vote to the rating store, unless the abuse detector flags it as suspicious.
+// A suspicious vote is quarantined instead, and the current rating of the laptop, possibly nil, is returned.
+func (server *LaptopServer) countVote(vote *Vote) (rating *Rating, quarantined bool, err error) {
+	reason := ""
+	if server.abuseDetector != nil {
+		reason = server.abuseDetector.Check(vote)
+	}
+
+	if reason == "" {
+		rating, err = server.ratingStore.Add(vote)
+		if err != nil {
+			return nil, false, status.Errorf(codes.Internal, "cannot add rating to the store: %v", err)
+		}
+		return rating, false, nil
+	}
+
+	ratings, err := server.quarantineVotes([]*Vote{vote}, reason)
+	if err != nil {
+		return nil, false, err
+	}
+	return ratings[0], true, nil
+}
+
+// quarantineVotes quarantines a suspicious batch of votes with one operation,
+// and returns the current rating of the laptop of each vote, possibly nil. It returns a gRPC status error.
+func (server *LaptopServer) quarantineVotes(votes []*Vote, reason string) ([]*Rating, error) {
+	quarantined := make([]*QuarantinedVote, len(votes))
+	for i, vote := range votes {
+		id, err := uuid.NewRandom()
+		if err != nil {
+			return nil, status.Errorf(codes.Internal, "cannot generate a quarantine ID: %v", err)
+		}
+		quarantined[i] = &QuarantinedVote{ID: id.String(), Vote: *vote, Reason: reason}
+	}
+
+	err := server.quarantineStore.AddAll(quarantined)
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "cannot quarantine ratings: %v", err)
+	}
+
+	ratings := make([]*Rating, len(votes))
+	for i, vote := range quarantined {
+		log.Printf("quarantined rating %s of laptop %s: %s", vote.ID, vote.Vote.LaptopID, reason)
+
+		ratings[i], err = server.ratingStore.Find(vote.Vote.LaptopID)
+		if err != nil {
+			return nil, status.Errorf(codes.Internal, "cannot find rating: %v", err)
+		}
+	}
+	return ratings, nil
+}
+
+// GetRating is a unary RPC to get the rating of a laptop and the statistics of its scores.
+// A laptop that has never been rated has a zero count and no statistics.
+func (server *LaptopServer) GetRating(
+	ctx context.Context,
+	req *pb.GetRatingRequest,
+) (*pb.GetRatingResponse, error) {
+	laptopID := req.GetLaptopId()
+	log.Printf("receive a get-rating request for laptop %s", laptopID)
+
+	laptop, err := server.laptopStore.Find(laptopID)
+	if err != nil {
+		return nil, logError(status.Errorf(codes.Internal, "cannot find laptop: %v", err))
+	}
+	if laptop == nil {
+		return nil, logError(status.Errorf(codes.NotFound, "laptop %s doesn't exist", laptopID))
+	}
+
+	var rating *Rating
+	if req.GetWindowDays() > 0 {
+		rating, err = server.ratingStore.FindSince(laptopID, windowStart(req.GetWindowDays()))
+	} else {
+		rating, err = server.ratingStore.Find(laptopID)
+	}
+	if err != nil {
+		return nil, logError(status.Errorf(codes.Internal, "cannot find rating: %v", err))
+	}
+
+	res := &pb.GetRatingResponse{
+		LaptopId: laptopID,
+	}
+	if rating != nil && rating.Count > 0 {
+		res.RatedCount = rating.Count
+		res.AverageScore = rating.Average()
+		res.Statistics = toRatingStatistics(rating)
+	}
+	return res, nil
+}
+
+// GetTopRatedLaptops is a unary RPC to get the laptops with the best Bayesian average,
+// among the laptops rated enough times that match the optional filter
+func (server *LaptopServer) GetTopRatedLaptops(
+	ctx context.Context,
+	req *pb.GetTopRatedLaptopsRequest,
+) (*pb.GetTopRatedLaptopsResponse, error) {
+	filter := req.GetFilter()
+	log.Printf("receive a get-top-rated-laptops request with min count %d and filter %v", req.GetMinRatedCount(), filter)
+
+	limit := defaultTopRatedLimit
+	if req.GetLimit() > 0 {
+		limit = min(int(req.GetLimit()), maxTopRatedLimit)
+	}
+
+	var since time.Time
+	if req.GetWindowDays() > 0 {
+		since = windowStart(req.GetWindowDays())
+	}
+
+	res := &pb.GetTopRatedLaptopsResponse{}
+	err := server.ratingStore.TopRated(req.GetMinRatedCount(), since, func(laptopID string, rating *Rating) error {
+		err := contextError(ctx)
+		if err != nil {
+			return err
+		}
+
+		// the ratings of deleted laptops are skipped
+		laptop, err := server.laptopStore.Find(laptopID)
+		if err != nil {
+			return logError(status.Errorf(codes.Internal, "cannot find laptop: %v", err))
+		}
+		if laptop == nil || (filter != nil && !isQualified(filter, laptop)) {
+			return nil
+		}
+
+		err = server.setGalleryFields(laptop)
+		if err != nil {
+			return logError(err)
+		}
+
+		res.Laptops = append(res.Laptops, &pb.RatedLaptop{
+			Laptop:       laptop,
+			RatedCount:   rating.Count,
+			AverageScore: rating.Average(),
+			Statistics:   toRatingStatistics(rating),
+		})
+		if len(res.Laptops) == limit {
+			return errEnoughLaptops
+		}
+		return nil
+	})
+	if err != nil && !errors.Is(err, errEnoughLaptops) {
+		return nil, err
+	}
+
+	return res, nil
+}
+
+// ListQuarantinedRatings is a unary RPC to list the quarantined ratings of a laptop, or of all laptops, oldest first
+func (server *LaptopServer) ListQuarantinedRatings(
+	ctx context.Context,
+	req *pb.ListQuarantinedRatingsRequest,
+) (*pb.ListQuarantinedRatingsResponse, error) {
+	log.Printf("receive a list-quarantined-ratings request for laptop %q", req.GetLaptopId())
+
+	votes, err := server.quarantineStore.List(req.GetLaptopId())
+	if err != nil {
+		return nil, logError(status.Errorf(codes.Internal, "cannot list quarantined ratings: %v", err))
+	}
+
+	res := &pb.ListQuarantinedRatingsResponse{}
+	for _, vote := range votes {
+		res.Ratings = append(res.Ratings, toQuarantinedRatingMessage(vote))
+	}
+	return res, nil
+}
+
+// ReleaseQuarantinedRating is a unary RPC to count a quarantined rating.
+// A released rating doesn't replace a more recent rating of the same user for the same laptop.
+func (server *LaptopServer) ReleaseQuarantinedRating(
+	ctx context.Context,
+	req *pb.ReleaseQuarantinedRatingRequest,
+) (*pb.ReleaseQuarantinedRatingResponse, error) {
+	quarantineID := req.GetQuarantineId()
+	log.Printf("receive a release-quarantined-rating request for %s", quarantineID)
+
+	vote, err := server.quarantineStore.Find(quarantineID)
+	if err != nil {
+		return nil, logError(status.Errorf(codes.Internal, "cannot find quarantined rating: %v", err))
+	}
+	if vote == nil {
+		return nil, logError(status.Errorf(codes.NotFound, "rating %s isn't quarantined", quarantineID))
+	}
+
+	err = server.checkRatedLaptop(vote.Vote.LaptopID)
+	if err != nil {
+		return nil, logError(err)
+	}
+
+	// the rating is removed first, so that it is released only once
+	_, err = server.quarantineStore.Remove(quarantineID)
+	if errors.Is(err, ErrNotFound) {
+		return nil, logError(status.Errorf(codes.NotFound, "rating %s isn't quarantined", quarantineID))
+	}
+	if err != nil {
+		return nil, logError(status.Errorf(codes.Internal, "cannot remove quarantined rating: %v", err))
+	}
+
+	// the rating store checks that the user hasn't voted again since, in the same step as it adds the vote
+	rating, _, err := server.ratingStore.AddIfNewer(&vote.Vote)
+	if err != nil {
+		// the rating goes back to the quarantine, so that it can be released again
+		if err := server.quarantineStore.Add(vote); err != nil {
+			log.Printf("cannot quarantine rating %s again: %v", quarantineID, err)
+		}
+		return nil, logError(status.Errorf(codes.Internal, "cannot add rating to the store: %v", err))
+	}
+
+	res := &pb.ReleaseQuarantinedRatingResponse{
+		Rating: toRateLaptopResponse(vote.Vote.LaptopID, rating),
+	}
+	return res, nil
+}
+
+// DiscardQuarantinedRating is a unary RPC to drop a quarantined rating without counting it
+func (server *LaptopServer) DiscardQuarantinedRating(
+	ctx context.Context,
+	req *pb.DiscardQuarantinedRatingRequest,
+) (*pb.DiscardQuarantinedRatingResponse, error) {
+	quarantineID := req.GetQuarantineId()
+	log.Printf("receive a discard-quarantined-rating request for %s", quarantineID)
+
+	_, err := server.quarantineStore.Remove(quarantineID)
+	if errors.Is(err, ErrNotFound) {
+		return nil, logError(status.Errorf(codes.NotFound, "rating %s isn't quarantined", quarantineID))
+	}
+	if err != nil {
+		return nil, logError(status.Errorf(codes.Internal, "cannot remove quarantined rating: %v", err))
+	}
+
+	return &pb.DiscardQuarantinedRatingResponse{}, nil
+}
+
+// WatchRatings is a server-streaming RPC to watch the new ratings of some laptops, or of all laptops.
+// It sends the latest rating of each laptop rated since the previous updates, at most once per update interval.
+func (server *LaptopServer) WatchRatings(
+	req *pb.WatchRatingsRequest,
+	stream pb.LaptopService_WatchRatingsServer,
+) error {
+	laptopIDs := req.GetLaptopIds()
+	log.Printf("receive a watch-ratings request for %d laptops", len(laptopIDs))
+
+	if len(laptopIDs) > maxWatchedLaptops {
+		return logError(status.Errorf(
+			codes.InvalidArgument,
+			"cannot watch more than %d laptops, got %d", maxWatchedLaptops, len(laptopIDs),
+		))
+	}
+	for _, laptopID := range laptopIDs {
+		laptop, err := server.laptopStore.Find(laptopID)
+		if err != nil {
+			return logError(status.Errorf(codes.Internal, "cannot find laptop: %v", err))
+		}
+		if laptop == nil {
+			return logError(status.Errorf(codes.NotFound, "laptop %s doesn't exist", laptopID))
+		}
+	}
+
+	subscription := server.ratingBroker.Subscribe(laptopIDs...)
+	defer subscription.Close()
+
+	// the header tells the client that the ratings added from now on are sent
+	err := stream.SendHeader(metadata.MD{})
+	if err != nil {
+		return logError(status.Errorf(codes.Unknown, "cannot send stream header: %v", err))
+	}
+
+	ctx := stream.Context()
+	for {
+		select {
+		case <-ctx.Done():
+			return contextError(ctx)
+		case <-subscription.Ready():
+		}
+
+		for _, update := range subscription.Updates() {
+			// the ratings of the laptops deleted since they were rated aren't visible anymore
+			laptop, err := server.laptopStore.Find(update.LaptopID)
+			if err != nil {
+				return logError(status.Errorf(codes.Internal, "cannot find laptop: %v", err))
+			}
+			if laptop == nil {
+				continue
+			}
+
+			res := &pb.WatchRatingsResponse{
+				LaptopId:     update.LaptopID,
+				RatedCount:   update.Rating.Count,
+				AverageScore: update.Rating.Average(),
+				Statistics:   toRatingStatistics(update.Rating),
+			}
+			err = stream.Send(res)
+			if err != nil {
+				return logError(status.Errorf(codes.Unknown, "cannot send stream response: %v", err))
+			}
+		}
+
+		// the ratings published during the interval are coalesced in the subscription
+		select {
+		case <-ctx.Done():
+			return contextError(ctx)
+		case <-time.After(server.ratingInterval):
+		}
+	}
+}
+
+// GetMyRatings is a unary RPC to get the scores given by the authenticated user
+func (server *LaptopServer) GetMyRatings(
+	ctx context.Context,
+	req *pb.GetMyRatingsRequest,
+) (*pb.GetMyRatingsResponse, error) {
+	claims, ok := ClaimsFromContext(ctx)
+	if !ok {
+		return nil, logError(status.Errorf(codes.Unauthenticated, "ratings are only recorded for authenticated users"))
+	}
+	log.Printf("receive a get-my-ratings request from user %s", claims.Username)
+
+	votes, err := server.ratingStore.UserVotes(claims.Username)
+	if err != nil {
+		return nil, logError(status.Errorf(codes.Internal, "cannot find ratings: %v", err))
+	}
+
+	res := &pb.GetMyRatingsResponse{}
+	for _, vote := range votes {
+		res.Ratings = append(res.Ratings, &pb.MyRating{
+			LaptopId: vote.LaptopID,
+			Score:    vote.Score,
+			RatedAt:  timestamppb.New(vote.Time),
+		})
+	}
+	return res, nil
+}
+
+// SubmitReview is a unary RPC to submit a review of a laptop by the authenticated user.
+// The review is hidden until a moderator approves it.
+func (server *LaptopServer) SubmitReview(
+	ctx context.Context,
+	req *pb.SubmitReviewRequest,
+) (*pb.SubmitReviewResponse, error) {
+	claims, ok := ClaimsFromContext(ctx)
+	if !ok {
+		return nil, logError(status.Errorf(codes.Unauthenticated, "reviews are only accepted from authenticated users"))
+	}
+
+	laptopID := req.GetLaptopId()
+	log.Printf("receive a submit-review request for laptop %s from user %s", laptopID, claims.Username)
+
+	title := strings.TrimSpace(req.GetTitle())
+	if title == "" || utf8.RuneCountInString(title) > maxReviewTitleLength {
+		return nil, logError(status.Errorf(codes.InvalidArgument, "review title must have 1 to %d characters", maxReviewTitleLength))
+	}
+	body := strings.TrimSpace(req.GetBody())
+	if utf8.RuneCountInString(body) > maxReviewBodyLength {
+		return nil, logError(status.Errorf(codes.InvalidArgument, "review body must have at most %d characters", maxReviewBodyLength))
+	}
+	score := req.GetScore()
+	err := server.validateScore(score)
+	if err != nil {
+		return nil, logError(status.Errorf(codes.InvalidArgument, "invalid score: %v", err))
+	}
+
+	laptop, err := server.laptopStore.Find(laptopID)
+	if err != nil {
+		return nil, logError(status.Errorf(codes.Internal, "cannot find laptop: %v", err))
+	}
+	if laptop == nil {
+		return nil, logError(status.Errorf(codes.NotFound, "laptop %s doesn't exist", laptopID))
+	}
+
+	reviewID, err := uuid.NewRandom()
+	if err != nil {
+		return nil, logError(status.Errorf(codes.Internal, "cannot generate review id: %v", err))
+	}
+
+	review := &Review{
+		ID:        reviewID.String(),
+		LaptopID:  laptopID,
+		Username:  claims.Username,
+		Title:     title,
+		Body:      body,
+		Score:     score,
+		Status:    ReviewPending,
+		CreatedAt: time.Now(),
+	}
+
+	err = server.reviewStore.Save(review)
+	if err != nil {
+		return nil, logError(status.Errorf(codes.Internal, "cannot save review to the store: %v", err))
+	}
+
+	log.Printf("saved review %s of laptop %s", review.ID, laptopID)
+	return &pb.SubmitReviewResponse{Review: toReviewMessage(review)}, nil
+}
+
+// ListReviews is a unary RPC to list a page of the approved reviews of a laptop
+func (server *LaptopServer) ListReviews(
+	ctx context.Context,
+	req *pb.ListReviewsRequest,
+) (*pb.ListReviewsResponse, error) {
+	laptopID := req.GetLaptopId()
+	log.Printf("receive a list-reviews request for laptop %s", laptopID)
+
+	var order ReviewOrder
+	switch req.GetOrder() {
+	case pb.ListReviewsRequest_NEWEST:
+		order = ReviewsNewestFirst
+	case pb.ListReviewsRequest_OLDEST:
+		order = ReviewsOldestFirst
+	case pb.ListReviewsRequest_HIGHEST_SCORE:
+		order = ReviewsHighestScoreFirst
+	case pb.ListReviewsRequest_LOWEST_SCORE:
+		order = ReviewsLowestScoreFirst
+	default:
+		return nil, logError(status.Errorf(codes.InvalidArgument, "unknown review order %v", req.GetOrder()))
+	}
+
+	laptop, err := server.laptopStore.Find(laptopID)
+	if err != nil {
+		return nil, logError(status.Errorf(codes.Internal, "cannot find laptop: %v", err))
+	}
+	if laptop == nil {
+		return nil, logError(status.Errorf(codes.NotFound, "laptop %s doesn't exist", laptopID))
+	}
+
+	query := ReviewQuery{
+		LaptopID: laptopID,
+		Status:   ReviewApproved,
+		Order:    order,
+	}
+	reviews, nextPageToken, err := server.listReviews(query, req.GetPageSize(), req.GetPageToken())
+	if err != nil {
+		return nil, logError(err)
+	}
+
+	return &pb.ListReviewsResponse{Reviews: reviews, NextPageToken: nextPageToken}, nil
+}
+
+// ListPendingReviews is a unary RPC to list a page of the reviews of all laptops that wait for moderation, oldest first
+func (server *LaptopServer) ListPendingReviews(
+	ctx context.Context,
+	req *pb.ListPendingReviewsRequest,
+) (*pb.ListPendingReviewsResponse, error) {
+	log.Print("receive a list-pending-reviews request")
+
+	query := ReviewQuery{
+		Status: ReviewPending,
+		Order:  ReviewsOldestFirst,
+	}
+	reviews, nextPageToken, err := server.listReviews(query, req.GetPageSize(), req.GetPageToken())
+	if err != nil {
+		return nil, logError(err)
+	}
+
+	return &pb.ListPendingReviewsResponse{Reviews: reviews, NextPageToken: nextPageToken}, nil
+}
+
+// ApproveReview is a unary RPC to approve a pending review, whose score is then added to the laptop rating.
+// Approving an approved review adds its score again, which replaces the same score,
+// so that a moderator can retry an approval whose score couldn't be added.
+func (server *LaptopServer) ApproveReview(
+	ctx context.Context,
+	req *pb.ApproveReviewRequest,
+) (*pb.ApproveReviewResponse, error) {
+	review, err := server.moderateReview(ctx, req.GetReviewId(), ReviewApproved)
+	if err != nil {
+		return nil, logError(err)
+	}
+
+	// the score is kept apart from the rating of the author, which it doesn't replace,
+	// and is keyed by the review, so that adding it again is a no-op
+	_, err = server.ratingStore.Add(&Vote{
+		LaptopID: review.LaptopID,
+		Username: review.Username,
+		Score:    review.Score,
+		Time:     review.ModeratedAt,
+		ReviewID: review.ID,
+	})
+	if err != nil {
+		return nil, logError(status.Errorf(codes.Internal, "cannot add review score to the rating: %v", err))
+	}
+
+	return &pb.ApproveReviewResponse{Review: toReviewMessage(review)}, nil
+}
+
+// RejectReview is a unary RPC to reject a pending review, which stays hidden
+func (server *LaptopServer) RejectReview(
+	ctx context.Context,
+	req *pb.RejectReviewRequest,
+) (*pb.RejectReviewResponse, error) {
+	review, err := server.moderateReview(ctx, req.GetReviewId(), ReviewRejected)
+	if err != nil {
+		return nil, logError(err)
+	}
+
+	return &pb.RejectReviewResponse{Review: toReviewMessage(review)}, nil
+}
+
+// moderateReview sets the status of a pending review on behalf of the authenticated moderator.
+// It returns the moderated review, or a gRPC status error.
+// A review that already has the status is returned unchanged, so that the moderation can be retried.
+func (server *LaptopServer) moderateReview(ctx context.Context, reviewID string, reviewStatus ReviewStatus) (*Review, error) {
+	// the moderator is anonymous if the server doesn't authenticate its users
+	var moderator string
+	if claims, ok := ClaimsFromContext(ctx); ok {
+		moderator = claims.Username
+	}
+	log.Printf("receive a moderate-review request for review %s from user %s", reviewID, moderator)
+
+	review, err := server.reviewStore.Find(reviewID)
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "cannot find review: %v", err)
+	}
+	if review == nil {
+		return nil, status.Errorf(codes.NotFound, "review %s doesn't exist", reviewID)
+	}
+	if moderator != "" && review.Username == moderator {
+		return nil, status.Errorf(codes.PermissionDenied, "cannot moderate your own review")
+	}
+	if review.Status == reviewStatus {
+		return review, nil
+	}
+
+	review, err = server.reviewStore.Moderate(reviewID, reviewStatus, moderator, time.Now())
+	if errors.Is(err, ErrNotFound) {
+		return nil, status.Errorf(codes.NotFound, "review %s doesn't exist", reviewID)
+	}
+	if errors.Is(err, ErrAlreadyModerated) {
+		return nil, status.Errorf(codes.FailedPrecondition, "cannot moderate review %s: %v", reviewID, err)
+	}
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "cannot moderate review: %v", err)
+	}
+
+	return review, nil
+}
+
+// listReviews returns a page of the reviews selected by the query as messages, with the token of the next page.
+// It returns a gRPC status error if the page token is invalid.
+func (server *LaptopServer) listReviews(query ReviewQuery, pageSize uint32, pageToken string) ([]*pb.Review, string, error) {
+	limit := defaultReviewPageSize
+	if pageSize > 0 {
+		limit = min(int(pageSize), maxReviewPageSize)
+	}
+
+	// the page token is the ID of the last review of the previous page,
+	// and one more review is listed to know whether there is a next page
+	query.After = pageToken
+	query.Limit = limit + 1
+
+	reviews, err := server.reviewStore.List(query)
+	if errors.Is(err, ErrNotFound) {
+		return nil, "", status.Errorf(codes.InvalidArgument, "invalid page token %q", pageToken)
+	}
+	if err != nil {
+		return nil, "", status.Errorf(codes.Internal, "cannot list reviews: %v", err)
+	}
+
+	var nextPageToken string
+	if len(reviews) > limit {
+		reviews = reviews[:limit]
+		nextPageToken = reviews[limit-1].ID
+	}
+
+	messages := make([]*pb.Review, len(reviews))
+	for i, review := range reviews {
+		messages[i] = toReviewMessage(review)
+	}
+	return messages, nextPageToken, nil
+}
+
+// validateScore returns an error if a score is out of the score range, or has too many decimals
+func (server *LaptopServer) validateScore(score float64) error {
+	// NaN isn't in any range, and would poison the sums of the rating
+	if math.IsNaN(score) || score < server.minScore || score > server.maxScore {
+		return fmt.Errorf("score %v is not between %v and %v", score, server.minScore, server.maxScore)
+	}
+
+	scaled := score * math.Pow10(server.scoreDecimals)
+	if math.Abs(scaled-math.Round(scaled)) > scoreDecimalsTolerance {
+		return fmt.Errorf("score %v has more than %d decimals", score, server.scoreDecimals)
+	}
+	return nil
+}
+
+// checkRatedLaptop returns a NotFound error if the rated laptop doesn't exist
+func (server *LaptopServer) checkRatedLaptop(laptopID string) error {
+	laptop, err := server.laptopStore.Find(laptopID)
+	if err != nil {
+		return status.Errorf(codes.Internal, "cannot find laptop: %v", err)
+	}
+	if laptop == nil {
+		return status.Errorf(codes.NotFound, "laptop %s doesn't exist", laptopID)
+	}
+	return nil
+}
+
+// saveImage validates the beginning of the image data, then streams it to the image store and saves its renditions.
+// It returns the ID of the saved image, or a gRPC status error.
+func (server *LaptopServer) saveImage(
+	laptopID string,
+	imageType string,
+	uploader string,
+	imageData io.Reader,
+) (string, error) {
+	reader := bufio.NewReaderSize(imageData, imageHeaderSize)
+	header, err := reader.Peek(imageHeaderSize)
+	if err != nil && err != io.EOF {
+		return "", status.Errorf(codes.Unknown, "cannot read image data: %v", err)
+	}
+
+	width, height, err := server.imageValidator.Validate(imageType, header)
+	if err != nil {
+		return "", status.Errorf(codes.InvalidArgument, "cannot accept image: %v", err)
+	}
+
+	info := &ImageInfo{
+		LaptopID: laptopID,
+		Type:     imageType,
+		Uploader: uploader,
+		Width:    width,
+		Height:   height,
+	}
+
+	imageID, err := server.imageStore.Save(info, reader)
+	if errors.Is(err, ErrQuotaExceeded) {
+		return "", status.Errorf(codes.ResourceExhausted, "cannot save image to the store: %v", err)
+	}
+	if err != nil {
+		return "", status.Errorf(codes.Internal, "cannot save image to the store: %v", err)
+	}
+
+	// the gallery may have been filled by another upload since the limit was checked
+	err = server.galleryStore.Add(laptopID, imageID, server.maxImagesPerLaptop)
+	if err != nil {
+		deleteErr := server.imageStore.Delete(imageID)
+		if deleteErr != nil {
+			log.Printf("cannot delete image %s: %v", imageID, deleteErr)
+		}
+
+		if errors.Is(err, ErrGalleryFull) {
+			return "", status.Errorf(codes.ResourceExhausted, "cannot add image to the gallery: %v", err)
+		}
+		return "", status.Errorf(codes.Internal, "cannot add image to the gallery: %v", err)
+	}
+
+	// the renditions are generated off the request path, and a missing rendition only means that
+	// the original image is served instead of it
+	server.queueRenditions(imageID)
+
+	return imageID, nil
+}
+
+// checkGalleryLimit returns a ResourceExhausted error if a laptop gallery can't have any more images
+func (server *LaptopServer) checkGalleryLimit(laptopID string) error {
+	gallery, err := server.galleryStore.Images(laptopID)
+	if err != nil {
+		return status.Errorf(codes.Internal, "cannot find gallery: %v", err)
+	}
+
+	if len(gallery) >= server.maxImagesPerLaptop {
+		return status.Errorf(codes.ResourceExhausted, "laptop %s already has %d images", laptopID, len(gallery))
+	}
+	return nil
+}
+
+// setGalleryFields sets the primary image and the image count of a laptop from its gallery
+func (server *LaptopServer) setGalleryFields(laptop *pb.Laptop) error {
+	gallery, err := server.galleryStore.Images(laptop.GetId())
+	if err != nil {
+		return status.Errorf(codes.Internal, "cannot find gallery: %v", err)
+	}
+
+	laptop.PrimaryImageId = ""
+	if len(gallery) > 0 {
+		laptop.PrimaryImageId = gallery[0]
+	}
+	laptop.ImageCount = uint32(len(gallery))
+	return nil
+}
+
+// galleryError converts an error of the gallery store to a gRPC status error
+func galleryError(err error, laptopID string) error {
+	switch {
+	case errors.Is(err, ErrNotFound):
+		return status.Errorf(codes.NotFound, "image is not in the gallery of laptop %s", laptopID)
+	case errors.Is(err, ErrInvalidOrder):
+		return status.Errorf(codes.InvalidArgument, "cannot reorder images: %v", err)
+	default:
+		return status.Errorf(codes.Internal, "cannot change gallery: %v", err)
+	}
+}
+
+// acquireUpload reserves one of the concurrent uploads, and returns a function to release it
+func (server *LaptopServer) acquireUpload() (func(), error) {
+	select {
+	case server.uploads <- struct{}{}:
+		return func() { <-server.uploads }, nil
+	default:
+		return nil, status.Errorf(codes.ResourceExhausted, "too many concurrent uploads, try again later")
+	}
+}
+
+// findUploadSession returns an upload session if it belongs to the user of the context
+func (server *LaptopServer) findUploadSession(ctx context.Context, uploadID string) (*UploadSession, error) {
+	session, err := server.uploadSessionStore.Find(uploadID)
+	if err != nil {
+		return nil, uploadSessionError(err)
+	}
+
+	if claims, ok := ClaimsFromContext(ctx); ok && claims.Username != session.Info.Uploader {
+		return nil, status.Errorf(codes.PermissionDenied, "upload %s belongs to another user", uploadID)
+	}
+	return session, nil
+}
+
+// uploadSessionError converts an error of the upload session store to a gRPC status error
+func uploadSessionError(err error) error {
+	switch {
+	case errors.Is(err, ErrNotFound):
+		return status.Errorf(codes.NotFound, "upload doesn't exist or has expired")
+	case errors.Is(err, ErrInvalidOffset), errors.Is(err, ErrSizeMismatch):
+		return status.Errorf(codes.FailedPrecondition, "cannot upload image: %v", err)
+	case errors.Is(err, ErrChecksumMismatch):
+		return status.Errorf(codes.DataLoss, "cannot upload image: %v", err)
+	default:
+		return status.Errorf(codes.Internal, "cannot upload image: %v", err)
+	}
+}
+
+// queueRenditions queues an image for its renditions, unless the queue is full or closed
+func (server *LaptopServer) queueRenditions(imageID string) {
+	server.renditionMutex.RLock()
+	defer server.renditionMutex.RUnlock()
+
+	if server.closed {
+		log.Printf("cannot save renditions of image %s: server is closed", imageID)
+		return
+	}
+
+	select {
+	case server.renditionQueue <- imageID:
+	default:
+		log.Printf("cannot save renditions of image %s: too many images are waiting for renditions", imageID)
+	}
+}
+
+// generateRenditions saves the renditions of the images of the rendition queue, one at a time
+func (server *LaptopServer) generateRenditions() {
+	defer server.renditionsDone.Done()
+
+	for imageID := range server.renditionQueue {
+		err := server.saveRenditions(imageID)
+		if err != nil {
+			log.Printf("cannot save renditions of image %s: %v", imageID, err)
+		}
+	}
+}
+
+// saveRenditions generates the renditions of a saved image and saves them to the image store
+func (server *LaptopServer) saveRenditions(imageID string) error {
+	info, imageData, err := server.imageStore.Open(imageID)
+	if err != nil {
+		return err
+	}
+	defer imageData.Close()
+
+	renditions, err := server.renditionGenerator.Generate(info.Type, imageData)
+	if err != nil {
+		return err
+	}
+
+	for _, rendition := range renditions {
+		renditionInfo := &ImageInfo{
+			LaptopID:   info.LaptopID,
+			Type:       rendition.Type,
+			Uploader:   info.Uploader,
+			Width:      rendition.Width,
+			Height:     rendition.Height,
+			OriginalID: info.ID,
+			Rendition:  rendition.Size,
+		}
+
+		renditionID, err := server.imageStore.Save(renditionInfo, &rendition.Data)
+		if err != nil {
+			return err
+		}
+
+		log.Printf("saved rendition %d of image %s with id: %s", rendition.Size, imageID, renditionID)
+	}
+	return nil
+}
+
+// renditions returns the info of the renditions of an image
+func (server *LaptopServer) renditions(info *ImageInfo) ([]*ImageInfo, error) {
+	images, err := server.imageStore.List(info.LaptopID)
+	if err != nil {
+		return nil, err
+	}
+
+	var renditions []*ImageInfo
+	for _, other := range images {
+		if other.OriginalID == info.ID {
+			renditions = append(renditions, other)
+		}
+	}
+	return renditions, nil
+}
+
+// uploadStreamReader reads the image data from the chunks of an UploadImage stream.
+// It stops with an error when the image is larger than maxSize,
+// or when the size or checksum of the whole image isn't the expected one.
+type uploadStreamReader struct {
+	stream           pb.LaptopService_UploadImageServer
+	maxSize          int64
+	expectedSize     int64
+	expectedChecksum string
+	hash             hash.Hash
+	chunk            []byte
+	size             int64
+	// err is the gRPC status error that stopped the reader
+	err error
+}
+
+func (reader *uploadStreamReader) Read(p []byte) (int, error) {
+	for len(reader.chunk) == 0 {
+		if reader.err != nil {
+			return 0, reader.err
+		}
+
+		if err := contextError(reader.stream.Context()); err != nil {
+			reader.err = err
+			return 0, err
+		}
+
+		req, err := reader.stream.Recv()
+		if err == io.EOF {
+			log.Print("no more data")
+			reader.err = reader.verify()
+			if reader.err != nil {
+				return 0, reader.err
+			}
+			return 0, io.EOF
+		}
+		if err != nil {
+			reader.err = status.Errorf(codes.Unknown, "cannot receive chunk data: %v", err)
+			return 0, reader.err
+		}
+
+		reader.chunk = req.GetChunkData()
+		reader.size += int64(len(reader.chunk))
+		log.Printf("received a chunk with size: %d", len(reader.chunk))
+
+		if reader.size > reader.maxSize {
+			reader.err = status.Errorf(codes.InvalidArgument, "image is too large: %d > %d", reader.size, reader.maxSize)
+			return 0, reader.err
+		}
+		if reader.expectedSize > 0 && reader.size > reader.expectedSize {
+			reader.err = status.Errorf(codes.DataLoss, "received %d bytes, expected %d", reader.size, reader.expectedSize)
+			return 0, reader.err
+		}
+	}
+
+	n := copy(p, reader.chunk)
+	reader.hash.Write(p[:n])
+	reader.chunk = reader.chunk[n:]
+	return n, nil
+}
+
+// verify checks the size and checksum of the whole image against the expected ones
+func (reader *uploadStreamReader) verify() error {
+	if reader.expectedSize > 0 && reader.size != reader.expectedSize {
+		return status.Errorf(codes.DataLoss, "received %d bytes, expected %d", reader.size, reader.expectedSize)
+	}
+
+	checksum := reader.checksum()
+	if reader.expectedChecksum != "" && !strings.EqualFold(checksum, reader.expectedChecksum) {
+		return status.Errorf(codes.DataLoss, "received image has checksum %s, expected %s", checksum, reader.expectedChecksum)
+	}
+	return nil
+}
+
+// checksum returns the hex-encoded SHA-256 checksum of the image data read so far
+func (reader *uploadStreamReader) checksum() string {
+	return hex.EncodeToString(reader.hash.Sum(nil))
+}
+
+func toImageMetadata(info *ImageInfo) *pb.ImageMetadata {
+	return &pb.ImageMetadata{
+		Id:         info.ID,
+		LaptopId:   info.LaptopID,
+		ImageType:  info.Type,
+		Size:       uint64(info.Size),
+		Checksum:   info.Checksum,
+		UploadedAt: timestamppb.New(info.UploadedAt),
+		Uploader:   info.Uploader,
+		Width:      uint32(info.Width),
+		Height:     uint32(info.Height),
+	}
+}
+
+// windowStart returns the start of a rating window of the last days
+func windowStart(days uint32) time.Time {
+	return time.Now().AddDate(0, 0, -int(days))
+}
+
+// toRateLaptopResponse converts the rating of a laptop, possibly nil if it has never been rated, to a response
+func toRateLaptopResponse(laptopID string, rating *Rating) *pb.RateLaptopResponse {
+	if rating == nil {
+		return &pb.RateLaptopResponse{LaptopId: laptopID}
+	}
+	return &pb.RateLaptopResponse{
+		LaptopId:     laptopID,
+		RatedCount:   rating.Count,
+		AverageScore: rating.Average(),
+		Statistics:   toRatingStatistics(rating),
+	}
+}
+
+func toRatingStatistics(rating *Rating) *pb.RatingStatistics {
+	return &pb.RatingStatistics{
+		Histogram:         rating.Histogram,
+		MedianScore:       rating.Median,
+		StandardDeviation: rating.StandardDeviation(),
+		BayesianAverage:   rating.BayesianAverage,
+		DecayedAverage:    rating.DecayedAverage,
+		HistogramMinScore: int32(rating.HistogramMin),
+	}
+}
+
+func toQuarantinedRatingMessage(vote *QuarantinedVote) *pb.QuarantinedRating {
+	return &pb.QuarantinedRating{
+		Id:       vote.ID,
+		LaptopId: vote.Vote.LaptopID,
+		Username: vote.Vote.Username,
+		Score:    vote.Vote.Score,
+		RatedAt:  timestamppb.New(vote.Vote.Time),
+		Reason:   vote.Reason,
+	}
+}
+
+// toReviewMessage converts a review to a review message.
+// The review statuses have the same values as the message statuses.
+func toReviewMessage(review *Review) *pb.Review {
+	message := &pb.Review{
+		Id:        review.ID,
+		LaptopId:  review.LaptopID,
+		Username:  review.Username,
+		Title:     review.Title,
+		Body:      review.Body,
+		Score:     review.Score,
+		Status:    pb.Review_Status(review.Status),
+		CreatedAt: timestamppb.New(review.CreatedAt),
+		Moderator: review.Moderator,
+	}
+	if !review.ModeratedAt.IsZero() {
+		message.ModeratedAt = timestamppb.New(review.ModeratedAt)
+	}
+	return message
 }
 
 func contextError(ctx context.Context) error {
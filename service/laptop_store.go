@@ -3,81 +3,187 @@ package service
 import (
 	"context"
 	"errors"
-	"fmt"
 	"log"
+	"sort"
 	"sync"
 
-	"github.com/jinzhu/copier"
 	"gitlab.com/brucemig/pcbook/pb"
+	"google.golang.org/protobuf/proto"
 )
 
 // ErrAlreadyExists is returned when a record with the same ID already exists in the store
 var ErrAlreadyExists = errors.New("record already exists")
 
+// ErrNotFound is returned when a record with the given ID doesn't exist in the store
+var ErrNotFound = errors.New("record not found")
+
 // LaptopStore is an interface to store laptop
 type LaptopStore interface {
 	//  Save saves the laptop to the store
 	Save(laptop *pb.Laptop) error
+	// Update replaces an existing laptop in the store
+	Update(laptop *pb.Laptop) error
+	// Delete removes a laptop from the store
+	Delete(id string) error
 	// Find  finds a laptop by ID
 	Find(id string) (*pb.Laptop, error)
 	// Search searches for laptops with filter, returns one by one via the found function
 	Search(ctx context.Context, filter *pb.Filter, found func(laptop *pb.Laptop) error) error
 }
 
-// InMemoryLaptopStore stores laptop in memory
+// InMemoryLaptopStore stores laptop in memory.
+// The laptops are spread over a number of shards by ID, each with its own lock,
+// so that writes to different laptops don't wait for each other.
 type InMemoryLaptopStore struct {
+	shards []*laptopShard
+}
+
+type laptopShard struct {
 	mutex sync.RWMutex
 	data  map[string]*pb.Laptop
 }
 
 // NewInMemoryLaptopStore retlurns a new InMemoryLaptopStore
 func NewInMemoryLaptopStore() *InMemoryLaptopStore {
+	return NewShardedInMemoryLaptopStore(defaultShardCount)
+}
+
+// NewShardedInMemoryLaptopStore returns a new InMemoryLaptopStore with the given number of shards
+func NewShardedInMemoryLaptopStore(shardCount int) *InMemoryLaptopStore {
+	shards := make([]*laptopShard, max(shardCount, 1))
+	for i := range shards {
+		shards[i] = &laptopShard{
+			data: make(map[string]*pb.Laptop),
+		}
+	}
+
 	return &InMemoryLaptopStore{
-		data: make(map[string]*pb.Laptop),
+		shards: shards,
 	}
 }
 
+func (store *InMemoryLaptopStore) shard(id string) *laptopShard {
+	return store.shards[shardIndex(id, len(store.shards))]
+}
+
 // Save saves the laptop to the store
 func (store *InMemoryLaptopStore) Save(laptop *pb.Laptop) error {
-	store.mutex.Lock()
-	defer store.mutex.Unlock()
+	shard := store.shard(laptop.Id)
+	shard.mutex.Lock()
+	defer shard.mutex.Unlock()
 
-	if store.data[laptop.Id] != nil {
+	if shard.data[laptop.Id] != nil {
 		return ErrAlreadyExists
 	}
 
 	// deep copy
-	other, err := deepCopy(laptop)
-	if err != nil {
-		return err
+	other := deepCopy(laptop)
+	shard.data[other.Id] = other
+	return nil
+}
+
+// Update replaces an existing laptop in the store
+func (store *InMemoryLaptopStore) Update(laptop *pb.Laptop) error {
+	shard := store.shard(laptop.Id)
+	shard.mutex.Lock()
+	defer shard.mutex.Unlock()
+
+	if shard.data[laptop.Id] == nil {
+		return ErrNotFound
+	}
+
+	other := deepCopy(laptop)
+	shard.data[other.Id] = other
+	return nil
+}
+
+// Delete removes a laptop from the store
+func (store *InMemoryLaptopStore) Delete(id string) error {
+	shard := store.shard(id)
+	shard.mutex.Lock()
+	defer shard.mutex.Unlock()
+
+	if shard.data[id] == nil {
+		return ErrNotFound
 	}
 
-	store.data[other.Id] = other
+	delete(shard.data, id)
 	return nil
 }
 
 // Find  finds a laptop by ID
 func (store *InMemoryLaptopStore) Find(id string) (*pb.Laptop, error) {
-	store.mutex.RLock()
-	defer store.mutex.RUnlock()
+	shard := store.shard(id)
+	shard.mutex.RLock()
+	defer shard.mutex.RUnlock()
 
-	laptop := store.data[id]
+	laptop := shard.data[id]
 	if laptop == nil {
 		return nil, nil
 	}
-	return deepCopy(laptop)
+	return deepCopy(laptop), nil
 }
 
-// Search searches for laptops with filter, returns one by one via the found function
+// Search searches for laptops with filter, returns one by one via the found function.
+// The shards are searched in parallel, but found is never called concurrently.
 func (store *InMemoryLaptopStore) Search(
 	ctx context.Context,
 	filter *pb.Filter,
 	found func(laptop *pb.Laptop) error,
 ) error {
-	store.mutex.RLock()
-	defer store.mutex.RUnlock()
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	// serialize the calls to found, and stop calling it after it fails
+	var foundMutex sync.Mutex
+	var foundErr error
+	foundOnce := func(laptop *pb.Laptop) error {
+		foundMutex.Lock()
+		defer foundMutex.Unlock()
+
+		if foundErr != nil {
+			return errSearchStopped
+		}
+
+		foundErr = found(laptop)
+		return foundErr
+	}
+
+	errs := make(chan error, len(store.shards))
+	for _, shard := range store.shards {
+		go func(shard *laptopShard) {
+			errs <- shard.search(ctx, filter, foundOnce)
+		}(shard)
+	}
+
+	var firstErr error
+	for range store.shards {
+		err := <-errs
+		if err != nil && firstErr == nil {
+			// the other shards can stop searching
+			firstErr = err
+			cancel()
+		}
+	}
+
+	if foundErr != nil {
+		return foundErr
+	}
+	return firstErr
+}
+
+// errSearchStopped is returned by the shards that are still searching after another one failed
+var errSearchStopped = errors.New("search is stopped")
+
+func (shard *laptopShard) search(
+	ctx context.Context,
+	filter *pb.Filter,
+	found func(laptop *pb.Laptop) error,
+) error {
+	shard.mutex.RLock()
+	defer shard.mutex.RUnlock()
 
-	for _, laptop := range store.data {
+	for _, laptop := range shard.data {
 		// heavy processing
 		// time.Sleep(time.Second)
 		// log.Print("checking laptop id: ", laptop.GetId())
@@ -88,12 +194,7 @@ func (store *InMemoryLaptopStore) Search(
 		}
 
 		if isQualified(filter, laptop) {
-			other, err := deepCopy(laptop)
-			if err != nil {
-				return err
-			}
-
-			err = found(other)
+			err := found(deepCopy(laptop))
 			if err != nil {
 				return err
 			}
@@ -103,6 +204,23 @@ func (store *InMemoryLaptopStore) Search(
 	return nil
 }
 
+// all returns a copy of every laptop in the store, sorted by ID
+func (store *InMemoryLaptopStore) all() ([]*pb.Laptop, error) {
+	var laptops []*pb.Laptop
+	for _, shard := range store.shards {
+		shard.mutex.RLock()
+		for _, laptop := range shard.data {
+			laptops = append(laptops, deepCopy(laptop))
+		}
+		shard.mutex.RUnlock()
+	}
+
+	sort.Slice(laptops, func(i, j int) bool {
+		return laptops[i].GetId() < laptops[j].GetId()
+	})
+	return laptops, nil
+}
+
 func isQualified(filter *pb.Filter, laptop *pb.Laptop) bool {
 	if laptop.GetPriceUsd() > filter.GetMaxPriceUsd() {
 		return false
@@ -144,12 +262,7 @@ func toBit(memory *pb.Memory) uint64 {
 	}
 }
 
-func deepCopy(laptop *pb.Laptop) (*pb.Laptop, error) {
-	other := &pb.Laptop{}
-	err := copier.Copy(other, laptop)
-	if err != nil {
-		return nil, fmt.Errorf("cannot copy laptop data: %w", err)
-	}
-
-	return other, nil
+// deepCopy returns a deep copy of the laptop, including its oneof fields
+func deepCopy(laptop *pb.Laptop) *pb.Laptop {
+	return proto.Clone(laptop).(*pb.Laptop)
 }
@@ -0,0 +1,122 @@
+package service_test
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"gitlab.com/brucemig/pcbook/pb"
+	"gitlab.com/brucemig/pcbook/sample"
+	"gitlab.com/brucemig/pcbook/service"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+func TestInMemoryLaptopStoreRoundTrip(t *testing.T) {
+	t.Parallel()
+
+	laptopKg := newFullLaptop()
+	laptopKg.Weight = &pb.Laptop_WeightKg{WeightKg: 1.8}
+
+	laptopLbs := newFullLaptop()
+	laptopLbs.Weight = &pb.Laptop_WeightLbs{WeightLbs: 4.2}
+
+	store := service.NewInMemoryLaptopStore()
+
+	for _, laptop := range []*pb.Laptop{laptopKg, laptopLbs} {
+		expected := proto.Clone(laptop).(*pb.Laptop)
+		require.NoError(t, store.Save(laptop))
+
+		found, err := store.Find(laptop.GetId())
+		require.NoError(t, err)
+		require.True(t, proto.Equal(expected, found))
+		require.IsType(t, expected.GetWeight(), found.GetWeight())
+
+		// the oneof wrapper must not be shared with the stored laptop
+		switch weight := found.GetWeight().(type) {
+		case *pb.Laptop_WeightKg:
+			weight.WeightKg = 100
+		case *pb.Laptop_WeightLbs:
+			weight.WeightLbs = 100
+		}
+
+		found, err = store.Find(laptop.GetId())
+		require.NoError(t, err)
+		require.True(t, proto.Equal(expected, found))
+	}
+}
+
+// newFullLaptop returns a sample laptop with every field set to a non-default value
+func newFullLaptop() *pb.Laptop {
+	laptop := sample.NewLaptop()
+	laptop.Cpu = &pb.CPU{
+		Brand:         "Intel",
+		Name:          "Core i7-9750H",
+		NumberCores:   6,
+		NumberThreads: 12,
+		MinGhz:        2.6,
+		MaxGhz:        4.5,
+	}
+	laptop.Gpu = []*pb.GPU{sample.NewGPU(), sample.NewGPU()}
+	laptop.Screen.Panel = pb.Screen_OLED
+	laptop.Screen.Multitouch = true
+	laptop.Keyboard.Layout = pb.Keyboard_AZERTY
+	laptop.Keyboard.Backlit = true
+	laptop.ReleaseYear = 2019
+	laptop.UpdatedAt = &timestamppb.Timestamp{Seconds: 1700000000, Nanos: 42}
+	return laptop
+}
+
+func BenchmarkInMemoryLaptopStore(b *testing.B) {
+	for _, size := range []int{10_000, 100_000} {
+		store := service.NewInMemoryLaptopStore()
+		ids := make([]string, size)
+		for i := range ids {
+			laptop := sample.NewLaptop()
+			ids[i] = laptop.GetId()
+			require.NoError(b, store.Save(laptop))
+		}
+
+		b.Run(fmt.Sprintf("save_%d", size), func(b *testing.B) {
+			laptops := make([]*pb.Laptop, b.N)
+			for i := range laptops {
+				laptops[i] = sample.NewLaptop()
+			}
+
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				err := store.Save(laptops[i])
+				if err != nil {
+					b.Fatal(err)
+				}
+			}
+			b.StopTimer()
+
+			for i := range laptops {
+				require.NoError(b, store.Delete(laptops[i].GetId()))
+			}
+		})
+
+		b.Run(fmt.Sprintf("find_%d", size), func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				_, err := store.Find(ids[i%size])
+				if err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+
+		b.Run(fmt.Sprintf("search_%d", size), func(b *testing.B) {
+			filter := &pb.Filter{MaxPriceUsd: 2500}
+			for i := 0; i < b.N; i++ {
+				err := store.Search(context.Background(), filter, func(laptop *pb.Laptop) error {
+					return nil
+				})
+				if err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,128 @@
+package service
+
+import (
+	"sort"
+	"sync"
+)
+
+// QuarantineStore is an interface to store the votes flagged as suspicious,
+// which aren't counted until an admin releases them
+type QuarantineStore interface {
+	// Add quarantines a vote.
+	// It returns ErrAlreadyExists if a vote with the same ID is already quarantined.
+	Add(vote *QuarantinedVote) error
+	// AddAll quarantines a batch of votes atomically.
+	// It returns ErrAlreadyExists, and quarantines none of them, if a vote with the same ID as one of them is already quarantined.
+	AddAll(votes []*QuarantinedVote) error
+	// Find finds a quarantined vote by ID, or returns nil if it isn't quarantined
+	Find(quarantineID string) (*QuarantinedVote, error)
+	// List returns the quarantined votes of a laptop, or of all laptops if the laptop ID is empty,
+	// oldest first, then by ID
+	List(laptopID string) ([]*QuarantinedVote, error)
+	// Remove removes a vote from the quarantine and returns it.
+	// It returns ErrNotFound if the vote isn't quarantined.
+	Remove(quarantineID string) (*QuarantinedVote, error)
+}
+
+// QuarantinedVote is a vote flagged as suspicious by an abuse detector
+type QuarantinedVote struct {
+	ID   string
+	Vote Vote
+	// Reason tells why the vote was flagged
+	Reason string
+}
+
+// InMemoryQuarantineStore stores quarantined votes in memory
+type InMemoryQuarantineStore struct {
+	mutex sync.RWMutex
+	votes map[string]*QuarantinedVote
+}
+
+// NewInMemoryQuarantineStore returns a new InMemoryQuarantineStore
+func NewInMemoryQuarantineStore() *InMemoryQuarantineStore {
+	return &InMemoryQuarantineStore{
+		votes: make(map[string]*QuarantinedVote),
+	}
+}
+
+// Add quarantines a vote
+func (store *InMemoryQuarantineStore) Add(vote *QuarantinedVote) error {
+	store.mutex.Lock()
+	defer store.mutex.Unlock()
+
+	if store.votes[vote.ID] != nil {
+		return ErrAlreadyExists
+	}
+
+	other := *vote
+	store.votes[vote.ID] = &other
+	return nil
+}
+
+// AddAll quarantines a batch of votes atomically
+func (store *InMemoryQuarantineStore) AddAll(votes []*QuarantinedVote) error {
+	store.mutex.Lock()
+	defer store.mutex.Unlock()
+
+	for _, vote := range votes {
+		if store.votes[vote.ID] != nil {
+			return ErrAlreadyExists
+		}
+	}
+
+	for _, vote := range votes {
+		other := *vote
+		store.votes[vote.ID] = &other
+	}
+	return nil
+}
+
+// Find finds a quarantined vote by ID
+func (store *InMemoryQuarantineStore) Find(quarantineID string) (*QuarantinedVote, error) {
+	store.mutex.RLock()
+	defer store.mutex.RUnlock()
+
+	vote := store.votes[quarantineID]
+	if vote == nil {
+		return nil, nil
+	}
+
+	other := *vote
+	return &other, nil
+}
+
+// List returns the quarantined votes of a laptop, or of all laptops
+func (store *InMemoryQuarantineStore) List(laptopID string) ([]*QuarantinedVote, error) {
+	store.mutex.RLock()
+	defer store.mutex.RUnlock()
+
+	var votes []*QuarantinedVote
+	for _, vote := range store.votes {
+		if laptopID == "" || vote.Vote.LaptopID == laptopID {
+			other := *vote
+			votes = append(votes, &other)
+		}
+	}
+
+	sort.Slice(votes, func(i, j int) bool {
+		if !votes[i].Vote.Time.Equal(votes[j].Vote.Time) {
+			return votes[i].Vote.Time.Before(votes[j].Vote.Time)
+		}
+		return votes[i].ID < votes[j].ID
+	})
+	return votes, nil
+}
+
+// Remove removes a vote from the quarantine
+func (store *InMemoryQuarantineStore) Remove(quarantineID string) (*QuarantinedVote, error) {
+	store.mutex.Lock()
+	defer store.mutex.Unlock()
+
+	vote := store.votes[quarantineID]
+	if vote == nil {
+		return nil, ErrNotFound
+	}
+
+	delete(store.votes, quarantineID)
+	return vote, nil
+}
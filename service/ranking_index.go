@@ -0,0 +1,100 @@
+package service
+
+import "math/rand/v2"
+
+// maximum number of levels of the ranking index, enough for 2^32 laptops
+const rankingMaxLevel = 32
+
+// rankingIndex is a skiplist of laptop ratings in TopRated order,
+// so that a rating is moved in O(log n) whatever the number of laptops.
+// The zero value is an empty index. It is not safe for concurrent use.
+type rankingIndex struct {
+	head  rankingNode
+	level int
+}
+
+// rankingNode is an entry of the ranking index, with its successors on each of its levels
+type rankingNode struct {
+	ranked *rankedRating
+	next   []*rankingNode
+}
+
+// insert adds a laptop rating to the index
+func (index *rankingIndex) insert(ranked *rankedRating) {
+	var previous [rankingMaxLevel]*rankingNode
+	index.search(ranked, &previous)
+
+	// each node is on the next level with a probability of 1/2
+	level := 1
+	for level < rankingMaxLevel && rand.IntN(2) == 0 {
+		level++
+	}
+	if level > index.level {
+		for i := index.level; i < level; i++ {
+			previous[i] = &index.head
+		}
+		index.level = level
+	}
+
+	node := &rankingNode{ranked: ranked, next: make([]*rankingNode, level)}
+	for i := 0; i < level; i++ {
+		node.next[i] = previous[i].next[i]
+		previous[i].next[i] = node
+	}
+}
+
+// remove removes a laptop rating from the index, if it is there
+func (index *rankingIndex) remove(ranked *rankedRating) {
+	var previous [rankingMaxLevel]*rankingNode
+	node := index.search(ranked, &previous)
+	if node == nil || node.ranked.laptopID != ranked.laptopID {
+		return
+	}
+
+	for i := 0; i < len(node.next); i++ {
+		previous[i].next[i] = node.next[i]
+	}
+	for index.level > 0 && index.head.next[index.level-1] == nil {
+		index.level--
+	}
+}
+
+// each calls found with the laptop ratings of the index in order, starting after the given rating if not nil,
+// until it returns false. The given rating doesn't need to be in the index.
+func (index *rankingIndex) each(after *rankedRating, found func(ranked *rankedRating) bool) {
+	if index.head.next == nil {
+		return
+	}
+
+	node := index.head.next[0]
+	if after != nil {
+		var previous [rankingMaxLevel]*rankingNode
+		node = index.search(after, &previous)
+		if node != nil && !rankedBefore(after, node.ranked) {
+			node = node.next[0]
+		}
+	}
+
+	for ; node != nil; node = node.next[0] {
+		if !found(node.ranked) {
+			return
+		}
+	}
+}
+
+// search fills previous with the last node before the given rating on each level,
+// and returns the first node at or after it, or nil if there is none
+func (index *rankingIndex) search(ranked *rankedRating, previous *[rankingMaxLevel]*rankingNode) *rankingNode {
+	if index.head.next == nil {
+		index.head.next = make([]*rankingNode, rankingMaxLevel)
+	}
+
+	node := &index.head
+	for i := index.level - 1; i >= 0; i-- {
+		for node.next[i] != nil && rankedBefore(node.next[i].ranked, ranked) {
+			node = node.next[i]
+		}
+		previous[i] = node
+	}
+	return node.next[0]
+}
@@ -0,0 +1,118 @@
+package service
+
+import (
+	"sync"
+)
+
+// RatingBroker publishes the new ratings of the laptops to their subscribers.
+// Its Publish method is a rating store observer, see WithRatingObserver.
+type RatingBroker struct {
+	mutex         sync.RWMutex
+	subscriptions map[*RatingSubscription]bool
+}
+
+// RatingSubscription receives the new ratings of some laptops, or of all laptops.
+// The ratings of a laptop published before they are taken are coalesced into the latest one,
+// so that a slow subscriber doesn't block the broker, nor fall behind on a laptop rated many times.
+type RatingSubscription struct {
+	broker *RatingBroker
+	// laptopIDs are the IDs of the subscribed laptops, or nil for all laptops
+	laptopIDs map[string]bool
+
+	mutex sync.Mutex
+	// pending are the latest ratings that aren't taken yet, by laptop ID, and order their laptop IDs by first publication
+	pending map[string]*Rating
+	order   []string
+	ready   chan struct{}
+}
+
+// RatingUpdate is the new rating of a laptop
+type RatingUpdate struct {
+	LaptopID string
+	Rating   *Rating
+}
+
+// NewRatingBroker returns a new RatingBroker
+func NewRatingBroker() *RatingBroker {
+	return &RatingBroker{
+		subscriptions: make(map[*RatingSubscription]bool),
+	}
+}
+
+// Subscribe returns a new subscription to the ratings of the given laptops, or of all laptops if none is given.
+// The subscription must be closed when it is no longer used.
+func (broker *RatingBroker) Subscribe(laptopIDs ...string) *RatingSubscription {
+	subscription := &RatingSubscription{
+		broker:  broker,
+		pending: make(map[string]*Rating),
+		ready:   make(chan struct{}, 1),
+	}
+	if len(laptopIDs) > 0 {
+		subscription.laptopIDs = make(map[string]bool, len(laptopIDs))
+		for _, laptopID := range laptopIDs {
+			subscription.laptopIDs[laptopID] = true
+		}
+	}
+
+	broker.mutex.Lock()
+	defer broker.mutex.Unlock()
+
+	broker.subscriptions[subscription] = true
+	return subscription
+}
+
+// Publish publishes the new rating of a laptop to its subscribers, without waiting for them
+func (broker *RatingBroker) Publish(laptopID string, rating *Rating) {
+	broker.mutex.RLock()
+	defer broker.mutex.RUnlock()
+
+	for subscription := range broker.subscriptions {
+		if subscription.laptopIDs == nil || subscription.laptopIDs[laptopID] {
+			subscription.push(laptopID, rating)
+		}
+	}
+}
+
+func (subscription *RatingSubscription) push(laptopID string, rating *Rating) {
+	subscription.mutex.Lock()
+	defer subscription.mutex.Unlock()
+
+	if subscription.pending[laptopID] == nil {
+		subscription.order = append(subscription.order, laptopID)
+	}
+	subscription.pending[laptopID] = rating
+
+	select {
+	case subscription.ready <- struct{}{}:
+	default:
+	}
+}
+
+// Ready returns a channel that receives a value when there are updates to take
+func (subscription *RatingSubscription) Ready() <-chan struct{} {
+	return subscription.ready
+}
+
+// Updates takes the pending updates, with the latest rating of each laptop, by first publication since the last call
+func (subscription *RatingSubscription) Updates() []*RatingUpdate {
+	subscription.mutex.Lock()
+	defer subscription.mutex.Unlock()
+
+	updates := make([]*RatingUpdate, len(subscription.order))
+	for i, laptopID := range subscription.order {
+		updates[i] = &RatingUpdate{LaptopID: laptopID, Rating: subscription.pending[laptopID]}
+	}
+
+	subscription.pending = make(map[string]*Rating)
+	subscription.order = nil
+	return updates
+}
+
+// Close stops the subscription
+func (subscription *RatingSubscription) Close() {
+	broker := subscription.broker
+	broker.mutex.Lock()
+	defer broker.mutex.Unlock()
+
+	delete(broker.subscriptions, subscription)
+}
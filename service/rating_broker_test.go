@@ -0,0 +1,57 @@
+package service_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"gitlab.com/brucemig/pcbook/service"
+)
+
+func TestRatingBroker(t *testing.T) {
+	t.Parallel()
+
+	broker := service.NewRatingBroker()
+	store := service.NewInMemoryRatingStore(service.WithRatingObserver(broker.Publish))
+
+	all := broker.Subscribe()
+	defer all.Close()
+	laptop1 := broker.Subscribe("laptop1")
+
+	for _, vote := range []*service.Vote{
+		{LaptopID: "laptop1", Username: "user1", Score: 4},
+		{LaptopID: "laptop2", Username: "user1", Score: 6},
+		{LaptopID: "laptop1", Username: "user2", Score: 8},
+		{LaptopID: "laptop1", Username: "user3", Score: 9},
+	} {
+		_, err := store.Add(vote)
+		require.NoError(t, err)
+	}
+
+	// the updates of a laptop are coalesced into its latest rating
+	<-all.Ready()
+	updates := all.Updates()
+	require.Len(t, updates, 2)
+	require.Equal(t, "laptop1", updates[0].LaptopID)
+	require.Equal(t, uint32(3), updates[0].Rating.Count)
+	require.Equal(t, 21.0, updates[0].Rating.Sum)
+	require.Equal(t, "laptop2", updates[1].LaptopID)
+	require.Equal(t, uint32(1), updates[1].Rating.Count)
+	require.Empty(t, all.Updates())
+
+	<-laptop1.Ready()
+	updates = laptop1.Updates()
+	require.Len(t, updates, 1)
+	require.Equal(t, "laptop1", updates[0].LaptopID)
+	require.Equal(t, uint32(3), updates[0].Rating.Count)
+
+	// a closed subscription doesn't receive the new ratings anymore
+	laptop1.Close()
+	_, err := store.Add(&service.Vote{LaptopID: "laptop1", Username: "user4", Score: 5})
+	require.NoError(t, err)
+	require.Empty(t, laptop1.Updates())
+
+	<-all.Ready()
+	updates = all.Updates()
+	require.Len(t, updates, 1)
+	require.Equal(t, uint32(4), updates[0].Rating.Count)
+}
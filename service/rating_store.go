@@ -1,48 +1,622 @@
 package service
 
-import "sync"
+import (
+	"math"
+	"slices"
+	"sort"
+	"sync"
+	"time"
+)
+
+// MinScore and MaxScore are the default bounds of the scores and of the rating histogram
+const (
+	MinScore = 1
+	MaxScore = 10
+)
+
+// DefaultRatingPrior is the prior of the Bayesian average of the ratings:
+// every laptop starts with 10 virtual scores at the middle of the score range
+var DefaultRatingPrior = RatingPrior{Weight: 10, Mean: (MinScore + MaxScore) / 2.0}
+
+// DefaultRatingHalfLife is the default time after which a score weighs half as much in the decayed average
+const DefaultRatingHalfLife = 90 * 24 * time.Hour
+
+// maxDecayExponent is the number of half-lives between the newest score of a laptop and the reference time
+// of its decay weights, after which the weights are rescaled before they overflow
+const maxDecayExponent = 512
+
+// topRatedPageSize is the number of laptop ratings copied from the ranking at a time by TopRated
+const topRatedPageSize = 100
 
 // RatingStore is an interface to store laptop ratings
 type RatingStore interface {
-	// Add adds a new laptop score to the store and returns its rating
-	Add(laptopID string, score float64) (*Rating, error)
+	// Add adds a vote to the store and returns the new rating of its laptop.
+	// A vote replaces the previous vote of the same user for the same laptop.
+	// A vote with an empty username is anonymous, and is never replaced.
+	// The vote of a review only replaces the previous vote of the same review, and is not a vote of its user.
+	// A vote without a time is timestamped when it is added.
+	Add(vote *Vote) (*Rating, error)
+	// AddAll adds a batch of votes to the store atomically, in order, like Add:
+	// either all the votes are added, or none of them is. It returns the rating of the laptop of each vote after the batch.
+	AddAll(votes []*Vote) ([]*Rating, error)
+	// AddIfNewer adds a vote like Add, unless its user has already given a more recent vote to the laptop.
+	// It returns the rating of the laptop, possibly nil, and whether the vote was added.
+	AddIfNewer(vote *Vote) (*Rating, bool, error)
+	// Find returns the rating of a laptop, or nil if it has never been rated
+	Find(laptopID string) (*Rating, error)
+	// FindSince returns the rating of a laptop with the votes since the given time only,
+	// or nil if it has never been rated
+	FindSince(laptopID string, since time.Time) (*Rating, error)
+	// UserVotes returns the votes of a user, sorted by laptop ID
+	UserVotes(username string) ([]*Vote, error)
+	// TopRated calls found with the rating of each laptop rated at least minCount times since the given time,
+	// by descending Bayesian average, until found returns an error, which TopRated returns.
+	// Laptops with the same Bayesian average are ordered by descending count, then by ID.
+	// A zero time selects all votes. The store isn't locked while found runs, so found may call it;
+	// a laptop whose rating changes in the meantime is found at most once.
+	TopRated(minCount uint32, since time.Time, found func(laptopID string, rating *Rating) error) error
+}
+
+// Vote is the score given by a user to a laptop
+type Vote struct {
+	LaptopID string
+	Username string
+	Score    float64
+	Time     time.Time
+	// ReviewID is the ID of the approved review that gave the score, if any
+	ReviewID string
 }
 
 // Rating contains the rating information of a laptop
 type Rating struct {
 	Count uint32
 	Sum   float64
+	// SumOfSquares is the sum of the squared scores
+	SumOfSquares float64
+	// Histogram counts the scores rounded to each integer of the score range of the store,
+	// at index score-HistogramMin. It is shared by the copies of the rating, so it must not be modified.
+	Histogram    []uint32
+	HistogramMin int
+	Median       float64
+	// BayesianAverage is the average score with the prior virtual scores of the store,
+	// so that a laptop with few scores doesn't outrank a laptop with many good scores
+	BayesianAverage float64
+	// DecayedAverage is the average score where each score weighs half as much every half-life of the store,
+	// relative to the newest score, so that old scores count less than recent ones
+	DecayedAverage float64
+}
+
+// RatingPrior is the prior of a Bayesian average: Weight virtual scores equal to Mean
+type RatingPrior struct {
+	Weight float64
+	Mean   float64
+}
+
+// RatingStoreOption configures an InMemoryRatingStore
+type RatingStoreOption func(store *InMemoryRatingStore)
+
+// WithRatingPrior sets the prior of the Bayesian average of the ratings.
+// The larger the weight, the more scores a laptop needs for its Bayesian average to move away from the mean.
+func WithRatingPrior(weight float64, mean float64) RatingStoreOption {
+	return func(store *InMemoryRatingStore) {
+		store.prior = RatingPrior{Weight: weight, Mean: mean}
+	}
+}
+
+// WithRatingHalfLife sets the half-life of the scores in the decayed average of the ratings.
+// A zero half-life disables the decay, and the decayed average is the average.
+func WithRatingHalfLife(halfLife time.Duration) RatingStoreOption {
+	return func(store *InMemoryRatingStore) {
+		store.halfLife = halfLife
+	}
 }
 
-// InMemoryRatingStore stores laptop ratings in memory
+// WithRatingScoreRange sets the range of the scores, so that the histogram has a bucket for each integer in the range.
+// It is MinScore to MaxScore if not set. A score out of the range is counted in the nearest bucket.
+func WithRatingScoreRange(minScore float64, maxScore float64) RatingStoreOption {
+	return func(store *InMemoryRatingStore) {
+		store.histogramMin = int(math.Round(minScore))
+		store.histogramMax = max(int(math.Round(maxScore)), store.histogramMin)
+	}
+}
+
+// WithRatingObserver adds an observer that is called with the new rating of a laptop every time a vote is added.
+// The observers of a laptop are called in the order of its votes, under the lock of the laptop:
+// they must return quickly, must not call the store, and must not modify the rating.
+func WithRatingObserver(observer func(laptopID string, rating *Rating)) RatingStoreOption {
+	return func(store *InMemoryRatingStore) {
+		store.observers = append(store.observers, observer)
+	}
+}
+
+// InMemoryRatingStore stores laptop ratings in memory.
+// The ratings are spread over a number of shards by laptop ID, each with its own lock.
 type InMemoryRatingStore struct {
-	mutex  sync.RWMutex
-	rating map[string]*Rating
+	shards   []*ratingShard
+	prior    RatingPrior
+	halfLife time.Duration
+	// histogramMin and histogramMax are the scores of the first and last buckets of the histograms
+	histogramMin int
+	histogramMax int
+	// observers are called with the new rating of a laptop by Add
+	observers []func(laptopID string, rating *Rating)
+
+	// ranking is the index of the laptop ratings in TopRated order, kept up to date by Add.
+	// Its lock is taken after the lock of a shard.
+	rankingMutex sync.RWMutex
+	ranking      rankingIndex
+}
+
+// rankedRating is an entry of the ranking index
+type rankedRating struct {
+	laptopID string
+	rating   *Rating
+}
+
+type ratingShard struct {
+	mutex   sync.RWMutex
+	laptops map[string]*laptopRating
+	// userVotes are the votes of each user, by username and laptop ID
+	userVotes map[string]map[string]*Vote
+	// reviewVotes are the votes of the reviews, by review ID
+	reviewVotes map[string]*Vote
+}
+
+// laptopRating contains the votes of a laptop and their aggregates
+type laptopRating struct {
+	rating Rating
+	votes  []*Vote
+	// scores are the scores of the votes in ascending order, to find the median
+	scores []float64
+	// decayedSum and decayedWeight are the sums of the weighted scores and of the weights of the decayed average.
+	// The weight of a score is 2^(t/halfLife), where t is the time of its vote since decayReference.
+	decayedSum     float64
+	decayedWeight  float64
+	decayReference time.Time
+	// ranked is the snapshot of the rating in the ranking index
+	ranked *Rating
 }
 
 // NewInMemoryRatingStore returns a new InMemoryRatingStore
-func NewInMemoryRatingStore() *InMemoryRatingStore {
-	return &InMemoryRatingStore{
-		rating: make(map[string]*Rating),
+func NewInMemoryRatingStore(options ...RatingStoreOption) *InMemoryRatingStore {
+	return NewShardedInMemoryRatingStore(defaultShardCount, options...)
+}
+
+// NewShardedInMemoryRatingStore returns a new InMemoryRatingStore with the given number of shards
+func NewShardedInMemoryRatingStore(shardCount int, options ...RatingStoreOption) *InMemoryRatingStore {
+	shards := make([]*ratingShard, max(shardCount, 1))
+	for i := range shards {
+		shards[i] = &ratingShard{
+			laptops:     make(map[string]*laptopRating),
+			userVotes:   make(map[string]map[string]*Vote),
+			reviewVotes: make(map[string]*Vote),
+		}
 	}
+
+	store := &InMemoryRatingStore{
+		shards:       shards,
+		prior:        DefaultRatingPrior,
+		halfLife:     DefaultRatingHalfLife,
+		histogramMin: MinScore,
+		histogramMax: MaxScore,
+	}
+
+	for _, option := range options {
+		option(store)
+	}
+	return store
+}
+
+func (store *InMemoryRatingStore) shard(laptopID string) *ratingShard {
+	return store.shards[shardIndex(laptopID, len(store.shards))]
 }
 
-// Add adds a new laptop score to the store and returns its rating
-func (store *InMemoryRatingStore) Add(laptopID string, score float64) (*Rating, error) {
-	store.mutex.Lock()
-	defer store.mutex.Unlock()
+// Add adds a vote to the store and returns the new rating of its laptop
+func (store *InMemoryRatingStore) Add(vote *Vote) (*Rating, error) {
+	shard := store.shard(vote.LaptopID)
+	shard.mutex.Lock()
+	defer shard.mutex.Unlock()
 
-	rating := store.rating[laptopID]
-	if rating == nil {
-		rating = &Rating{
-			Count: 1,
-			Sum:   score,
+	result := *store.add(shard, vote)
+	return &result, nil
+}
+
+// AddIfNewer adds a vote, unless its user has already given a more recent vote to the laptop
+func (store *InMemoryRatingStore) AddIfNewer(vote *Vote) (*Rating, bool, error) {
+	shard := store.shard(vote.LaptopID)
+	shard.mutex.Lock()
+	defer shard.mutex.Unlock()
+
+	if shard.hasNewerVote(vote) {
+		var rating *Rating
+		if laptop := shard.laptops[vote.LaptopID]; laptop != nil {
+			rating = laptop.snapshot(store.prior)
 		}
+		return rating, false, nil
+	}
+
+	result := *store.add(shard, vote)
+	return &result, true, nil
+}
+
+// hasNewerVote tells whether the user of a vote has already given a more recent vote to its laptop
+func (store *InMemoryRatingStore) hasNewerVote(vote *Vote) bool {
+	shard := store.shard(vote.LaptopID)
+	shard.mutex.RLock()
+	defer shard.mutex.RUnlock()
+
+	return shard.hasNewerVote(vote)
+}
+
+// hasNewerVote tells whether the user of a vote has already given a more recent vote to its laptop.
+// The caller must hold the lock of the shard.
+func (shard *ratingShard) hasNewerVote(vote *Vote) bool {
+	// the anonymous votes and the votes of the reviews are never replaced by the votes of a user
+	if vote.Username == "" || vote.ReviewID != "" {
+		return false
+	}
+
+	previous := shard.userVotes[vote.Username][vote.LaptopID]
+	return previous != nil && previous.Time.After(vote.Time)
+}
+
+// AddAll adds a batch of votes to the store atomically, and returns the rating of the laptop of each vote after the batch
+func (store *InMemoryRatingStore) AddAll(votes []*Vote) ([]*Rating, error) {
+	// the shards are locked in order, so that concurrent batches don't deadlock
+	var indexes []int
+	for _, vote := range votes {
+		indexes = append(indexes, shardIndex(vote.LaptopID, len(store.shards)))
+	}
+	slices.Sort(indexes)
+	for _, i := range slices.Compact(indexes) {
+		store.shards[i].mutex.Lock()
+		defer store.shards[i].mutex.Unlock()
+	}
+
+	for _, vote := range votes {
+		store.add(store.shard(vote.LaptopID), vote)
+	}
+
+	ratings := make([]*Rating, len(votes))
+	for i, vote := range votes {
+		rating := *store.shard(vote.LaptopID).laptops[vote.LaptopID].ranked
+		ratings[i] = &rating
+	}
+	return ratings, nil
+}
+
+// add adds a vote to a shard and returns the new rating of its laptop, which the caller must not modify.
+// The caller must hold the lock of the shard.
+func (store *InMemoryRatingStore) add(shard *ratingShard, vote *Vote) *Rating {
+	laptop := shard.laptops[vote.LaptopID]
+	if laptop == nil {
+		laptop = newLaptopRating(store.histogramMin, store.histogramMax)
+		shard.laptops[vote.LaptopID] = laptop
+	}
+
+	other := *vote
+	if other.Time.IsZero() {
+		other.Time = time.Now()
+	}
+
+	previous := shard.userVotes[vote.Username][vote.LaptopID]
+	if vote.ReviewID != "" {
+		previous = shard.reviewVotes[vote.ReviewID]
+	}
+
+	if previous != nil {
+		laptop.replace(previous, &other, store.halfLife)
 	} else {
-		rating.Count++
-		rating.Sum += score
+		laptop.add(&other, store.halfLife)
+		if vote.ReviewID != "" {
+			shard.reviewVotes[vote.ReviewID] = &other
+		} else if vote.Username != "" {
+			if shard.userVotes[vote.Username] == nil {
+				shard.userVotes[vote.Username] = make(map[string]*Vote)
+			}
+			shard.userVotes[vote.Username][vote.LaptopID] = &other
+		}
+	}
+
+	// the ranking is updated under the shard lock, so that the updates of a laptop are applied in order
+	rating := laptop.snapshot(store.prior)
+	store.rank(vote.LaptopID, laptop.ranked, rating)
+	laptop.ranked = rating
+	for _, observer := range store.observers {
+		observer(vote.LaptopID, rating)
+	}
+	return rating
+}
+
+// Find returns the rating of a laptop, or nil if it has never been rated
+func (store *InMemoryRatingStore) Find(laptopID string) (*Rating, error) {
+	shard := store.shard(laptopID)
+	shard.mutex.RLock()
+	defer shard.mutex.RUnlock()
+
+	laptop := shard.laptops[laptopID]
+	if laptop == nil {
+		return nil, nil
+	}
+
+	return laptop.snapshot(store.prior), nil
+}
+
+// FindSince returns the rating of a laptop with the votes since the given time only
+func (store *InMemoryRatingStore) FindSince(laptopID string, since time.Time) (*Rating, error) {
+	shard := store.shard(laptopID)
+	shard.mutex.RLock()
+	defer shard.mutex.RUnlock()
+
+	laptop := shard.laptops[laptopID]
+	if laptop == nil {
+		return nil, nil
+	}
+
+	return laptop.since(since, store.halfLife).snapshot(store.prior), nil
+}
+
+// UserVotes returns the votes of a user, sorted by laptop ID
+func (store *InMemoryRatingStore) UserVotes(username string) ([]*Vote, error) {
+	var votes []*Vote
+	for _, shard := range store.shards {
+		shard.mutex.RLock()
+		for _, vote := range shard.userVotes[username] {
+			other := *vote
+			votes = append(votes, &other)
+		}
+		shard.mutex.RUnlock()
+	}
+
+	sort.Slice(votes, func(i, j int) bool {
+		return votes[i].LaptopID < votes[j].LaptopID
+	})
+	return votes, nil
+}
+
+// TopRated calls found with the ratings of the laptops rated at least minCount times since the given time,
+// by descending Bayesian average. Without a time, it walks the ranking index. With a time,
+// it ranks the ratings of all laptops since that time, which the index can't keep as the time passes.
+func (store *InMemoryRatingStore) TopRated(
+	minCount uint32,
+	since time.Time,
+	found func(laptopID string, rating *Rating) error,
+) error {
+	if since.IsZero() {
+		return store.topRated(minCount, found)
+	}
+
+	var ranking []*rankedRating
+	for _, shard := range store.shards {
+		shard.mutex.RLock()
+		for laptopID, laptop := range shard.laptops {
+			// like the index, the ranking only has the laptops rated at least once
+			rating := laptop.since(since, store.halfLife).snapshot(store.prior)
+			if rating.Count > 0 && rating.Count >= minCount {
+				ranking = append(ranking, &rankedRating{laptopID: laptopID, rating: rating})
+			}
+		}
+		shard.mutex.RUnlock()
+	}
+
+	sort.Slice(ranking, func(i, j int) bool {
+		return rankedBefore(ranking[i], ranking[j])
+	})
+
+	for _, ranked := range ranking {
+		err := found(ranked.laptopID, ranked.rating)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// topRated calls found with the ratings of the laptops rated at least minCount times, in the order of the ranking index
+func (store *InMemoryRatingStore) topRated(minCount uint32, found func(laptopID string, rating *Rating) error) error {
+	// the ratings are copied a page at a time, so that the ranking isn't locked while found runs
+	var after *rankedRating
+	seen := make(map[string]bool)
+	for {
+		page := store.topRatedPage(after, minCount)
+		for _, ranked := range page {
+			// a laptop that moved up in the ranking between two pages is only found once
+			if seen[ranked.laptopID] {
+				continue
+			}
+			seen[ranked.laptopID] = true
+
+			err := found(ranked.laptopID, ranked.rating)
+			if err != nil {
+				return err
+			}
+		}
+
+		if len(page) < topRatedPageSize {
+			return nil
+		}
+		after = page[len(page)-1]
+	}
+}
+
+// topRatedPage returns copies of the next laptop ratings of the ranking after the given one,
+// skipping the laptops with less than minCount votes
+func (store *InMemoryRatingStore) topRatedPage(after *rankedRating, minCount uint32) []*rankedRating {
+	store.rankingMutex.RLock()
+	defer store.rankingMutex.RUnlock()
+
+	var page []*rankedRating
+	store.ranking.each(after, func(ranked *rankedRating) bool {
+		if ranked.rating.Count >= minCount {
+			rating := *ranked.rating
+			page = append(page, &rankedRating{laptopID: ranked.laptopID, rating: &rating})
+		}
+		return len(page) < topRatedPageSize
+	})
+	return page
+}
+
+// rank moves a laptop from the position of its previous rating in the ranking, if any, to the position of its new rating
+func (store *InMemoryRatingStore) rank(laptopID string, previous *Rating, rating *Rating) {
+	store.rankingMutex.Lock()
+	defer store.rankingMutex.Unlock()
+
+	if previous != nil {
+		store.ranking.remove(&rankedRating{laptopID: laptopID, rating: previous})
+	}
+	store.ranking.insert(&rankedRating{laptopID: laptopID, rating: rating})
+}
+
+// rankedBefore tells whether a laptop rating comes before another one in TopRated order
+func rankedBefore(ranked1 *rankedRating, ranked2 *rankedRating) bool {
+	if ranked1.rating.BayesianAverage != ranked2.rating.BayesianAverage {
+		return ranked1.rating.BayesianAverage > ranked2.rating.BayesianAverage
+	}
+	if ranked1.rating.Count != ranked2.rating.Count {
+		return ranked1.rating.Count > ranked2.rating.Count
+	}
+	return ranked1.laptopID < ranked2.laptopID
+}
+
+// newLaptopRating returns the rating of a laptop without votes, whose histogram goes from minScore to maxScore
+func newLaptopRating(minScore int, maxScore int) *laptopRating {
+	return &laptopRating{
+		rating: Rating{
+			Histogram:    make([]uint32, maxScore-minScore+1),
+			HistogramMin: minScore,
+		},
 	}
+}
+
+// add adds a new vote to the laptop
+func (laptop *laptopRating) add(vote *Vote, halfLife time.Duration) {
+	laptop.votes = append(laptop.votes, vote)
+	laptop.insertScore(vote.Score)
+
+	laptop.rating.Count++
+	laptop.rating.Sum += vote.Score
+	laptop.rating.SumOfSquares += vote.Score * vote.Score
+	laptop.countScore(vote.Score, 1)
+	laptop.addDecayed(vote, halfLife)
+}
+
+// replace replaces the score and time of a vote of the laptop with the ones of a new vote
+func (laptop *laptopRating) replace(vote *Vote, newVote *Vote, halfLife time.Duration) {
+	laptop.removeScore(vote.Score)
+	laptop.insertScore(newVote.Score)
+	laptop.countScore(vote.Score, -1)
+	laptop.countScore(newVote.Score, 1)
+	vote.Score = newVote.Score
+	vote.Time = newVote.Time
+
+	// the sums are recomputed rather than adjusted, so that replaced scores don't accumulate rounding errors
+	laptop.rating.Sum = 0
+	laptop.rating.SumOfSquares = 0
+	laptop.decayedSum = 0
+	laptop.decayedWeight = 0
+	laptop.decayReference = time.Time{}
+	for _, other := range laptop.votes {
+		laptop.rating.Sum += other.Score
+		laptop.rating.SumOfSquares += other.Score * other.Score
+		laptop.addDecayed(other, halfLife)
+	}
+}
+
+// addDecayed adds the weighted score of a vote to the sums of the decayed average
+func (laptop *laptopRating) addDecayed(vote *Vote, halfLife time.Duration) {
+	if halfLife <= 0 {
+		laptop.decayedSum += vote.Score
+		laptop.decayedWeight++
+		return
+	}
+
+	if laptop.decayReference.IsZero() {
+		laptop.decayReference = vote.Time
+	}
+
+	exponent := float64(vote.Time.Sub(laptop.decayReference)) / float64(halfLife)
+	if exponent > maxDecayExponent {
+		// the weights are relative, so they can all be scaled to a reference at the time of the vote
+		scale := math.Exp2(-exponent)
+		laptop.decayedSum *= scale
+		laptop.decayedWeight *= scale
+		laptop.decayReference = vote.Time
+		exponent = 0
+	}
+
+	weight := math.Exp2(exponent)
+	laptop.decayedSum += weight * vote.Score
+	laptop.decayedWeight += weight
+}
+
+// since returns the rating of the laptop with the votes since the given time only
+func (laptop *laptopRating) since(since time.Time, halfLife time.Duration) *laptopRating {
+	window := newLaptopRating(laptop.rating.HistogramMin, laptop.rating.HistogramMin+len(laptop.rating.Histogram)-1)
+	for _, vote := range laptop.votes {
+		if !vote.Time.Before(since) {
+			window.add(vote, halfLife)
+		}
+	}
+	return window
+}
+
+func (laptop *laptopRating) insertScore(score float64) {
+	i, _ := slices.BinarySearch(laptop.scores, score)
+	laptop.scores = slices.Insert(laptop.scores, i, score)
+}
+
+func (laptop *laptopRating) removeScore(score float64) {
+	i, found := slices.BinarySearch(laptop.scores, score)
+	if found {
+		laptop.scores = slices.Delete(laptop.scores, i, i+1)
+	}
+}
+
+// snapshot returns a copy of the rating of the laptop, with its median, Bayesian average and decayed average
+func (laptop *laptopRating) snapshot(prior RatingPrior) *Rating {
+	rating := laptop.rating
+
+	n := len(laptop.scores)
+	if n%2 == 1 {
+		rating.Median = laptop.scores[n/2]
+	} else if n > 0 {
+		rating.Median = (laptop.scores[n/2-1] + laptop.scores[n/2]) / 2
+	}
+
+	rating.BayesianAverage = (prior.Weight*prior.Mean + rating.Sum) / (prior.Weight + float64(rating.Count))
+	if laptop.decayedWeight > 0 {
+		rating.DecayedAverage = laptop.decayedSum / laptop.decayedWeight
+	}
+	return &rating
+}
+
+// Average returns the average score, or 0 if there is no score
+func (rating *Rating) Average() float64 {
+	if rating.Count == 0 {
+		return 0
+	}
+	return rating.Sum / float64(rating.Count)
+}
+
+// StandardDeviation returns the population standard deviation of the scores, or 0 if there is no score
+func (rating *Rating) StandardDeviation() float64 {
+	if rating.Count == 0 {
+		return 0
+	}
+
+	average := rating.Average()
+	variance := rating.SumOfSquares/float64(rating.Count) - average*average
+	// rounding errors may make the variance of equal scores slightly negative
+	return math.Sqrt(max(variance, 0))
+}
 
-	store.rating[laptopID] = rating
-	return rating, nil
+// countScore adds delta to the histogram bucket of a score rounded to the nearest integer in the range of the histogram.
+// The histogram is shared by the copies of the rating, so it is replaced rather than modified.
+func (laptop *laptopRating) countScore(score float64, delta int) {
+	histogram := slices.Clone(laptop.rating.Histogram)
+	minScore := float64(laptop.rating.HistogramMin)
+	maxScore := minScore + float64(len(histogram)-1)
+	bucket := int(min(max(math.Round(score), minScore), maxScore) - minScore)
+	histogram[bucket] = uint32(int(histogram[bucket]) + delta)
+	laptop.rating.Histogram = histogram
 }
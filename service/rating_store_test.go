@@ -0,0 +1,247 @@
+package service_test
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log"
+	"math"
+	"math/rand/v2"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"gitlab.com/brucemig/pcbook/pb"
+	"gitlab.com/brucemig/pcbook/sample"
+	"gitlab.com/brucemig/pcbook/service"
+)
+
+func TestInMemoryRatingStorePrior(t *testing.T) {
+	t.Parallel()
+
+	store := service.NewInMemoryRatingStore(service.WithRatingPrior(20, 7))
+
+	// a single perfect score doesn't outrank many very good scores
+	single, err := store.Add(&service.Vote{LaptopID: "single", Username: "user", Score: 10})
+	require.NoError(t, err)
+	require.Equal(t, 10.0, single.Average())
+	require.InDelta(t, (20*7+10)/21.0, single.BayesianAverage, 1e-9)
+
+	var many *service.Rating
+	for i := 0; i < 500; i++ {
+		score := 9.0
+		if i%10 == 0 {
+			score = 10
+		}
+		many, err = store.Add(&service.Vote{LaptopID: "many", Username: fmt.Sprintf("user%d", i), Score: score})
+		require.NoError(t, err)
+	}
+	require.InDelta(t, 9.1, many.Average(), 1e-9)
+	require.Greater(t, many.BayesianAverage, single.BayesianAverage)
+}
+
+func TestInMemoryRatingStoreHalfLife(t *testing.T) {
+	t.Parallel()
+
+	// with a short half-life, the weights of scores years apart would overflow without rescaling
+	store := service.NewInMemoryRatingStore(service.WithRatingHalfLife(time.Hour))
+	start := time.Date(2015, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	var rating *service.Rating
+	var err error
+	for year := 0; year < 10; year++ {
+		votedAt := start.AddDate(year, 0, 0)
+		rating, err = store.Add(&service.Vote{LaptopID: "old", Username: fmt.Sprintf("user%d", year), Score: float64(year + 1), Time: votedAt})
+		require.NoError(t, err)
+		require.False(t, math.IsNaN(rating.DecayedAverage) || math.IsInf(rating.DecayedAverage, 0))
+	}
+	require.Equal(t, 10.0, rating.DecayedAverage)
+
+	// an older vote doesn't move the decayed average of recent ones
+	rating, err = store.Add(&service.Vote{LaptopID: "old", Score: 1, Time: start})
+	require.NoError(t, err)
+	require.Equal(t, 10.0, rating.DecayedAverage)
+
+	// without a half-life, the decayed average is the average
+	store = service.NewInMemoryRatingStore(service.WithRatingHalfLife(0))
+	for i, score := range []float64{2, 4, 9} {
+		rating, err = store.Add(&service.Vote{LaptopID: "laptop", Score: score, Time: start.AddDate(i, 0, 0)})
+		require.NoError(t, err)
+	}
+	require.Equal(t, 5.0, rating.DecayedAverage)
+}
+
+func TestInMemoryRatingStoreScoreRange(t *testing.T) {
+	t.Parallel()
+
+	// the histogram has a bucket for each integer of the score range
+	store := service.NewInMemoryRatingStore(service.WithRatingScoreRange(0, 5))
+	var rating *service.Rating
+	var err error
+	for i, score := range []float64{0, 0.4, 2.6, 5} {
+		rating, err = store.Add(&service.Vote{LaptopID: "laptop", Username: fmt.Sprintf("user%d", i), Score: score})
+		require.NoError(t, err)
+	}
+	require.Equal(t, 0, rating.HistogramMin)
+	require.Equal(t, []uint32{2, 0, 0, 1, 0, 1}, rating.Histogram)
+
+	// a replaced score moves to its new bucket, without changing the histograms already returned
+	replaced, err := store.Add(&service.Vote{LaptopID: "laptop", Username: "user0", Score: 4})
+	require.NoError(t, err)
+	require.Equal(t, []uint32{1, 0, 0, 1, 1, 1}, replaced.Histogram)
+	require.Equal(t, []uint32{2, 0, 0, 1, 0, 1}, rating.Histogram)
+
+	window, err := store.FindSince("laptop", time.Unix(1, 0))
+	require.NoError(t, err)
+	require.Equal(t, replaced.Histogram, window.Histogram)
+	require.Equal(t, 0, window.HistogramMin)
+
+	// a score out of the range is counted in the nearest bucket
+	store = service.NewInMemoryRatingStore(service.WithRatingScoreRange(-2, 2))
+	rating, err = store.Add(&service.Vote{LaptopID: "laptop", Score: -3})
+	require.NoError(t, err)
+	require.Equal(t, -2, rating.HistogramMin)
+	require.Equal(t, []uint32{1, 0, 0, 0, 0}, rating.Histogram)
+}
+
+func TestInMemoryRatingStoreRankingIndex(t *testing.T) {
+	t.Parallel()
+
+	store := service.NewInMemoryRatingStore()
+	for i := 0; i < 5000; i++ {
+		_, err := store.Add(&service.Vote{
+			LaptopID: fmt.Sprintf("laptop%d", rand.IntN(300)),
+			Username: fmt.Sprintf("user%d", rand.IntN(20)),
+			Score:    float64(1 + rand.IntN(10)),
+		})
+		require.NoError(t, err)
+	}
+
+	// the index has the order of a ranking made from scratch
+	topRated := func(since time.Time) []string {
+		var laptopIDs []string
+		err := store.TopRated(0, since, func(laptopID string, rating *service.Rating) error {
+			laptopIDs = append(laptopIDs, laptopID)
+			return nil
+		})
+		require.NoError(t, err)
+		return laptopIDs
+	}
+	indexed := topRated(time.Time{})
+	require.Len(t, indexed, 300)
+	require.Equal(t, topRated(time.Unix(1, 0)), indexed)
+}
+
+func TestInMemoryRatingStoreTopRatedWrites(t *testing.T) {
+	t.Parallel()
+
+	store := service.NewInMemoryRatingStore()
+	for i := 0; i < 250; i++ {
+		_, err := store.Add(&service.Vote{LaptopID: fmt.Sprintf("laptop%d", i), Score: float64(1 + i%10)})
+		require.NoError(t, err)
+	}
+
+	// the ranking isn't locked while found runs, so found can rate the laptops,
+	// and a laptop that moves up in the ranking isn't found twice
+	found := make(map[string]int)
+	err := store.TopRated(0, time.Time{}, func(laptopID string, rating *service.Rating) error {
+		found[laptopID]++
+		_, err := store.Add(&service.Vote{LaptopID: laptopID, Username: "user", Score: 10})
+		return err
+	})
+	require.NoError(t, err)
+	require.Len(t, found, 250)
+	for laptopID, count := range found {
+		require.Equal(t, 1, count, laptopID)
+	}
+}
+
+func BenchmarkInMemoryRatingStoreAdd(b *testing.B) {
+	laptopIDs := make([]string, 1000)
+	for i := range laptopIDs {
+		laptopIDs[i] = sample.NewLaptop().GetId()
+	}
+
+	usernames := make([]string, 10)
+	for i := range usernames {
+		usernames[i] = fmt.Sprintf("user%d", i)
+	}
+
+	for _, shardCount := range []int{1, 16, 64} {
+		b.Run(fmt.Sprintf("shards_%d", shardCount), func(b *testing.B) {
+			store := service.NewShardedInMemoryRatingStore(shardCount)
+
+			b.RunParallel(func(testPB *testing.PB) {
+				for i := 0; testPB.Next(); i++ {
+					_, err := store.Add(&service.Vote{
+						LaptopID: laptopIDs[i%len(laptopIDs)],
+						Username: usernames[i%len(usernames)],
+						Score:    5,
+					})
+					if err != nil {
+						b.Fatal(err)
+					}
+				}
+			})
+		})
+	}
+}
+
+// BenchmarkRateLaptopStreams rates laptops through the stores that the server wires:
+// the catalog stores, backed by an event log file that is synced for every rating
+func BenchmarkRateLaptopStreams(b *testing.B) {
+	log.SetOutput(io.Discard)
+	defer log.SetOutput(os.Stderr)
+
+	eventLog, err := service.NewFileEventLog(filepath.Join(b.TempDir(), "events.log"))
+	require.NoError(b, err)
+	defer eventLog.Close()
+
+	catalog, err := service.NewCatalog(eventLog)
+	require.NoError(b, err)
+	laptopStore := catalog.LaptopStore()
+
+	laptopIDs := make([]string, 100)
+	for i := range laptopIDs {
+		laptop := sample.NewLaptop()
+		laptopIDs[i] = laptop.GetId()
+		require.NoError(b, laptopStore.Save(laptop))
+	}
+
+	serverAddress := startTestLaptopServer(b, laptopStore, nil, catalog.RatingStore())
+	laptopClient := newTestLaptopClient(b, serverAddress)
+
+	// each goroutine rates the laptops through its own stream
+	b.ResetTimer()
+	b.SetParallelism(8)
+	b.RunParallel(func(testPB *testing.PB) {
+		stream, err := laptopClient.RateLaptop(context.Background())
+		if err != nil {
+			b.Fatal(err)
+		}
+
+		for i := 0; testPB.Next(); i++ {
+			req := &pb.RateLaptopRequest{
+				LaptopId: laptopIDs[i%len(laptopIDs)],
+				Score:    5,
+			}
+
+			err := stream.Send(req)
+			if err != nil {
+				b.Fatal(err)
+			}
+
+			_, err = stream.Recv()
+			if err != nil {
+				b.Fatal(err)
+			}
+		}
+
+		err = stream.CloseSend()
+		if err != nil {
+			b.Fatal(err)
+		}
+	})
+}
@@ -0,0 +1,235 @@
+package service
+
+import (
+	"errors"
+	"sort"
+	"sync"
+	"time"
+)
+
+// ErrAlreadyModerated is returned when a review that isn't pending is approved or rejected
+var ErrAlreadyModerated = errors.New("review already moderated")
+
+// ReviewStatus is the moderation status of a review
+type ReviewStatus int
+
+// A review is pending until a moderator approves or rejects it
+const (
+	ReviewPending ReviewStatus = iota
+	ReviewApproved
+	ReviewRejected
+)
+
+// ReviewOrder is the order of the reviews returned by ReviewStore.List.
+// Reviews in the same position are ordered by ID.
+type ReviewOrder int
+
+// The review orders
+const (
+	ReviewsNewestFirst ReviewOrder = iota
+	ReviewsOldestFirst
+	// ReviewsHighestScoreFirst orders reviews with the same score newest first
+	ReviewsHighestScoreFirst
+	// ReviewsLowestScoreFirst orders reviews with the same score newest first
+	ReviewsLowestScoreFirst
+)
+
+// ReviewStore is an interface to store laptop reviews
+type ReviewStore interface {
+	// Save saves a new review to the store.
+	// It returns ErrAlreadyExists if a review with the same ID already exists.
+	Save(review *Review) error
+	// Find finds a review by ID, or returns nil if it doesn't exist
+	Find(reviewID string) (*Review, error)
+	// Moderate sets the status of a pending review and returns the moderated review.
+	// It returns ErrNotFound if the review doesn't exist, and ErrAlreadyModerated if it isn't pending.
+	Moderate(reviewID string, status ReviewStatus, moderator string, moderatedAt time.Time) (*Review, error)
+	// List returns a page of the reviews selected by the query.
+	// It returns ErrNotFound if the review that the page starts after doesn't exist.
+	List(query ReviewQuery) ([]*Review, error)
+}
+
+// Review is a review written by a user about a laptop
+type Review struct {
+	ID       string
+	LaptopID string
+	Username string
+	Title    string
+	Body     string
+	Score    float64
+	Status   ReviewStatus
+	// Moderator is the user who approved or rejected the review, empty while it is pending
+	Moderator   string
+	CreatedAt   time.Time
+	ModeratedAt time.Time
+}
+
+// ReviewQuery selects a page of reviews
+type ReviewQuery struct {
+	// LaptopID selects the reviews of a laptop, or of all laptops if empty
+	LaptopID string
+	Status   ReviewStatus
+	Order    ReviewOrder
+	// After is the ID of the last review of the previous page, or empty for the first page
+	After string
+	// Limit is the maximum number of reviews in the page, or 0 for no limit
+	Limit int
+}
+
+// InMemoryReviewStore stores laptop reviews in memory
+type InMemoryReviewStore struct {
+	mutex   sync.RWMutex
+	reviews map[string]*Review
+	// laptops are the IDs of the reviews of each laptop
+	laptops map[string][]string
+}
+
+// NewInMemoryReviewStore returns a new InMemoryReviewStore
+func NewInMemoryReviewStore() *InMemoryReviewStore {
+	return &InMemoryReviewStore{
+		reviews: make(map[string]*Review),
+		laptops: make(map[string][]string),
+	}
+}
+
+// Save saves a new review to the store
+func (store *InMemoryReviewStore) Save(review *Review) error {
+	store.mutex.Lock()
+	defer store.mutex.Unlock()
+
+	if store.reviews[review.ID] != nil {
+		return ErrAlreadyExists
+	}
+
+	other := *review
+	store.reviews[review.ID] = &other
+	store.laptops[review.LaptopID] = append(store.laptops[review.LaptopID], review.ID)
+	return nil
+}
+
+// Find finds a review by ID
+func (store *InMemoryReviewStore) Find(reviewID string) (*Review, error) {
+	store.mutex.RLock()
+	defer store.mutex.RUnlock()
+
+	review := store.reviews[reviewID]
+	if review == nil {
+		return nil, nil
+	}
+
+	other := *review
+	return &other, nil
+}
+
+// Moderate sets the status of a pending review
+func (store *InMemoryReviewStore) Moderate(
+	reviewID string,
+	status ReviewStatus,
+	moderator string,
+	moderatedAt time.Time,
+) (*Review, error) {
+	store.mutex.Lock()
+	defer store.mutex.Unlock()
+
+	review := store.reviews[reviewID]
+	if review == nil {
+		return nil, ErrNotFound
+	}
+	if review.Status != ReviewPending {
+		return nil, ErrAlreadyModerated
+	}
+
+	review.Status = status
+	review.Moderator = moderator
+	review.ModeratedAt = moderatedAt
+
+	other := *review
+	return &other, nil
+}
+
+// List returns a page of the reviews selected by the query
+func (store *InMemoryReviewStore) List(query ReviewQuery) ([]*Review, error) {
+	store.mutex.RLock()
+	defer store.mutex.RUnlock()
+
+	var reviews []*Review
+	if query.LaptopID != "" {
+		for _, reviewID := range store.laptops[query.LaptopID] {
+			review := store.reviews[reviewID]
+			if review.Status == query.Status {
+				reviews = append(reviews, review)
+			}
+		}
+	} else {
+		for _, review := range store.reviews {
+			if review.Status == query.Status {
+				reviews = append(reviews, review)
+			}
+		}
+	}
+
+	less := reviewLess(query.Order)
+	sort.Slice(reviews, func(i, j int) bool {
+		return less(reviews[i], reviews[j])
+	})
+
+	// the page starts after the position of the review in the order, even if the query doesn't select it anymore
+	if query.After != "" {
+		after := store.reviews[query.After]
+		if after == nil {
+			return nil, ErrNotFound
+		}
+
+		i := sort.Search(len(reviews), func(i int) bool {
+			return less(after, reviews[i])
+		})
+		reviews = reviews[i:]
+	}
+
+	if query.Limit > 0 && len(reviews) > query.Limit {
+		reviews = reviews[:query.Limit]
+	}
+
+	page := make([]*Review, len(reviews))
+	for i, review := range reviews {
+		other := *review
+		page[i] = &other
+	}
+	return page, nil
+}
+
+// reviewLess returns the function that tells whether a review comes before another one in the given order
+func reviewLess(order ReviewOrder) func(review1 *Review, review2 *Review) bool {
+	newer := func(review1 *Review, review2 *Review) bool {
+		if !review1.CreatedAt.Equal(review2.CreatedAt) {
+			return review1.CreatedAt.After(review2.CreatedAt)
+		}
+		return review1.ID < review2.ID
+	}
+
+	switch order {
+	case ReviewsOldestFirst:
+		return func(review1 *Review, review2 *Review) bool {
+			if !review1.CreatedAt.Equal(review2.CreatedAt) {
+				return review1.CreatedAt.Before(review2.CreatedAt)
+			}
+			return review1.ID < review2.ID
+		}
+	case ReviewsHighestScoreFirst:
+		return func(review1 *Review, review2 *Review) bool {
+			if review1.Score != review2.Score {
+				return review1.Score > review2.Score
+			}
+			return newer(review1, review2)
+		}
+	case ReviewsLowestScoreFirst:
+		return func(review1 *Review, review2 *Review) bool {
+			if review1.Score != review2.Score {
+				return review1.Score < review2.Score
+			}
+			return newer(review1, review2)
+		}
+	default:
+		return newer
+	}
+}
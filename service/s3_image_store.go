@@ -0,0 +1,533 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// DefaultS3Timeout is the default time limit of an S3 request, including the transfer of its body
+const DefaultS3Timeout = time.Minute
+
+// S3Config configures the S3-compatible object storage of an S3ImageStore
+type S3Config struct {
+	// Endpoint is the base URL of the S3 API, such as https://s3.us-east-1.amazonaws.com
+	Endpoint        string
+	Bucket          string
+	Region          string
+	AccessKeyID     string
+	SecretAccessKey string
+	// HTTPClient sends the S3 requests, a client without any settings but the timeout if nil
+	HTTPClient *http.Client
+	// Timeout limits each S3 request, including the transfer of its body, DefaultS3Timeout if zero.
+	// It also applies to a given HTTPClient, so that a stalled endpoint never blocks the store.
+	Timeout time.Duration
+}
+
+// S3ImageStore stores images in an S3-compatible object storage, so that several servers can share them.
+// The objects of the bucket are:
+//   - blobs/{checksum}: the image data, shared by the images with the same content
+//   - images/{image_id}.json: the image metadata, written last when an image is saved
+//   - laptops/{laptop_id}/{image_id}: an empty marker to list the images of a laptop
+//   - refs/{checksum}/{image_id}: an empty marker to count the images that point to a blob
+//
+// S3 has no transactions, so a blob deleted with its last image on one server may race
+// with the same content being saved on another server. Save checks the blob again once the image is saved,
+// and CollectDangling deletes the images whose blob was lost anyway.
+type S3ImageStore struct {
+	client   *http.Client
+	timeout  time.Duration
+	endpoint *url.URL
+	bucket   string
+	signer   *s3Signer
+}
+
+// NewS3ImageStore returns a new S3ImageStore
+func NewS3ImageStore(config S3Config) (*S3ImageStore, error) {
+	endpoint, err := url.Parse(config.Endpoint)
+	if err != nil {
+		return nil, fmt.Errorf("cannot parse S3 endpoint: %w", err)
+	}
+	if endpoint.Scheme == "" || endpoint.Host == "" {
+		return nil, fmt.Errorf("S3 endpoint must be an absolute URL: %s", config.Endpoint)
+	}
+	if config.Bucket == "" || config.Region == "" {
+		return nil, errors.New("S3 bucket and region must be set")
+	}
+
+	timeout := config.Timeout
+	if timeout == 0 {
+		timeout = DefaultS3Timeout
+	}
+
+	client := config.HTTPClient
+	if client == nil {
+		client = &http.Client{Timeout: timeout}
+	}
+
+	store := &S3ImageStore{
+		client:   client,
+		timeout:  timeout,
+		endpoint: endpoint,
+		bucket:   config.Bucket,
+		signer: &s3Signer{
+			accessKeyID:     config.AccessKeyID,
+			secretAccessKey: config.SecretAccessKey,
+			region:          config.Region,
+		},
+	}
+	return store, nil
+}
+
+// Save spools a new laptop image to a temporary file to compute its checksum,
+// then uploads its blob if no other image has the same content, and its metadata
+func (store *S3ImageStore) Save(info *ImageInfo, imageData io.Reader) (string, error) {
+	imageID, err := uuid.NewRandom()
+	if err != nil {
+		return "", fmt.Errorf("cannot generate image id: %w", err)
+	}
+
+	file, err := os.CreateTemp("", "s3-upload-*"+tmpExt)
+	if err != nil {
+		return "", fmt.Errorf("cannot create image file: %w", err)
+	}
+	defer os.Remove(file.Name())
+	defer file.Close()
+
+	hash := sha256.New()
+	size, err := io.Copy(io.MultiWriter(file, hash), imageData)
+	if err != nil {
+		return "", fmt.Errorf("cannot write image to file: %w", err)
+	}
+
+	checksum := hex.EncodeToString(hash.Sum(nil))
+	other := &ImageInfo{
+		ID:         imageID.String(),
+		LaptopID:   info.LaptopID,
+		Type:       info.Type,
+		Size:       size,
+		Checksum:   checksum,
+		UploadedAt: time.Now().UTC(),
+		Uploader:   info.Uploader,
+		Width:      info.Width,
+		Height:     info.Height,
+		OriginalID: info.OriginalID,
+		Rendition:  info.Rendition,
+		Path:       s3BlobKey(checksum),
+	}
+
+	// the reference is written before the blob is checked, so that the blob is never deleted in between
+	refKey := s3RefKey(checksum, other.ID)
+	err = store.putObject(refKey, nil, 0, emptyPayloadHash)
+	if err != nil {
+		return "", err
+	}
+
+	// a failed save doesn't leave its blob behind unless another image points to it,
+	// nor its metadata, which may have been written even if the request failed
+	saved := false
+	defer func() {
+		if !saved {
+			store.deleteObject(s3MetadataKey(other.ID))
+			store.deleteObject(s3LaptopKey(other.LaptopID, other.ID))
+			store.deleteObject(refKey)
+			store.deleteUnreferencedBlob(checksum)
+		}
+	}()
+
+	exists, err := store.headObject(other.Path)
+	if err != nil {
+		return "", err
+	}
+
+	if !exists {
+		_, err = file.Seek(0, io.SeekStart)
+		if err != nil {
+			return "", fmt.Errorf("cannot rewind image file: %w", err)
+		}
+
+		// the checksum of the image is also the payload hash of the request
+		err = store.putObject(other.Path, file, size, checksum)
+		if err != nil {
+			return "", err
+		}
+	}
+
+	err = store.putObject(s3LaptopKey(other.LaptopID, other.ID), nil, 0, emptyPayloadHash)
+	if err != nil {
+		return "", err
+	}
+
+	metadata, err := json.Marshal(other)
+	if err != nil {
+		return "", fmt.Errorf("cannot marshal image metadata: %w", err)
+	}
+
+	metadataHash := sha256.Sum256(metadata)
+	err = store.putObject(s3MetadataKey(other.ID), bytes.NewReader(metadata), int64(len(metadata)), hex.EncodeToString(metadataHash[:]))
+	if err != nil {
+		return "", err
+	}
+
+	// a concurrent Delete of the last other image with the same content may have deleted the blob
+	exists, err = store.headObject(other.Path)
+	if err != nil {
+		return "", err
+	}
+	if !exists {
+		_, err = file.Seek(0, io.SeekStart)
+		if err != nil {
+			return "", fmt.Errorf("cannot rewind image file: %w", err)
+		}
+
+		err = store.putObject(other.Path, file, size, checksum)
+		if err != nil {
+			return "", err
+		}
+	}
+
+	saved = true
+	return other.ID, nil
+}
+
+// Open returns the info of an image and a reader of its blob
+func (store *S3ImageStore) Open(imageID string) (*ImageInfo, io.ReadCloser, error) {
+	info, err := store.readMetadata(imageID)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	res, err := store.do(http.MethodGet, info.Path, nil, nil, 0, emptyPayloadHash)
+	if err != nil {
+		return nil, nil, fmt.Errorf("cannot get image blob: %w", err)
+	}
+	return info, res.Body, nil
+}
+
+// Stat returns the info of an image from its metadata, without getting its blob
+func (store *S3ImageStore) Stat(imageID string) (*ImageInfo, error) {
+	return store.readMetadata(imageID)
+}
+
+// List returns the info of all images of a laptop, in upload order
+func (store *S3ImageStore) List(laptopID string) ([]*ImageInfo, error) {
+	prefix := s3LaptopKey(laptopID, "")
+	keys, err := store.listObjects(prefix)
+	if err != nil {
+		return nil, err
+	}
+
+	var images []*ImageInfo
+	for _, key := range keys {
+		info, err := store.readMetadata(strings.TrimPrefix(key, prefix))
+		if errors.Is(err, ErrNotFound) {
+			// the image is being saved or deleted
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		images = append(images, info)
+	}
+
+	sortImages(images)
+	return images, nil
+}
+
+// Delete deletes the metadata of an image, and its blob if no other image points to it
+func (store *S3ImageStore) Delete(imageID string) error {
+	info, err := store.readMetadata(imageID)
+	if err != nil {
+		return err
+	}
+
+	// the metadata is deleted first, so that the image disappears at once
+	for _, key := range []string{
+		s3MetadataKey(imageID),
+		s3LaptopKey(info.LaptopID, imageID),
+		s3RefKey(info.Checksum, imageID),
+	} {
+		err = store.deleteObject(key)
+		if err != nil {
+			return err
+		}
+	}
+
+	return store.deleteUnreferencedBlob(info.Checksum)
+}
+
+// CollectDangling deletes the images saved before the given time whose blob doesn't exist,
+// and returns the number of deleted images. The images are deleted with imageStore,
+// which is the S3ImageStore or a store that wraps it, such as the catalog image store.
+func (store *S3ImageStore) CollectDangling(before time.Time, imageStore ImageStore) (int, error) {
+	keys, err := store.listObjects(s3MetadataPrefix)
+	if err != nil {
+		return 0, err
+	}
+
+	count := 0
+	for _, key := range keys {
+		imageID := strings.TrimSuffix(strings.TrimPrefix(key, s3MetadataPrefix), ".json")
+		info, err := store.readMetadata(imageID)
+		if errors.Is(err, ErrNotFound) {
+			continue
+		}
+		if err != nil {
+			return count, err
+		}
+		if !info.UploadedAt.Before(before) {
+			continue
+		}
+
+		exists, err := store.headObject(info.Path)
+		if err != nil {
+			return count, err
+		}
+		if exists {
+			continue
+		}
+
+		err = imageStore.Delete(imageID)
+		if errors.Is(err, ErrNotFound) {
+			continue
+		}
+		if err != nil {
+			return count, fmt.Errorf("cannot delete dangling image %s: %w", imageID, err)
+		}
+		count++
+	}
+
+	return count, nil
+}
+
+// CollectDanglingImages deletes the dangling images of an S3ImageStore at every interval,
+// once they are older than the grace period, until the context is done
+func CollectDanglingImages(
+	ctx context.Context,
+	store *S3ImageStore,
+	imageStore ImageStore,
+	gracePeriod time.Duration,
+	interval time.Duration,
+) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case now := <-ticker.C:
+			count, err := store.CollectDangling(now.Add(-gracePeriod), imageStore)
+			if err != nil {
+				log.Printf("cannot collect dangling images: %v", err)
+			}
+			log.Printf("collected %d dangling images", count)
+		}
+	}
+}
+
+func (store *S3ImageStore) readMetadata(imageID string) (*ImageInfo, error) {
+	res, err := store.do(http.MethodGet, s3MetadataKey(imageID), nil, nil, 0, emptyPayloadHash)
+	if err != nil {
+		return nil, err
+	}
+	defer res.Body.Close()
+
+	info := &ImageInfo{}
+	err = json.NewDecoder(res.Body).Decode(info)
+	if err != nil {
+		return nil, fmt.Errorf("cannot parse image metadata of %s: %w", imageID, err)
+	}
+
+	info.Path = s3BlobKey(info.Checksum)
+	return info, nil
+}
+
+// deleteUnreferencedBlob deletes the blob with the given checksum if no image points to it anymore.
+// A concurrent Save of the same content writes its reference first, and uploads the blob again if it was deleted.
+func (store *S3ImageStore) deleteUnreferencedBlob(checksum string) error {
+	refs, err := store.listObjects(s3RefKey(checksum, ""))
+	if err != nil {
+		return err
+	}
+	if len(refs) > 0 {
+		return nil
+	}
+
+	return store.deleteObject(s3BlobKey(checksum))
+}
+
+func (store *S3ImageStore) putObject(key string, body io.Reader, size int64, payloadHash string) error {
+	res, err := store.do(http.MethodPut, key, nil, body, size, payloadHash)
+	if err != nil {
+		return err
+	}
+	return res.Body.Close()
+}
+
+// headObject returns true if the object exists
+func (store *S3ImageStore) headObject(key string) (bool, error) {
+	res, err := store.do(http.MethodHead, key, nil, nil, 0, emptyPayloadHash)
+	if errors.Is(err, ErrNotFound) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	return true, res.Body.Close()
+}
+
+// deleteObject deletes an object, which succeeds even if the object doesn't exist
+func (store *S3ImageStore) deleteObject(key string) error {
+	res, err := store.do(http.MethodDelete, key, nil, nil, 0, emptyPayloadHash)
+	if errors.Is(err, ErrNotFound) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	return res.Body.Close()
+}
+
+// listObjects returns the keys of all objects with the given prefix, following the continuation tokens
+func (store *S3ImageStore) listObjects(prefix string) ([]string, error) {
+	var keys []string
+	continuationToken := ""
+
+	for {
+		query := url.Values{}
+		query.Set("list-type", "2")
+		query.Set("prefix", prefix)
+		if continuationToken != "" {
+			query.Set("continuation-token", continuationToken)
+		}
+
+		res, err := store.do(http.MethodGet, "", query, nil, 0, emptyPayloadHash)
+		if err != nil {
+			return nil, err
+		}
+
+		result := struct {
+			Contents []struct {
+				Key string
+			}
+			IsTruncated           bool
+			NextContinuationToken string
+		}{}
+		err = xml.NewDecoder(res.Body).Decode(&result)
+		res.Body.Close()
+		if err != nil {
+			return nil, fmt.Errorf("cannot parse S3 object list: %w", err)
+		}
+
+		for _, object := range result.Contents {
+			keys = append(keys, object.Key)
+		}
+
+		if !result.IsTruncated {
+			return keys, nil
+		}
+		continuationToken = result.NextContinuationToken
+	}
+}
+
+// do sends a signed request for an object of the bucket, or for the bucket itself if key is empty.
+// It returns an error wrapping ErrNotFound for a 404 response, and an error for the other unsuccessful responses.
+// The request is canceled after the timeout of the store, or when the body of its response is closed.
+func (store *S3ImageStore) do(
+	method string,
+	key string,
+	query url.Values,
+	body io.Reader,
+	size int64,
+	payloadHash string,
+) (*http.Response, error) {
+	path := strings.TrimSuffix(store.endpoint.Path, "/") + "/" + store.bucket
+	if key != "" {
+		path += "/" + key
+	}
+
+	endpoint := *store.endpoint
+	endpoint.Path = path
+	endpoint.RawPath = uriEncode(path, false)
+	endpoint.RawQuery = canonicalQuery(query)
+
+	ctx, cancel := context.WithTimeout(context.Background(), store.timeout)
+	req, err := http.NewRequestWithContext(ctx, method, endpoint.String(), body)
+	if err != nil {
+		cancel()
+		return nil, fmt.Errorf("cannot create S3 request: %w", err)
+	}
+	if body != nil {
+		req.ContentLength = size
+	}
+
+	store.signer.sign(req, payloadHash, time.Now())
+
+	res, err := store.client.Do(req)
+	if err != nil {
+		cancel()
+		return nil, fmt.Errorf("cannot send S3 request: %w", err)
+	}
+
+	if res.StatusCode >= 200 && res.StatusCode < 300 {
+		res.Body = &s3ResponseBody{ReadCloser: res.Body, cancel: cancel}
+		return res, nil
+	}
+	defer cancel()
+	defer res.Body.Close()
+
+	if res.StatusCode == http.StatusNotFound {
+		return nil, fmt.Errorf("%w: S3 object %s", ErrNotFound, key)
+	}
+
+	s3Error := struct {
+		Code    string
+		Message string
+	}{}
+	xml.NewDecoder(io.LimitReader(res.Body, 4096)).Decode(&s3Error)
+	return nil, fmt.Errorf("S3 %s %s failed with status %d: %s %s", method, key, res.StatusCode, s3Error.Code, s3Error.Message)
+}
+
+// s3ResponseBody is the body of a successful S3 response, which cancels its request when it is closed
+type s3ResponseBody struct {
+	io.ReadCloser
+	cancel context.CancelFunc
+}
+
+func (body *s3ResponseBody) Close() error {
+	defer body.cancel()
+	return body.ReadCloser.Close()
+}
+
+func s3BlobKey(checksum string) string {
+	return blobFolder + "/" + checksum
+}
+
+const s3MetadataPrefix = "images/"
+
+func s3MetadataKey(imageID string) string {
+	return s3MetadataPrefix + imageID + ".json"
+}
+
+func s3LaptopKey(laptopID string, imageID string) string {
+	return "laptops/" + laptopID + "/" + imageID
+}
+
+func s3RefKey(checksum string, imageID string) string {
+	return "refs/" + checksum + "/" + imageID
+}
@@ -0,0 +1,506 @@
+package service_test
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/xml"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"gitlab.com/brucemig/pcbook/sample"
+	"gitlab.com/brucemig/pcbook/service"
+	"gitlab.com/brucemig/pcbook/storetest"
+)
+
+const (
+	testS3Bucket          = "images"
+	testS3Region          = "eu-west-3"
+	testS3AccessKeyID     = "access-key"
+	testS3SecretAccessKey = "secret-key"
+	testS3MaxKeys         = 2
+)
+
+func TestS3ImageStore(t *testing.T) {
+	t.Parallel()
+
+	storetest.TestImageStore(t, func(t *testing.T) service.ImageStore {
+		return newTestS3ImageStore(t, newFakeS3(t), testS3SecretAccessKey)
+	})
+}
+
+func TestS3ImageStoreDeduplication(t *testing.T) {
+	t.Parallel()
+
+	s3 := newFakeS3(t)
+	store := newTestS3ImageStore(t, s3, testS3SecretAccessKey)
+
+	laptopID := sample.NewLaptop().GetId()
+	imageID1, err := store.Save(&service.ImageInfo{LaptopID: laptopID, Type: ".png"}, strings.NewReader("same data"))
+	require.NoError(t, err)
+	imageID2, err := store.Save(&service.ImageInfo{LaptopID: laptopID, Type: ".png"}, strings.NewReader("same data"))
+	require.NoError(t, err)
+
+	checksum := sha256.Sum256([]byte("same data"))
+	blobKey := "blobs/" + hex.EncodeToString(checksum[:])
+	require.Equal(t, 1, s3.puts(blobKey))
+
+	require.NoError(t, store.Delete(imageID1))
+	require.True(t, s3.exists(blobKey))
+
+	_, imageData, err := store.Open(imageID2)
+	require.NoError(t, err)
+	data, err := io.ReadAll(imageData)
+	require.NoError(t, err)
+	require.NoError(t, imageData.Close())
+	require.Equal(t, "same data", string(data))
+
+	require.NoError(t, store.Delete(imageID2))
+	require.False(t, s3.exists(blobKey))
+	require.Empty(t, s3.keys())
+}
+
+func TestS3ImageStoreFailedSave(t *testing.T) {
+	t.Parallel()
+
+	s3 := newFakeS3(t)
+	store := newTestS3ImageStore(t, s3, testS3SecretAccessKey)
+
+	laptopID := sample.NewLaptop().GetId()
+	imageID, err := store.Save(&service.ImageInfo{LaptopID: laptopID, Type: ".png"}, strings.NewReader("shared data"))
+	require.NoError(t, err)
+
+	// the blobs are uploaded, but the metadata can't be written
+	s3.failPuts("images/")
+	_, err = store.Save(&service.ImageInfo{LaptopID: laptopID, Type: ".png"}, strings.NewReader("lost data"))
+	require.ErrorContains(t, err, "InternalError")
+	_, err = store.Save(&service.ImageInfo{LaptopID: laptopID, Type: ".png"}, strings.NewReader("shared data"))
+	require.ErrorContains(t, err, "InternalError")
+	s3.failPuts("")
+
+	// the blob of the first failed save is deleted, while the other one is kept for the image that points to it
+	checksum := sha256.Sum256([]byte("lost data"))
+	require.False(t, s3.exists("blobs/"+hex.EncodeToString(checksum[:])))
+
+	images, err := store.List(laptopID)
+	require.NoError(t, err)
+	require.Len(t, images, 1)
+
+	require.NoError(t, store.Delete(imageID))
+	require.Empty(t, s3.keys())
+}
+
+func TestS3ImageStoreCollectDangling(t *testing.T) {
+	t.Parallel()
+
+	catalog, err := service.NewCatalog(service.NewInMemoryEventLog())
+	require.NoError(t, err)
+
+	s3 := newFakeS3(t)
+	s3Store := newTestS3ImageStore(t, s3, testS3SecretAccessKey)
+	store := catalog.ImageStore(s3Store)
+
+	laptopID := sample.NewLaptop().GetId()
+	danglingID, err := store.Save(&service.ImageInfo{LaptopID: laptopID, Type: ".png"}, strings.NewReader("lost data"))
+	require.NoError(t, err)
+	imageID, err := store.Save(&service.ImageInfo{LaptopID: laptopID, Type: ".png"}, strings.NewReader("image data"))
+	require.NoError(t, err)
+
+	// the blob is lost as if a concurrent Delete of another image had deleted it
+	checksum := sha256.Sum256([]byte("lost data"))
+	s3.delete("blobs/" + hex.EncodeToString(checksum[:]))
+
+	count, err := s3Store.CollectDangling(time.Now().Add(-time.Hour), store)
+	require.NoError(t, err)
+	require.Zero(t, count)
+
+	count, err = s3Store.CollectDangling(time.Now().Add(time.Hour), store)
+	require.NoError(t, err)
+	require.Equal(t, 1, count)
+
+	_, err = store.Stat(danglingID)
+	require.ErrorIs(t, err, service.ErrNotFound)
+	_, err = store.Stat(imageID)
+	require.NoError(t, err)
+	require.Len(t, catalog.Images(laptopID), 1)
+}
+
+func TestS3ImageStoreCatalogDoesNotGetBlobs(t *testing.T) {
+	t.Parallel()
+
+	catalog, err := service.NewCatalog(service.NewInMemoryEventLog())
+	require.NoError(t, err)
+
+	s3 := newFakeS3(t)
+	store := catalog.ImageStore(newTestS3ImageStore(t, s3, testS3SecretAccessKey))
+
+	laptopID := sample.NewLaptop().GetId()
+	imageID, err := store.Save(&service.ImageInfo{LaptopID: laptopID, Type: ".png"}, strings.NewReader("image data"))
+	require.NoError(t, err)
+	require.Len(t, catalog.Images(laptopID), 1)
+	require.NoError(t, store.Delete(imageID))
+
+	checksum := sha256.Sum256([]byte("image data"))
+	require.Zero(t, s3.gets("blobs/"+hex.EncodeToString(checksum[:])))
+}
+
+func TestS3ImageStoreListPages(t *testing.T) {
+	t.Parallel()
+
+	store := newTestS3ImageStore(t, newFakeS3(t), testS3SecretAccessKey)
+
+	laptopID := sample.NewLaptop().GetId()
+	imageIDs := make([]string, 2*testS3MaxKeys+1)
+	for i := range imageIDs {
+		var err error
+		imageIDs[i], err = store.Save(&service.ImageInfo{LaptopID: laptopID, Type: ".jpg"}, strings.NewReader("image"))
+		require.NoError(t, err)
+	}
+
+	images, err := store.List(laptopID)
+	require.NoError(t, err)
+	require.Len(t, images, len(imageIDs))
+	for i, info := range images {
+		require.Equal(t, imageIDs[i], info.ID)
+	}
+}
+
+func TestS3ImageStoreInvalidSignature(t *testing.T) {
+	t.Parallel()
+
+	store := newTestS3ImageStore(t, newFakeS3(t), "wrong-secret-key")
+
+	_, err := store.Save(&service.ImageInfo{LaptopID: sample.NewLaptop().GetId(), Type: ".jpg"}, strings.NewReader("image"))
+	require.ErrorContains(t, err, "SignatureDoesNotMatch")
+}
+
+func TestS3ImageStoreTimeout(t *testing.T) {
+	t.Parallel()
+
+	// the endpoint accepts the requests but never answers them
+	stalled := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-stalled:
+		case <-r.Context().Done():
+		}
+	}))
+	t.Cleanup(server.Close)
+	t.Cleanup(func() { close(stalled) })
+
+	store, err := service.NewS3ImageStore(service.S3Config{
+		Endpoint:   server.URL,
+		Bucket:     testS3Bucket,
+		Region:     testS3Region,
+		HTTPClient: server.Client(),
+		Timeout:    100 * time.Millisecond,
+	})
+	require.NoError(t, err)
+
+	start := time.Now()
+	_, err = store.Save(&service.ImageInfo{LaptopID: sample.NewLaptop().GetId(), Type: ".jpg"}, strings.NewReader("image"))
+	require.ErrorIs(t, err, context.DeadlineExceeded)
+
+	_, err = store.Stat("image")
+	require.ErrorIs(t, err, context.DeadlineExceeded)
+	require.Less(t, time.Since(start), 5*time.Second)
+}
+
+func TestNewS3ImageStoreInvalidConfig(t *testing.T) {
+	t.Parallel()
+
+	_, err := service.NewS3ImageStore(service.S3Config{Endpoint: "localhost:9000", Bucket: "images", Region: "us-east-1"})
+	require.Error(t, err)
+
+	_, err = service.NewS3ImageStore(service.S3Config{Endpoint: "http://localhost:9000", Region: "us-east-1"})
+	require.Error(t, err)
+}
+
+// TestS3SignatureExample checks the signature of the fake S3 server against the GET object example of the AWS documentation
+func TestS3SignatureExample(t *testing.T) {
+	t.Parallel()
+
+	req, err := http.NewRequest(http.MethodGet, "https://examplebucket.s3.amazonaws.com/test.txt", nil)
+	require.NoError(t, err)
+	req.Header.Set("Range", "bytes=0-9")
+	req.Header.Set("X-Amz-Content-Sha256", "e3b0c44298fc1c149afbf4c8996fb92427ae41e4649b934ca495991b7852b855")
+	req.Header.Set("X-Amz-Date", "20130524T000000Z")
+
+	signature := testS3Signature(
+		req,
+		[]string{"host", "range", "x-amz-content-sha256", "x-amz-date"},
+		"20130524/us-east-1/s3/aws4_request",
+		"wJalrXUtnFEMI/K7MDENG/bPxRfiCYEXAMPLEKEY",
+	)
+	require.Equal(t, "f0e8bdb87c964420e857bd35b5d6ed310bd44f0170aba48dd91039c6036bdb41", signature)
+}
+
+func newTestS3ImageStore(t *testing.T, s3 *fakeS3, secretAccessKey string) *service.S3ImageStore {
+	store, err := service.NewS3ImageStore(service.S3Config{
+		Endpoint:        s3.server.URL,
+		Bucket:          testS3Bucket,
+		Region:          testS3Region,
+		AccessKeyID:     testS3AccessKeyID,
+		SecretAccessKey: secretAccessKey,
+		HTTPClient:      s3.server.Client(),
+	})
+	require.NoError(t, err)
+	return store
+}
+
+// fakeS3 is an in-process S3 server with a single bucket, that checks the request signatures
+type fakeS3 struct {
+	server *httptest.Server
+
+	mutex     sync.Mutex
+	objects   map[string][]byte
+	putCounts map[string]int
+	getCounts map[string]int
+	// failedPuts is the prefix of the keys whose PUT requests fail, if not empty
+	failedPuts string
+}
+
+func newFakeS3(t *testing.T) *fakeS3 {
+	s3 := &fakeS3{
+		objects:   make(map[string][]byte),
+		putCounts: make(map[string]int),
+		getCounts: make(map[string]int),
+	}
+	s3.server = httptest.NewServer(http.HandlerFunc(s3.serveHTTP))
+	t.Cleanup(s3.server.Close)
+	return s3
+}
+
+func (s3 *fakeS3) serveHTTP(w http.ResponseWriter, r *http.Request) {
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		writeS3Error(w, http.StatusBadRequest, "IncompleteBody")
+		return
+	}
+
+	if code := checkS3Signature(r, body); code != "" {
+		writeS3Error(w, http.StatusForbidden, code)
+		return
+	}
+
+	bucket, key, _ := strings.Cut(strings.TrimPrefix(r.URL.Path, "/"), "/")
+	if bucket != testS3Bucket {
+		writeS3Error(w, http.StatusNotFound, "NoSuchBucket")
+		return
+	}
+
+	s3.mutex.Lock()
+	defer s3.mutex.Unlock()
+
+	switch {
+	case r.Method == http.MethodGet && key == "":
+		s3.listObjects(w, r.URL.Query())
+	case r.Method == http.MethodPut && s3.failedPuts != "" && strings.HasPrefix(key, s3.failedPuts):
+		writeS3Error(w, http.StatusInternalServerError, "InternalError")
+	case r.Method == http.MethodPut:
+		s3.objects[key] = body
+		s3.putCounts[key]++
+	case r.Method == http.MethodGet || r.Method == http.MethodHead:
+		data, ok := s3.objects[key]
+		if !ok {
+			writeS3Error(w, http.StatusNotFound, "NoSuchKey")
+			return
+		}
+		if r.Method == http.MethodGet {
+			s3.getCounts[key]++
+			w.Write(data)
+		}
+	case r.Method == http.MethodDelete:
+		delete(s3.objects, key)
+		w.WriteHeader(http.StatusNoContent)
+	default:
+		writeS3Error(w, http.StatusMethodNotAllowed, "MethodNotAllowed")
+	}
+}
+
+// listObjects writes a ListObjectsV2 response of at most testS3MaxKeys keys.
+// The caller must hold the mutex.
+func (s3 *fakeS3) listObjects(w http.ResponseWriter, query url.Values) {
+	if query.Get("list-type") != "2" {
+		writeS3Error(w, http.StatusBadRequest, "InvalidArgument")
+		return
+	}
+
+	var keys []string
+	for key := range s3.objects {
+		if strings.HasPrefix(key, query.Get("prefix")) && key > query.Get("continuation-token") {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+
+	type object struct {
+		Key string
+	}
+	result := struct {
+		XMLName               xml.Name `xml:"ListBucketResult"`
+		Contents              []object
+		IsTruncated           bool
+		NextContinuationToken string `xml:",omitempty"`
+	}{}
+
+	if len(keys) > testS3MaxKeys {
+		keys = keys[:testS3MaxKeys]
+		result.IsTruncated = true
+		result.NextContinuationToken = keys[len(keys)-1]
+	}
+	for _, key := range keys {
+		result.Contents = append(result.Contents, object{Key: key})
+	}
+
+	w.Header().Set("Content-Type", "application/xml")
+	xml.NewEncoder(w).Encode(result)
+}
+
+func (s3 *fakeS3) failPuts(prefix string) {
+	s3.mutex.Lock()
+	defer s3.mutex.Unlock()
+	s3.failedPuts = prefix
+}
+
+func (s3 *fakeS3) puts(key string) int {
+	s3.mutex.Lock()
+	defer s3.mutex.Unlock()
+	return s3.putCounts[key]
+}
+
+func (s3 *fakeS3) gets(key string) int {
+	s3.mutex.Lock()
+	defer s3.mutex.Unlock()
+	return s3.getCounts[key]
+}
+
+func (s3 *fakeS3) delete(key string) {
+	s3.mutex.Lock()
+	defer s3.mutex.Unlock()
+	delete(s3.objects, key)
+}
+
+func (s3 *fakeS3) exists(key string) bool {
+	s3.mutex.Lock()
+	defer s3.mutex.Unlock()
+	_, ok := s3.objects[key]
+	return ok
+}
+
+func (s3 *fakeS3) keys() []string {
+	s3.mutex.Lock()
+	defer s3.mutex.Unlock()
+
+	var keys []string
+	for key := range s3.objects {
+		keys = append(keys, key)
+	}
+	return keys
+}
+
+func writeS3Error(w http.ResponseWriter, statusCode int, code string) {
+	w.Header().Set("Content-Type", "application/xml")
+	w.WriteHeader(statusCode)
+	xml.NewEncoder(w).Encode(struct {
+		XMLName xml.Name `xml:"Error"`
+		Code    string
+	}{Code: code})
+}
+
+// checkS3Signature returns the S3 error code of a request with an invalid signature or payload hash, or an empty string
+func checkS3Signature(r *http.Request, body []byte) string {
+	payloadHash := sha256.Sum256(body)
+	if r.Header.Get("X-Amz-Content-Sha256") != hex.EncodeToString(payloadHash[:]) {
+		return "XAmzContentSHA256Mismatch"
+	}
+
+	authorization, ok := strings.CutPrefix(r.Header.Get("Authorization"), "AWS4-HMAC-SHA256 ")
+	if !ok {
+		return "AccessDenied"
+	}
+
+	fields := make(map[string]string)
+	for _, field := range strings.Split(authorization, ", ") {
+		name, value, _ := strings.Cut(field, "=")
+		fields[name] = value
+	}
+
+	accessKeyID, scope, _ := strings.Cut(fields["Credential"], "/")
+	if accessKeyID != testS3AccessKeyID {
+		return "InvalidAccessKeyId"
+	}
+
+	amzDate, err := time.Parse("20060102T150405Z", r.Header.Get("X-Amz-Date"))
+	if err != nil || scope != amzDate.Format("20060102")+"/"+testS3Region+"/s3/aws4_request" {
+		return "AuthorizationHeaderMalformed"
+	}
+
+	signedHeaders := strings.Split(fields["SignedHeaders"], ";")
+	signature := testS3Signature(r, signedHeaders, scope, testS3SecretAccessKey)
+	if !hmac.Equal([]byte(signature), []byte(fields["Signature"])) {
+		return "SignatureDoesNotMatch"
+	}
+	return ""
+}
+
+// testS3Signature computes the Signature Version 4 of a request, independently of the signer of the image store
+func testS3Signature(r *http.Request, signedHeaders []string, scope string, secretAccessKey string) string {
+	query := r.URL.Query()
+	names := make([]string, 0, len(query))
+	for name := range query {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	params := make([]string, 0, len(names))
+	for _, name := range names {
+		params = append(params, testS3Escape(name)+"="+testS3Escape(query.Get(name)))
+	}
+
+	headers := make([]string, len(signedHeaders))
+	for i, name := range signedHeaders {
+		value := r.Header.Get(name)
+		if name == "host" {
+			value = r.Host
+			if value == "" {
+				value = r.URL.Host
+			}
+		}
+		headers[i] = name + ":" + strings.TrimSpace(value) + "\n"
+	}
+
+	canonicalRequest := r.Method + "\n" +
+		r.URL.EscapedPath() + "\n" +
+		strings.Join(params, "&") + "\n" +
+		strings.Join(headers, "") + "\n" +
+		strings.Join(signedHeaders, ";") + "\n" +
+		r.Header.Get("X-Amz-Content-Sha256")
+
+	canonicalRequestHash := sha256.Sum256([]byte(canonicalRequest))
+	stringToSign := "AWS4-HMAC-SHA256\n" +
+		r.Header.Get("X-Amz-Date") + "\n" +
+		scope + "\n" +
+		hex.EncodeToString(canonicalRequestHash[:])
+
+	key := []byte("AWS4" + secretAccessKey)
+	for _, part := range append(strings.Split(scope, "/"), stringToSign) {
+		mac := hmac.New(sha256.New, key)
+		mac.Write([]byte(part))
+		key = mac.Sum(nil)
+	}
+	return hex.EncodeToString(key)
+}
+
+func testS3Escape(value string) string {
+	return strings.ReplaceAll(url.QueryEscape(value), "+", "%20")
+}
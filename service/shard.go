@@ -0,0 +1,13 @@
+package service
+
+import "hash/fnv"
+
+// defaultShardCount is the number of shards of the in-memory stores
+const defaultShardCount = 16
+
+// shardIndex returns the shard of a record ID
+func shardIndex(id string, shardCount int) int {
+	hash := fnv.New32a()
+	hash.Write([]byte(id))
+	return int(hash.Sum32() % uint32(shardCount))
+}
@@ -0,0 +1,129 @@
+package service_test
+
+import (
+	"testing"
+	"time"
+
+	"gitlab.com/brucemig/pcbook/service"
+	"gitlab.com/brucemig/pcbook/storetest"
+)
+
+func TestInMemoryLaptopStore(t *testing.T) {
+	t.Parallel()
+
+	storetest.TestLaptopStore(t, func(t *testing.T) service.LaptopStore {
+		return service.NewInMemoryLaptopStore()
+	})
+}
+
+func TestCatalogLaptopStore(t *testing.T) {
+	t.Parallel()
+
+	storetest.TestLaptopStore(t, func(t *testing.T) service.LaptopStore {
+		return newTestCatalog(t).LaptopStore()
+	})
+}
+
+func TestInMemoryRatingStore(t *testing.T) {
+	t.Parallel()
+
+	storetest.TestRatingStore(t, func(t *testing.T) service.RatingStore {
+		return service.NewInMemoryRatingStore()
+	})
+}
+
+func TestCatalogRatingStore(t *testing.T) {
+	t.Parallel()
+
+	storetest.TestRatingStore(t, func(t *testing.T) service.RatingStore {
+		return newTestCatalog(t).RatingStore()
+	})
+}
+
+func TestDiskImageStore(t *testing.T) {
+	t.Parallel()
+
+	storetest.TestImageStore(t, func(t *testing.T) service.ImageStore {
+		return newTestDiskImageStore(t)
+	})
+}
+
+func TestCatalogImageStore(t *testing.T) {
+	t.Parallel()
+
+	storetest.TestImageStore(t, func(t *testing.T) service.ImageStore {
+		return newTestCatalog(t).ImageStore(newTestDiskImageStore(t))
+	})
+}
+
+func TestInMemoryGalleryStore(t *testing.T) {
+	t.Parallel()
+
+	storetest.TestGalleryStore(t, func(t *testing.T) service.GalleryStore {
+		return service.NewInMemoryGalleryStore()
+	})
+}
+
+func TestCatalogGalleryStore(t *testing.T) {
+	t.Parallel()
+
+	storetest.TestGalleryStore(t, func(t *testing.T) service.GalleryStore {
+		return newTestCatalog(t).GalleryStore()
+	})
+}
+
+func TestInMemoryReviewStore(t *testing.T) {
+	t.Parallel()
+
+	storetest.TestReviewStore(t, func(t *testing.T) service.ReviewStore {
+		return service.NewInMemoryReviewStore()
+	})
+}
+
+func TestCatalogReviewStore(t *testing.T) {
+	t.Parallel()
+
+	storetest.TestReviewStore(t, func(t *testing.T) service.ReviewStore {
+		return newTestCatalog(t).ReviewStore()
+	})
+}
+
+func TestInMemoryQuarantineStore(t *testing.T) {
+	t.Parallel()
+
+	storetest.TestQuarantineStore(t, func(t *testing.T) service.QuarantineStore {
+		return service.NewInMemoryQuarantineStore()
+	})
+}
+
+func TestCatalogQuarantineStore(t *testing.T) {
+	t.Parallel()
+
+	storetest.TestQuarantineStore(t, func(t *testing.T) service.QuarantineStore {
+		return newTestCatalog(t).QuarantineStore()
+	})
+}
+
+func TestInMemoryUserStore(t *testing.T) {
+	t.Parallel()
+
+	storetest.TestUserStore(t, func(t *testing.T) service.UserStore {
+		return service.NewInMemoryUserStore()
+	})
+}
+
+func newTestCatalog(t *testing.T) *service.Catalog {
+	catalog, err := service.NewCatalog(service.NewInMemoryEventLog())
+	if err != nil {
+		t.Fatal(err)
+	}
+	return catalog
+}
+
+func TestCachedLaptopStore(t *testing.T) {
+	t.Parallel()
+
+	storetest.TestLaptopStore(t, func(t *testing.T) service.LaptopStore {
+		return service.NewCachedLaptopStore(service.NewInMemoryLaptopStore(), 4, time.Minute)
+	})
+}
@@ -0,0 +1,340 @@
+package service
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"hash"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// ErrInvalidOffset is returned when an upload chunk doesn't start at or before the committed offset
+var ErrInvalidOffset = errors.New("invalid offset")
+
+// ErrSizeMismatch is returned when a completed upload doesn't have the expected size
+var ErrSizeMismatch = errors.New("size mismatch")
+
+// ErrChecksumMismatch is returned when a completed upload doesn't have the expected checksum
+var ErrChecksumMismatch = errors.New("checksum mismatch")
+
+// ErrTooManySessions is returned when an upload session is started while too many sessions are open
+var ErrTooManySessions = errors.New("too many upload sessions")
+
+// extension of the files that hold the partial data of the upload sessions
+const uploadSessionExt = ".upload"
+
+// UploadSessionStore is an interface to store the partial data of resumable uploads
+type UploadSessionStore interface {
+	// Start starts a new upload session for an image and returns it.
+	// The laptop ID, image type, uploader, and the expected size and checksum of the image if any, are taken from info.
+	// It returns ErrTooManySessions if maxSessions sessions are already open,
+	// or maxUserSessions sessions of the same uploader. The anonymous sessions are only limited by maxSessions,
+	// and a zero limit is no limit.
+	Start(info *ImageInfo, maxSessions int, maxUserSessions int) (*UploadSession, error)
+	// Write writes a chunk at the given offset and returns the new committed offset.
+	// Writing a chunk again is a no-op, so that a client can safely retry it.
+	// It returns ErrInvalidOffset if the chunk starts after the committed offset,
+	// and ErrNotFound if the session doesn't exist or has expired.
+	Write(uploadID string, offset int64, chunk []byte) (int64, error)
+	// Find returns the session, or ErrNotFound if it doesn't exist or has expired
+	Find(uploadID string) (*UploadSession, error)
+	// Complete checks the size and checksum of the uploaded data, then calls save with the session and a reader of its data,
+	// and ends the session once save succeeds. The session is kept if the checks or save fail,
+	// so that the client can resume or retry the upload. The error of save is returned as is.
+	Complete(uploadID string, size int64, checksum string, save func(session *UploadSession, data io.Reader) error) error
+	// Collect deletes the sessions that have expired at the given time and returns their number
+	Collect(now time.Time) int
+}
+
+// UploadSession is the state of a resumable upload
+type UploadSession struct {
+	ID              string
+	Info            *ImageInfo
+	CommittedOffset int64
+	ExpiresAt       time.Time
+}
+
+// InMemoryUploadSessionStore keeps the upload sessions in memory, and spools their data to temporary files.
+// A session expires when it hasn't received any chunk for the TTL.
+type InMemoryUploadSessionStore struct {
+	mutex    sync.Mutex
+	ttl      time.Duration
+	folder   string
+	sessions map[string]*uploadSession
+}
+
+// uploadSession is an open session. Its mutex serializes the writes to its file,
+// which are made without the store mutex, so that the uploads don't wait for each other.
+type uploadSession struct {
+	UploadSession
+	mutex  sync.Mutex
+	file   *os.File
+	hash   hash.Hash
+	closed bool
+}
+
+// UploadSessionStoreOption configures optional settings of an InMemoryUploadSessionStore
+type UploadSessionStoreOption func(store *InMemoryUploadSessionStore)
+
+// WithUploadFolder sets the folder of the files that hold the partial data of the sessions,
+// the default temporary directory if not set. The session files left in the folder by a previous process are deleted.
+func WithUploadFolder(folder string) UploadSessionStoreOption {
+	return func(store *InMemoryUploadSessionStore) {
+		store.folder = folder
+	}
+}
+
+// NewInMemoryUploadSessionStore returns a new InMemoryUploadSessionStore
+func NewInMemoryUploadSessionStore(ttl time.Duration, options ...UploadSessionStoreOption) *InMemoryUploadSessionStore {
+	store := &InMemoryUploadSessionStore{
+		ttl:      ttl,
+		sessions: make(map[string]*uploadSession),
+	}
+	for _, option := range options {
+		option(store)
+	}
+
+	// the sessions don't survive a restart, so their files are stale
+	if store.folder != "" {
+		files, _ := filepath.Glob(filepath.Join(store.folder, "*"+uploadSessionExt))
+		for _, file := range files {
+			err := os.Remove(file)
+			if err != nil {
+				log.Printf("cannot delete stale upload session file: %v", err)
+			}
+		}
+	}
+	return store
+}
+
+// Start starts a new upload session for an image
+func (store *InMemoryUploadSessionStore) Start(info *ImageInfo, maxSessions int, maxUserSessions int) (*UploadSession, error) {
+	uploadID, err := uuid.NewRandom()
+	if err != nil {
+		return nil, fmt.Errorf("cannot generate upload id: %w", err)
+	}
+
+	store.mutex.Lock()
+	defer store.mutex.Unlock()
+
+	// the expired sessions that are not collected yet are not open anymore
+	now := time.Now()
+	sessions := 0
+	userSessions := 0
+	for _, session := range store.sessions {
+		if now.Before(session.ExpiresAt) {
+			sessions++
+			if info.Uploader != "" && session.Info.Uploader == info.Uploader {
+				userSessions++
+			}
+		}
+	}
+	if maxSessions > 0 && sessions >= maxSessions {
+		return nil, fmt.Errorf("%w: %d sessions are open", ErrTooManySessions, sessions)
+	}
+	if maxUserSessions > 0 && userSessions >= maxUserSessions {
+		return nil, fmt.Errorf("%w: user %s has %d open sessions", ErrTooManySessions, info.Uploader, userSessions)
+	}
+
+	if store.folder != "" {
+		err = os.MkdirAll(store.folder, 0755)
+		if err != nil {
+			return nil, fmt.Errorf("cannot create upload folder: %w", err)
+		}
+	}
+
+	file, err := os.CreateTemp(store.folder, "session-*"+uploadSessionExt)
+	if err != nil {
+		return nil, fmt.Errorf("cannot create upload file: %w", err)
+	}
+
+	session := &uploadSession{
+		UploadSession: UploadSession{
+			ID: uploadID.String(),
+			Info: &ImageInfo{
+				LaptopID: info.LaptopID,
+				Type:     info.Type,
+				Uploader: info.Uploader,
+				Size:     info.Size,
+				Checksum: info.Checksum,
+			},
+			ExpiresAt: now.Add(store.ttl),
+		},
+		file: file,
+		hash: sha256.New(),
+	}
+
+	store.sessions[session.ID] = session
+	return session.clone(), nil
+}
+
+// Write writes a chunk at the given offset and returns the new committed offset
+func (store *InMemoryUploadSessionStore) Write(uploadID string, offset int64, chunk []byte) (int64, error) {
+	session, err := store.lock(uploadID)
+	if err != nil {
+		return 0, err
+	}
+	defer session.mutex.Unlock()
+
+	committed := session.CommittedOffset
+	if offset > committed {
+		return committed, fmt.Errorf("%w: chunk starts at %d after committed offset %d", ErrInvalidOffset, offset, committed)
+	}
+
+	// only the part of the chunk after the committed offset is new
+	if end := offset + int64(len(chunk)); end > committed {
+		n, err := session.file.Write(chunk[committed-offset:])
+		session.hash.Write(chunk[committed-offset : committed-offset+int64(n)])
+		session.CommittedOffset += int64(n)
+		if err != nil {
+			return session.CommittedOffset, fmt.Errorf("cannot write upload file: %w", err)
+		}
+	}
+
+	store.mutex.Lock()
+	session.ExpiresAt = time.Now().Add(store.ttl)
+	store.mutex.Unlock()
+
+	return session.CommittedOffset, nil
+}
+
+// Find returns the session
+func (store *InMemoryUploadSessionStore) Find(uploadID string) (*UploadSession, error) {
+	session, err := store.lock(uploadID)
+	if err != nil {
+		return nil, err
+	}
+	defer session.mutex.Unlock()
+
+	store.mutex.Lock()
+	defer store.mutex.Unlock()
+	return session.clone(), nil
+}
+
+// Complete checks the size and checksum of the uploaded data, then saves it, and ends the session with its file.
+// The writes to the session wait until the data is saved.
+func (store *InMemoryUploadSessionStore) Complete(
+	uploadID string,
+	size int64,
+	checksum string,
+	save func(session *UploadSession, data io.Reader) error,
+) error {
+	session, err := store.lock(uploadID)
+	if err != nil {
+		return err
+	}
+	defer session.mutex.Unlock()
+
+	if session.CommittedOffset != size {
+		return fmt.Errorf("%w: uploaded %d bytes, expected %d", ErrSizeMismatch, session.CommittedOffset, size)
+	}
+
+	// the checksum is hexadecimal, in any case
+	if actual := hex.EncodeToString(session.hash.Sum(nil)); !strings.EqualFold(actual, checksum) {
+		return fmt.Errorf("%w: uploaded data has checksum %s, expected %s", ErrChecksumMismatch, actual, checksum)
+	}
+
+	store.mutex.Lock()
+	completed := session.clone()
+	store.mutex.Unlock()
+
+	err = save(completed, io.NewSectionReader(session.file, 0, session.CommittedOffset))
+	if err != nil {
+		return err
+	}
+
+	store.mutex.Lock()
+	delete(store.sessions, uploadID)
+	store.mutex.Unlock()
+
+	session.close()
+	return nil
+}
+
+// Collect deletes the sessions that have expired at the given time, with their files
+func (store *InMemoryUploadSessionStore) Collect(now time.Time) int {
+	store.mutex.Lock()
+	var expired []*uploadSession
+	for uploadID, session := range store.sessions {
+		if !now.Before(session.ExpiresAt) {
+			delete(store.sessions, uploadID)
+			expired = append(expired, session)
+		}
+	}
+	store.mutex.Unlock()
+
+	// a write in progress finishes before the file of its session is deleted
+	for _, session := range expired {
+		session.mutex.Lock()
+		session.close()
+		session.mutex.Unlock()
+	}
+	return len(expired)
+}
+
+// lock returns the session if it hasn't expired yet, with its mutex locked
+func (store *InMemoryUploadSessionStore) lock(uploadID string) (*uploadSession, error) {
+	store.mutex.Lock()
+	session := store.sessions[uploadID]
+	if session == nil || !time.Now().Before(session.ExpiresAt) {
+		store.mutex.Unlock()
+		return nil, ErrNotFound
+	}
+	store.mutex.Unlock()
+
+	session.mutex.Lock()
+	// the session may have been completed or collected in between
+	if session.closed {
+		session.mutex.Unlock()
+		return nil, ErrNotFound
+	}
+	return session, nil
+}
+
+// clone returns a copy of the session state.
+// The caller must hold the store mutex, which protects the expiry time.
+func (session *uploadSession) clone() *UploadSession {
+	other := session.UploadSession
+	info := *session.Info
+	other.Info = &info
+	return &other
+}
+
+// close ends the session, and deletes its file unless it was already ended.
+// The caller must hold the session mutex.
+func (session *uploadSession) close() {
+	if session.closed {
+		return
+	}
+
+	session.closed = true
+	session.file.Close()
+	os.Remove(session.file.Name())
+}
+
+// CollectUploadSessions deletes the expired sessions of the store at every interval, until the context is done
+func CollectUploadSessions(ctx context.Context, store UploadSessionStore, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case now := <-ticker.C:
+			if count := store.Collect(now); count > 0 {
+				log.Printf("collected %d expired upload sessions", count)
+			}
+		}
+	}
+}
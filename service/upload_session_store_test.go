@@ -0,0 +1,157 @@
+package service_test
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"gitlab.com/brucemig/pcbook/service"
+)
+
+func TestInMemoryUploadSessionStore(t *testing.T) {
+	t.Parallel()
+
+	folder := t.TempDir()
+	store := service.NewInMemoryUploadSessionStore(time.Hour, service.WithUploadFolder(folder))
+
+	session, err := store.Start(&service.ImageInfo{LaptopID: "laptop", Type: ".png", Uploader: "admin"}, 0, 0)
+	require.NoError(t, err)
+	require.NotEmpty(t, session.ID)
+	require.Zero(t, session.CommittedOffset)
+
+	committed, err := store.Write(session.ID, 0, []byte("hello "))
+	require.NoError(t, err)
+	require.EqualValues(t, 6, committed)
+
+	// retrying a chunk is a no-op, and overlapping chunks only append their new part
+	committed, err = store.Write(session.ID, 0, []byte("hello "))
+	require.NoError(t, err)
+	require.EqualValues(t, 6, committed)
+
+	committed, err = store.Write(session.ID, 3, []byte("lo wor"))
+	require.NoError(t, err)
+	require.EqualValues(t, 9, committed)
+
+	_, err = store.Write(session.ID, 10, []byte("d"))
+	require.ErrorIs(t, err, service.ErrInvalidOffset)
+
+	_, err = store.Write(session.ID, 9, []byte("ld"))
+	require.NoError(t, err)
+
+	found, err := store.Find(session.ID)
+	require.NoError(t, err)
+	require.EqualValues(t, 11, found.CommittedOffset)
+	require.Equal(t, "admin", found.Info.Uploader)
+
+	checksum := sha256.Sum256([]byte("hello world"))
+
+	// the session is kept when the checks or the save fail
+	save := func(session *service.UploadSession, data io.Reader) error {
+		t.Fatal("the data is saved before the checks")
+		return nil
+	}
+	err = store.Complete(session.ID, 12, hex.EncodeToString(checksum[:]), save)
+	require.ErrorIs(t, err, service.ErrSizeMismatch)
+
+	err = store.Complete(session.ID, 11, "bad checksum", save)
+	require.ErrorIs(t, err, service.ErrChecksumMismatch)
+
+	saveErr := errors.New("cannot save")
+	err = store.Complete(session.ID, 11, hex.EncodeToString(checksum[:]), func(session *service.UploadSession, data io.Reader) error {
+		return saveErr
+	})
+	require.ErrorIs(t, err, saveErr)
+
+	var data []byte
+	err = store.Complete(session.ID, 11, strings.ToUpper(hex.EncodeToString(checksum[:])), func(session *service.UploadSession, imageData io.Reader) error {
+		require.Equal(t, "laptop", session.Info.LaptopID)
+		data, err = io.ReadAll(imageData)
+		return err
+	})
+	require.NoError(t, err)
+	require.Equal(t, "hello world", string(data))
+
+	_, err = store.Find(session.ID)
+	require.ErrorIs(t, err, service.ErrNotFound)
+
+	// the data is spooled to a file of the upload folder, which is deleted with the session
+	files, err := os.ReadDir(folder)
+	require.NoError(t, err)
+	require.Empty(t, files)
+}
+
+func TestInMemoryUploadSessionStoreLimits(t *testing.T) {
+	t.Parallel()
+
+	store := service.NewInMemoryUploadSessionStore(time.Hour)
+
+	for i := 0; i < 2; i++ {
+		_, err := store.Start(&service.ImageInfo{LaptopID: "laptop", Type: ".png", Uploader: "user1"}, 3, 2)
+		require.NoError(t, err)
+	}
+
+	_, err := store.Start(&service.ImageInfo{LaptopID: "laptop", Type: ".png", Uploader: "user1"}, 3, 2)
+	require.ErrorIs(t, err, service.ErrTooManySessions)
+
+	session, err := store.Start(&service.ImageInfo{LaptopID: "laptop", Type: ".png", Uploader: "user2"}, 3, 2)
+	require.NoError(t, err)
+
+	_, err = store.Start(&service.ImageInfo{LaptopID: "laptop", Type: ".png", Uploader: "user3"}, 3, 2)
+	require.ErrorIs(t, err, service.ErrTooManySessions)
+
+	// a completed session is not open anymore
+	checksum := sha256.Sum256(nil)
+	err = store.Complete(session.ID, 0, hex.EncodeToString(checksum[:]), func(*service.UploadSession, io.Reader) error {
+		return nil
+	})
+	require.NoError(t, err)
+
+	_, err = store.Start(&service.ImageInfo{LaptopID: "laptop", Type: ".png", Uploader: "user3"}, 3, 2)
+	require.NoError(t, err)
+}
+
+func TestInMemoryUploadSessionStoreExpiry(t *testing.T) {
+	t.Parallel()
+
+	// the files of the sessions of a previous process are stale
+	folder := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(folder, "session-1.upload"), []byte("stale"), 0644))
+	store := service.NewInMemoryUploadSessionStore(time.Minute, service.WithUploadFolder(folder))
+	files, err := os.ReadDir(folder)
+	require.NoError(t, err)
+	require.Empty(t, files)
+
+	session, err := store.Start(&service.ImageInfo{LaptopID: "laptop", Type: ".png"}, 0, 0)
+	require.NoError(t, err)
+
+	_, err = store.Write(session.ID, 0, []byte("data"))
+	require.NoError(t, err)
+
+	require.Zero(t, store.Collect(time.Now()))
+	found, err := store.Find(session.ID)
+	require.NoError(t, err)
+	require.Equal(t, 1, store.Collect(found.ExpiresAt))
+
+	_, err = store.Write(session.ID, 0, []byte("data"))
+	require.ErrorIs(t, err, service.ErrNotFound)
+
+	files, err = os.ReadDir(folder)
+	require.NoError(t, err)
+	require.Empty(t, files)
+
+	// expired sessions can't be used even before they are collected
+	store = service.NewInMemoryUploadSessionStore(0)
+
+	session, err = store.Start(&service.ImageInfo{LaptopID: "laptop", Type: ".png"}, 0, 0)
+	require.NoError(t, err)
+
+	_, err = store.Find(session.ID)
+	require.ErrorIs(t, err, service.ErrNotFound)
+}
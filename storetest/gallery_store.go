@@ -0,0 +1,124 @@
+package storetest
+
+import (
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gitlab.com/brucemig/pcbook/sample"
+	"gitlab.com/brucemig/pcbook/service"
+)
+
+// TestGalleryStore runs the conformance suite against the gallery stores returned by newStore.
+// newStore must return a new empty store every time it is called.
+func TestGalleryStore(t *testing.T, newStore func(t *testing.T) service.GalleryStore) {
+	t.Run("add_and_remove", func(t *testing.T) {
+		t.Parallel()
+		store := newStore(t)
+
+		laptopID := sample.NewLaptop().GetId()
+
+		images, err := store.Images(laptopID)
+		require.NoError(t, err)
+		require.Empty(t, images)
+
+		require.NoError(t, store.Add(laptopID, "image1", 3))
+		require.NoError(t, store.Add(laptopID, "image2", 3))
+		require.NoError(t, store.Add(laptopID, "image3", 3))
+		require.ErrorIs(t, store.Add(laptopID, "image4", 3), service.ErrGalleryFull)
+		require.NoError(t, store.Add(sample.NewLaptop().GetId(), "other", 3))
+
+		images, err = store.Images(laptopID)
+		require.NoError(t, err)
+		require.Equal(t, []string{"image1", "image2", "image3"}, images)
+
+		require.NoError(t, store.Remove(laptopID, "image2"))
+		require.ErrorIs(t, store.Remove(laptopID, "image2"), service.ErrNotFound)
+
+		images, err = store.Images(laptopID)
+		require.NoError(t, err)
+		require.Equal(t, []string{"image1", "image3"}, images)
+	})
+
+	t.Run("set_primary", func(t *testing.T) {
+		t.Parallel()
+		store := newStore(t)
+
+		laptopID := sample.NewLaptop().GetId()
+		for _, imageID := range []string{"image1", "image2", "image3"} {
+			require.NoError(t, store.Add(laptopID, imageID, 10))
+		}
+
+		require.NoError(t, store.SetPrimary(laptopID, "image3"))
+		require.ErrorIs(t, store.SetPrimary(laptopID, "unknown"), service.ErrNotFound)
+
+		images, err := store.Images(laptopID)
+		require.NoError(t, err)
+		require.Equal(t, []string{"image3", "image1", "image2"}, images)
+	})
+
+	t.Run("reorder", func(t *testing.T) {
+		t.Parallel()
+		store := newStore(t)
+
+		laptopID := sample.NewLaptop().GetId()
+		for _, imageID := range []string{"image1", "image2", "image3"} {
+			require.NoError(t, store.Add(laptopID, imageID, 10))
+		}
+
+		require.ErrorIs(t, store.Reorder(laptopID, []string{"image1", "image2"}), service.ErrInvalidOrder)
+		require.ErrorIs(t, store.Reorder(laptopID, []string{"image1", "image1", "image2"}), service.ErrInvalidOrder)
+		require.ErrorIs(t, store.Reorder(laptopID, []string{"image1", "image2", "unknown"}), service.ErrInvalidOrder)
+		require.NoError(t, store.Reorder(laptopID, []string{"image2", "image3", "image1"}))
+
+		images, err := store.Images(laptopID)
+		require.NoError(t, err)
+		require.Equal(t, []string{"image2", "image3", "image1"}, images)
+	})
+
+	t.Run("copy_isolation", func(t *testing.T) {
+		t.Parallel()
+		store := newStore(t)
+
+		laptopID := sample.NewLaptop().GetId()
+		require.NoError(t, store.Add(laptopID, "image1", 10))
+
+		images, err := store.Images(laptopID)
+		require.NoError(t, err)
+		images[0] = "changed"
+
+		images, err = store.Images(laptopID)
+		require.NoError(t, err)
+		require.Equal(t, []string{"image1"}, images)
+	})
+
+	t.Run("concurrent_access", func(t *testing.T) {
+		t.Parallel()
+		store := newStore(t)
+
+		laptopID := sample.NewLaptop().GetId()
+
+		var wg sync.WaitGroup
+		for i := 0; i < concurrency; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+
+				// only half of the images fit in the gallery
+				err := store.Add(laptopID, sample.NewLaptop().GetId(), concurrency/2)
+				if err != nil {
+					assert.ErrorIs(t, err, service.ErrGalleryFull)
+				}
+
+				_, err = store.Images(laptopID)
+				assert.NoError(t, err)
+			}()
+		}
+		wg.Wait()
+
+		images, err := store.Images(laptopID)
+		require.NoError(t, err)
+		require.Len(t, images, concurrency/2)
+	})
+}
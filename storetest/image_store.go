@@ -0,0 +1,214 @@
+package storetest
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+	"testing"
+	"testing/iotest"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gitlab.com/brucemig/pcbook/sample"
+	"gitlab.com/brucemig/pcbook/service"
+)
+
+// TestImageStore runs the conformance suite against the image stores returned by newStore.
+// newStore must return a new empty store every time it is called.
+func TestImageStore(t *testing.T, newStore func(t *testing.T) service.ImageStore) {
+	t.Run("save", func(t *testing.T) {
+		t.Parallel()
+		store := newStore(t)
+
+		laptopID := sample.NewLaptop().GetId()
+
+		imageID1, err := store.Save(newImageInfo(laptopID, ".jpg"), strings.NewReader("image 1"))
+		require.NoError(t, err)
+		require.NotEmpty(t, imageID1)
+
+		imageID2, err := store.Save(newImageInfo(laptopID, ".jpg"), strings.NewReader("image 2"))
+		require.NoError(t, err)
+		require.NotEmpty(t, imageID2)
+		require.NotEqual(t, imageID1, imageID2)
+	})
+
+	t.Run("open", func(t *testing.T) {
+		t.Parallel()
+		store := newStore(t)
+
+		laptopID := sample.NewLaptop().GetId()
+
+		imageID, err := store.Save(newImageInfo(laptopID, ".png"), strings.NewReader("image data"))
+		require.NoError(t, err)
+
+		info, imageData, err := store.Open(imageID)
+		require.NoError(t, err)
+		defer imageData.Close()
+
+		checksum := sha256.Sum256([]byte("image data"))
+		require.Equal(t, imageID, info.ID)
+		require.Equal(t, laptopID, info.LaptopID)
+		require.Equal(t, ".png", info.Type)
+		require.Equal(t, int64(len("image data")), info.Size)
+		require.Equal(t, hex.EncodeToString(checksum[:]), info.Checksum)
+		require.Equal(t, "uploader", info.Uploader)
+		require.Equal(t, 640, info.Width)
+		require.Equal(t, 480, info.Height)
+		require.False(t, info.UploadedAt.IsZero())
+
+		data, err := io.ReadAll(imageData)
+		require.NoError(t, err)
+		require.Equal(t, "image data", string(data))
+
+		stat, err := store.Stat(imageID)
+		require.NoError(t, err)
+		require.Equal(t, info, stat)
+	})
+
+	t.Run("save_read_error", func(t *testing.T) {
+		t.Parallel()
+		store := newStore(t)
+
+		laptopID := sample.NewLaptop().GetId()
+
+		imageData := io.MultiReader(strings.NewReader("partial"), iotest.ErrReader(errors.New("connection lost")))
+		_, err := store.Save(newImageInfo(laptopID, ".png"), imageData)
+		require.Error(t, err)
+
+		images, err := store.List(laptopID)
+		require.NoError(t, err)
+		require.Empty(t, images)
+	})
+
+	t.Run("open_not_found", func(t *testing.T) {
+		t.Parallel()
+		store := newStore(t)
+
+		_, _, err := store.Open("unknown")
+		require.ErrorIs(t, err, service.ErrNotFound)
+
+		_, err = store.Stat("unknown")
+		require.ErrorIs(t, err, service.ErrNotFound)
+	})
+
+	t.Run("list_and_delete", func(t *testing.T) {
+		t.Parallel()
+		store := newStore(t)
+
+		laptopID := sample.NewLaptop().GetId()
+
+		images, err := store.List(laptopID)
+		require.NoError(t, err)
+		require.Empty(t, images)
+
+		imageIDs := make([]string, 3)
+		for i := range imageIDs {
+			imageIDs[i], err = store.Save(newImageInfo(laptopID, ".jpg"), strings.NewReader("image"))
+			require.NoError(t, err)
+		}
+
+		_, err = store.Save(newImageInfo(sample.NewLaptop().GetId(), ".jpg"), strings.NewReader("other"))
+		require.NoError(t, err)
+
+		images, err = store.List(laptopID)
+		require.NoError(t, err)
+		require.Equal(t, imageIDs, imageInfoIDs(images))
+
+		require.NoError(t, store.Delete(imageIDs[1]))
+		require.ErrorIs(t, store.Delete(imageIDs[1]), service.ErrNotFound)
+
+		_, _, err = store.Open(imageIDs[1])
+		require.ErrorIs(t, err, service.ErrNotFound)
+
+		images, err = store.List(laptopID)
+		require.NoError(t, err)
+		require.Equal(t, []string{imageIDs[0], imageIDs[2]}, imageInfoIDs(images))
+	})
+
+	t.Run("open_while_deleting", func(t *testing.T) {
+		t.Parallel()
+		store := newStore(t)
+
+		laptopID := sample.NewLaptop().GetId()
+		for i := 0; i < concurrency; i++ {
+			imageID, err := store.Save(newImageInfo(laptopID, ".png"), strings.NewReader(fmt.Sprintf("image %d", i)))
+			require.NoError(t, err)
+
+			var wg sync.WaitGroup
+			wg.Add(2)
+			go func() {
+				defer wg.Done()
+				assert.NoError(t, store.Delete(imageID))
+			}()
+			go func() {
+				defer wg.Done()
+
+				// an image deleted at the same time is either opened or not found
+				_, imageData, err := store.Open(imageID)
+				if err != nil {
+					assert.ErrorIs(t, err, service.ErrNotFound)
+					return
+				}
+				assert.NoError(t, imageData.Close())
+			}()
+			wg.Wait()
+		}
+	})
+
+	t.Run("concurrent_access", func(t *testing.T) {
+		t.Parallel()
+		store := newStore(t)
+
+		laptopID := sample.NewLaptop().GetId()
+
+		var mutex sync.Mutex
+		imageIDs := make(map[string]bool)
+
+		var wg sync.WaitGroup
+		for i := 0; i < concurrency; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+
+				imageID, err := store.Save(newImageInfo(laptopID, ".png"), strings.NewReader("image"))
+				assert.NoError(t, err)
+
+				_, err = store.List(laptopID)
+				assert.NoError(t, err)
+
+				mutex.Lock()
+				defer mutex.Unlock()
+				imageIDs[imageID] = true
+			}()
+		}
+		wg.Wait()
+
+		require.Len(t, imageIDs, concurrency)
+
+		images, err := store.List(laptopID)
+		require.NoError(t, err)
+		require.Len(t, images, concurrency)
+	})
+}
+
+func newImageInfo(laptopID string, imageType string) *service.ImageInfo {
+	return &service.ImageInfo{
+		LaptopID: laptopID,
+		Type:     imageType,
+		Uploader: "uploader",
+		Width:    640,
+		Height:   480,
+	}
+}
+
+func imageInfoIDs(images []*service.ImageInfo) []string {
+	ids := make([]string, len(images))
+	for i, info := range images {
+		ids[i] = info.ID
+	}
+	return ids
+}
@@ -0,0 +1,252 @@
+// Package storetest provides conformance test suites for store implementations.
+// A new store passes the suite if it behaves like the in-memory stores of the service package.
+package storetest
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gitlab.com/brucemig/pcbook/pb"
+	"gitlab.com/brucemig/pcbook/sample"
+	"gitlab.com/brucemig/pcbook/service"
+	"google.golang.org/protobuf/proto"
+)
+
+// concurrency is the number of goroutines used by the concurrent access tests
+const concurrency = 16
+
+// TestLaptopStore runs the conformance suite against the laptop stores returned by newStore.
+// newStore must return a new empty store every time it is called.
+func TestLaptopStore(t *testing.T, newStore func(t *testing.T) service.LaptopStore) {
+	t.Run("save_already_exists", func(t *testing.T) {
+		t.Parallel()
+		store := newStore(t)
+
+		laptop := sample.NewLaptop()
+		require.NoError(t, store.Save(laptop))
+
+		err := store.Save(laptop)
+		require.ErrorIs(t, err, service.ErrAlreadyExists)
+	})
+
+	t.Run("find_not_found", func(t *testing.T) {
+		t.Parallel()
+		store := newStore(t)
+
+		laptop, err := store.Find(sample.NewLaptop().GetId())
+		require.NoError(t, err)
+		require.Nil(t, laptop)
+	})
+
+	t.Run("update_and_delete", func(t *testing.T) {
+		t.Parallel()
+		store := newStore(t)
+
+		laptop := sample.NewLaptop()
+		require.ErrorIs(t, store.Update(laptop), service.ErrNotFound)
+		require.ErrorIs(t, store.Delete(laptop.GetId()), service.ErrNotFound)
+
+		require.NoError(t, store.Save(laptop))
+
+		laptop.PriceUsd = 1234
+		require.NoError(t, store.Update(laptop))
+
+		found, err := store.Find(laptop.GetId())
+		require.NoError(t, err)
+		require.True(t, proto.Equal(laptop, found))
+
+		require.NoError(t, store.Delete(laptop.GetId()))
+
+		found, err = store.Find(laptop.GetId())
+		require.NoError(t, err)
+		require.Nil(t, found)
+	})
+
+	t.Run("deep_copy_isolation", func(t *testing.T) {
+		t.Parallel()
+		store := newStore(t)
+
+		laptop := sample.NewLaptop()
+		expected := proto.Clone(laptop).(*pb.Laptop)
+		require.NoError(t, store.Save(laptop))
+
+		// mutating the saved laptop must not change the stored one
+		mutateLaptop(laptop)
+		requireStoredLaptop(t, store, expected)
+
+		// mutating a found laptop must not change the stored one
+		found, err := store.Find(expected.GetId())
+		require.NoError(t, err)
+		mutateLaptop(found)
+		requireStoredLaptop(t, store, expected)
+
+		// mutating a searched laptop must not change the stored one
+		err = store.Search(context.Background(), matchAllFilter(), func(laptop *pb.Laptop) error {
+			mutateLaptop(laptop)
+			return nil
+		})
+		require.NoError(t, err)
+		requireStoredLaptop(t, store, expected)
+	})
+
+	t.Run("search_filter", func(t *testing.T) {
+		t.Parallel()
+		store := newStore(t)
+
+		filter := &pb.Filter{
+			MaxPriceUsd: 2000,
+			MinCpuCores: 4,
+			MinCpuGhz:   2.2,
+			MinRam:      &pb.Memory{Value: 8, Unit: pb.Memory_GIGABYTE},
+		}
+
+		expectedIDs := make(map[string]bool)
+		for i := 0; i < 7; i++ {
+			laptop := sample.NewLaptop()
+			laptop.PriceUsd = 1500
+			laptop.Cpu.NumberCores = 4
+			laptop.Cpu.MinGhz = 2.5
+			laptop.Ram = &pb.Memory{Value: 16, Unit: pb.Memory_GIGABYTE}
+
+			switch i {
+			case 0:
+				laptop.PriceUsd = 2500
+			case 1:
+				laptop.Cpu.NumberCores = 2
+			case 2:
+				laptop.Cpu.MinGhz = 2.0
+			case 3:
+				laptop.Ram = &pb.Memory{Value: 4096, Unit: pb.Memory_MEGABYTE}
+			case 4:
+				laptop.PriceUsd = 2000
+				laptop.Ram = &pb.Memory{Value: 8192, Unit: pb.Memory_MEGABYTE}
+				expectedIDs[laptop.Id] = true
+			default:
+				expectedIDs[laptop.Id] = true
+			}
+
+			require.NoError(t, store.Save(laptop))
+		}
+
+		foundIDs := make(map[string]bool)
+		err := store.Search(context.Background(), filter, func(laptop *pb.Laptop) error {
+			require.False(t, foundIDs[laptop.GetId()], "laptop %s found twice", laptop.GetId())
+			foundIDs[laptop.GetId()] = true
+			return nil
+		})
+		require.NoError(t, err)
+		require.Equal(t, expectedIDs, foundIDs)
+	})
+
+	t.Run("search_context_canceled", func(t *testing.T) {
+		t.Parallel()
+		store := newStore(t)
+
+		for i := 0; i < 3; i++ {
+			require.NoError(t, store.Save(sample.NewLaptop()))
+		}
+
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+
+		found := 0
+		err := store.Search(ctx, matchAllFilter(), func(laptop *pb.Laptop) error {
+			found++
+			return nil
+		})
+		require.Error(t, err)
+		require.Zero(t, found)
+	})
+
+	t.Run("search_found_error", func(t *testing.T) {
+		t.Parallel()
+		store := newStore(t)
+
+		for i := 0; i < 20; i++ {
+			require.NoError(t, store.Save(sample.NewLaptop()))
+		}
+
+		foundErr := errors.New("cannot send laptop")
+		found := 0
+		err := store.Search(context.Background(), matchAllFilter(), func(laptop *pb.Laptop) error {
+			found++
+			return foundErr
+		})
+		require.ErrorIs(t, err, foundErr)
+		require.Equal(t, 1, found)
+	})
+
+	t.Run("concurrent_access", func(t *testing.T) {
+		t.Parallel()
+		store := newStore(t)
+
+		var wg sync.WaitGroup
+		for i := 0; i < concurrency; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+
+				laptop := sample.NewLaptop()
+				assert.NoError(t, store.Save(laptop))
+
+				laptop.PriceUsd = 1000
+				assert.NoError(t, store.Update(laptop))
+
+				found, err := store.Find(laptop.GetId())
+				assert.NoError(t, err)
+				assert.NotNil(t, found)
+				mutateLaptop(found)
+
+				err = store.Search(context.Background(), matchAllFilter(), func(laptop *pb.Laptop) error {
+					mutateLaptop(laptop)
+					return nil
+				})
+				assert.NoError(t, err)
+			}()
+		}
+		wg.Wait()
+
+		count := 0
+		err := store.Search(context.Background(), matchAllFilter(), func(laptop *pb.Laptop) error {
+			count++
+			return nil
+		})
+		require.NoError(t, err)
+		require.Equal(t, concurrency, count)
+	})
+}
+
+func requireStoredLaptop(t *testing.T, store service.LaptopStore, expected *pb.Laptop) {
+	found, err := store.Find(expected.GetId())
+	require.NoError(t, err)
+	require.NotNil(t, found)
+	require.True(t, proto.Equal(expected, found), "stored laptop was modified")
+}
+
+// mutateLaptop changes every nested message of the laptop, except its ID
+func mutateLaptop(laptop *pb.Laptop) {
+	laptop.Brand = "mutated"
+	laptop.PriceUsd = 1
+	laptop.Cpu.Brand = "mutated"
+	laptop.Ram.Value = 1
+	laptop.Gpu[0].Brand = "mutated"
+	laptop.Gpu[0].Memory.Value = 1
+	laptop.Storages[0].Memory.Value = 1
+	laptop.Screen.Resolution.Width = 1
+	laptop.Keyboard.Backlit = !laptop.Keyboard.Backlit
+	laptop.UpdatedAt.Seconds = 1
+	if weight, ok := laptop.GetWeight().(*pb.Laptop_WeightKg); ok {
+		weight.WeightKg = 1
+	} else {
+		laptop.Weight = &pb.Laptop_WeightKg{WeightKg: 1}
+	}
+}
+
+// matchAllFilter returns a filter that matches every laptop
+func matchAllFilter() *pb.Filter {
+	return &pb.Filter{MaxPriceUsd: 1e12}
+}
@@ -0,0 +1,137 @@
+package storetest
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+	"gitlab.com/brucemig/pcbook/sample"
+	"gitlab.com/brucemig/pcbook/service"
+)
+
+// TestQuarantineStore runs the conformance suite against the quarantine stores returned by newStore.
+// newStore must return a new empty store every time it is called.
+func TestQuarantineStore(t *testing.T, newStore func(t *testing.T) service.QuarantineStore) {
+	t.Run("add_and_find", func(t *testing.T) {
+		t.Parallel()
+		store := newStore(t)
+
+		vote := newQuarantinedVote(sample.NewLaptop().GetId(), "user1", time.Unix(1000, 0))
+		require.NoError(t, store.Add(vote))
+		require.ErrorIs(t, store.Add(vote), service.ErrAlreadyExists)
+
+		found, err := store.Find(vote.ID)
+		require.NoError(t, err)
+		requireSameQuarantinedVote(t, vote, found)
+
+		// mutating a returned vote must not change the stored one
+		found.Vote.Score = 1
+		found, err = store.Find(vote.ID)
+		require.NoError(t, err)
+		require.Equal(t, 10.0, found.Vote.Score)
+
+		found, err = store.Find(uuid.New().String())
+		require.NoError(t, err)
+		require.Nil(t, found)
+	})
+
+	t.Run("add_all", func(t *testing.T) {
+		t.Parallel()
+		store := newStore(t)
+
+		laptopID := sample.NewLaptop().GetId()
+		vote1 := newQuarantinedVote(laptopID, "user1", time.Unix(1000, 0))
+		vote2 := newQuarantinedVote(laptopID, "user1", time.Unix(1000, 0))
+		require.NoError(t, store.AddAll([]*service.QuarantinedVote{vote1, vote2}))
+
+		votes, err := store.List(laptopID)
+		require.NoError(t, err)
+		require.ElementsMatch(t, []string{vote1.ID, vote2.ID}, quarantineIDs(votes))
+
+		// a batch with a vote already quarantined is not quarantined at all
+		vote3 := newQuarantinedVote(laptopID, "user2", time.Unix(2000, 0))
+		require.ErrorIs(t, store.AddAll([]*service.QuarantinedVote{vote3, vote1}), service.ErrAlreadyExists)
+
+		found, err := store.Find(vote3.ID)
+		require.NoError(t, err)
+		require.Nil(t, found)
+	})
+
+	t.Run("list", func(t *testing.T) {
+		t.Parallel()
+		store := newStore(t)
+
+		laptopID1 := sample.NewLaptop().GetId()
+		laptopID2 := sample.NewLaptop().GetId()
+
+		newest := newQuarantinedVote(laptopID1, "user1", time.Unix(3000, 0))
+		oldest := newQuarantinedVote(laptopID1, "user2", time.Unix(1000, 0))
+		other := newQuarantinedVote(laptopID2, "user1", time.Unix(2000, 0))
+		for _, vote := range []*service.QuarantinedVote{newest, oldest, other} {
+			require.NoError(t, store.Add(vote))
+		}
+
+		votes, err := store.List(laptopID1)
+		require.NoError(t, err)
+		require.Equal(t, []string{oldest.ID, newest.ID}, quarantineIDs(votes))
+
+		// an empty laptop ID selects the votes of all laptops
+		votes, err = store.List("")
+		require.NoError(t, err)
+		require.Equal(t, []string{oldest.ID, other.ID, newest.ID}, quarantineIDs(votes))
+
+		votes, err = store.List(sample.NewLaptop().GetId())
+		require.NoError(t, err)
+		require.Empty(t, votes)
+	})
+
+	t.Run("remove", func(t *testing.T) {
+		t.Parallel()
+		store := newStore(t)
+
+		vote := newQuarantinedVote(sample.NewLaptop().GetId(), "user1", time.Unix(1000, 0))
+		require.NoError(t, store.Add(vote))
+
+		removed, err := store.Remove(vote.ID)
+		require.NoError(t, err)
+		requireSameQuarantinedVote(t, vote, removed)
+
+		found, err := store.Find(vote.ID)
+		require.NoError(t, err)
+		require.Nil(t, found)
+
+		_, err = store.Remove(vote.ID)
+		require.ErrorIs(t, err, service.ErrNotFound)
+
+		votes, err := store.List("")
+		require.NoError(t, err)
+		require.Empty(t, votes)
+	})
+}
+
+func newQuarantinedVote(laptopID string, username string, ratedAt time.Time) *service.QuarantinedVote {
+	return &service.QuarantinedVote{
+		ID:     uuid.New().String(),
+		Vote:   service.Vote{LaptopID: laptopID, Username: username, Score: 10, Time: ratedAt},
+		Reason: "too many scores",
+	}
+}
+
+// requireSameQuarantinedVote requires the votes to be equal, with their times at the same instant
+func requireSameQuarantinedVote(t *testing.T, expected *service.QuarantinedVote, actual *service.QuarantinedVote) {
+	require.NotNil(t, actual)
+	require.True(t, expected.Vote.Time.Equal(actual.Vote.Time))
+
+	other := *actual
+	other.Vote.Time = expected.Vote.Time
+	require.Equal(t, expected, &other)
+}
+
+func quarantineIDs(votes []*service.QuarantinedVote) []string {
+	ids := make([]string, len(votes))
+	for i, vote := range votes {
+		ids[i] = vote.ID
+	}
+	return ids
+}
@@ -0,0 +1,520 @@
+package storetest
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gitlab.com/brucemig/pcbook/sample"
+	"gitlab.com/brucemig/pcbook/service"
+)
+
+// TestRatingStore runs the conformance suite against the rating stores returned by newStore.
+// newStore must return a new empty store every time it is called.
+func TestRatingStore(t *testing.T, newStore func(t *testing.T) service.RatingStore) {
+	t.Run("add", func(t *testing.T) {
+		t.Parallel()
+		store := newStore(t)
+
+		laptopID1 := sample.NewLaptop().GetId()
+		laptopID2 := sample.NewLaptop().GetId()
+
+		rating, err := store.Add(newVote(laptopID1, "user1", 8))
+		require.NoError(t, err)
+		require.Equal(t, uint32(1), rating.Count)
+		require.Equal(t, 8.0, rating.Sum)
+
+		rating, err = store.Add(newVote(laptopID1, "user2", 7.5))
+		require.NoError(t, err)
+		require.Equal(t, uint32(2), rating.Count)
+		require.Equal(t, 15.5, rating.Sum)
+
+		rating, err = store.Add(newVote(laptopID2, "user1", 3))
+		require.NoError(t, err)
+		require.Equal(t, uint32(1), rating.Count)
+		require.Equal(t, 3.0, rating.Sum)
+	})
+
+	t.Run("replace", func(t *testing.T) {
+		t.Parallel()
+		store := newStore(t)
+
+		laptopID := sample.NewLaptop().GetId()
+
+		_, err := store.Add(newVote(laptopID, "user1", 8))
+		require.NoError(t, err)
+		_, err = store.Add(newVote(laptopID, "user2", 4))
+		require.NoError(t, err)
+
+		// the second vote of a user replaces the first one
+		rating, err := store.Add(newVote(laptopID, "user1", 2))
+		require.NoError(t, err)
+		require.Equal(t, uint32(2), rating.Count)
+		require.Equal(t, 6.0, rating.Sum)
+
+		for i := 0; i < 10; i++ {
+			rating, err = store.Add(newVote(laptopID, "user1", 0.1))
+			require.NoError(t, err)
+		}
+		require.Equal(t, uint32(2), rating.Count)
+		require.Equal(t, 4.1, rating.Sum)
+	})
+
+	t.Run("add_all", func(t *testing.T) {
+		t.Parallel()
+		store := newStore(t)
+
+		laptopID1 := sample.NewLaptop().GetId()
+		laptopID2 := sample.NewLaptop().GetId()
+
+		_, err := store.Add(newVote(laptopID1, "user1", 8))
+		require.NoError(t, err)
+
+		// the votes of a batch are added in order, and each one gets the rating of its laptop after the batch
+		ratings, err := store.AddAll([]*service.Vote{
+			newVote(laptopID1, "user2", 6),
+			newVote(laptopID2, "user1", 3),
+			newVote(laptopID1, "user1", 2),
+		})
+		require.NoError(t, err)
+		require.Len(t, ratings, 3)
+		require.Equal(t, uint32(2), ratings[0].Count)
+		require.Equal(t, 8.0, ratings[0].Sum)
+		require.Equal(t, ratings[0], ratings[2])
+		require.Equal(t, uint32(1), ratings[1].Count)
+		require.Equal(t, 3.0, ratings[1].Sum)
+
+		found, err := store.Find(laptopID1)
+		require.NoError(t, err)
+		require.Equal(t, ratings[0], found)
+
+		ratings, err = store.AddAll(nil)
+		require.NoError(t, err)
+		require.Empty(t, ratings)
+	})
+
+	t.Run("add_if_newer", func(t *testing.T) {
+		t.Parallel()
+		store := newStore(t)
+
+		laptopID := sample.NewLaptop().GetId()
+		votedAt := time.Now().Add(-time.Hour)
+
+		rating, added, err := store.AddIfNewer(newTimedVote(laptopID, "user1", 8, votedAt))
+		require.NoError(t, err)
+		require.True(t, added)
+		require.Equal(t, 8.0, rating.Sum)
+
+		// an older vote of the same user doesn't replace the more recent one
+		rating, added, err = store.AddIfNewer(newTimedVote(laptopID, "user1", 2, votedAt.Add(-time.Minute)))
+		require.NoError(t, err)
+		require.False(t, added)
+		require.Equal(t, uint32(1), rating.Count)
+		require.Equal(t, 8.0, rating.Sum)
+
+		rating, added, err = store.AddIfNewer(newTimedVote(laptopID, "user1", 4, votedAt.Add(time.Minute)))
+		require.NoError(t, err)
+		require.True(t, added)
+		require.Equal(t, uint32(1), rating.Count)
+		require.Equal(t, 4.0, rating.Sum)
+
+		// the anonymous votes are always added
+		rating, added, err = store.AddIfNewer(newTimedVote(laptopID, "", 6, votedAt.Add(-time.Minute)))
+		require.NoError(t, err)
+		require.True(t, added)
+		require.Equal(t, uint32(2), rating.Count)
+		require.Equal(t, 10.0, rating.Sum)
+
+		// an older vote never replaces a newer one added concurrently
+		var wg sync.WaitGroup
+		for i := 0; i < concurrency; i++ {
+			wg.Add(2)
+			username := fmt.Sprintf("user%d", i+2)
+			go func() {
+				defer wg.Done()
+				_, err := store.Add(newTimedVote(laptopID, username, 10, votedAt))
+				assert.NoError(t, err)
+			}()
+			go func() {
+				defer wg.Done()
+				_, _, err := store.AddIfNewer(newTimedVote(laptopID, username, 1, votedAt.Add(-time.Minute)))
+				assert.NoError(t, err)
+			}()
+		}
+		wg.Wait()
+
+		votes, err := store.UserVotes("user2")
+		require.NoError(t, err)
+		require.Len(t, votes, 1)
+		require.Equal(t, 10.0, votes[0].Score)
+
+		rating, err = store.Find(laptopID)
+		require.NoError(t, err)
+		require.Equal(t, uint32(concurrency+2), rating.Count)
+		require.Equal(t, 10.0+10*float64(concurrency), rating.Sum)
+	})
+
+	t.Run("statistics", func(t *testing.T) {
+		t.Parallel()
+		store := newStore(t)
+
+		laptopID := sample.NewLaptop().GetId()
+
+		var rating *service.Rating
+		for i, score := range []float64{2, 4, 4, 4, 5, 5, 7, 9} {
+			var err error
+			rating, err = store.Add(newVote(laptopID, fmt.Sprintf("user%d", i), score))
+			require.NoError(t, err)
+		}
+
+		require.Equal(t, uint32(8), rating.Count)
+		require.Equal(t, 5.0, rating.Average())
+		require.Equal(t, 2.0, rating.StandardDeviation())
+		require.Equal(t, 4.5, rating.Median)
+		require.Equal(t, []uint32{0, 1, 0, 3, 2, 0, 1, 0, 1, 0}, rating.Histogram)
+		require.InDelta(t, (service.DefaultRatingPrior.Weight*service.DefaultRatingPrior.Mean+40)/(service.DefaultRatingPrior.Weight+8), rating.BayesianAverage, 1e-9)
+
+		// replacing a score updates the statistics
+		rating, err := store.Add(newVote(laptopID, "user7", 0.6))
+		require.NoError(t, err)
+		require.Equal(t, 4.0, rating.Median)
+		require.Equal(t, []uint32{1, 1, 0, 3, 2, 0, 1, 0, 0, 0}, rating.Histogram)
+
+		found, err := store.Find(laptopID)
+		require.NoError(t, err)
+		require.Equal(t, rating, found)
+
+		found, err = store.Find(sample.NewLaptop().GetId())
+		require.NoError(t, err)
+		require.Nil(t, found)
+	})
+
+	t.Run("anonymous", func(t *testing.T) {
+		t.Parallel()
+		store := newStore(t)
+
+		laptopID := sample.NewLaptop().GetId()
+
+		_, err := store.Add(newVote(laptopID, "", 8))
+		require.NoError(t, err)
+
+		// anonymous votes are never replaced
+		rating, err := store.Add(newVote(laptopID, "", 6))
+		require.NoError(t, err)
+		require.Equal(t, uint32(2), rating.Count)
+		require.Equal(t, 14.0, rating.Sum)
+
+		votes, err := store.UserVotes("")
+		require.NoError(t, err)
+		require.Empty(t, votes)
+	})
+
+	t.Run("reviews", func(t *testing.T) {
+		t.Parallel()
+		store := newStore(t)
+
+		laptopID := sample.NewLaptop().GetId()
+
+		_, err := store.Add(newVote(laptopID, "user1", 8))
+		require.NoError(t, err)
+
+		// the score of a review doesn't replace the vote of its author, nor is replaced by it
+		review := newVote(laptopID, "user1", 4)
+		review.ReviewID = "review1"
+		rating, err := store.Add(review)
+		require.NoError(t, err)
+		require.Equal(t, uint32(2), rating.Count)
+		require.Equal(t, 12.0, rating.Sum)
+
+		rating, err = store.Add(newVote(laptopID, "user1", 6))
+		require.NoError(t, err)
+		require.Equal(t, uint32(2), rating.Count)
+		require.Equal(t, 10.0, rating.Sum)
+
+		// adding the score of the same review again replaces it
+		rating, err = store.Add(review)
+		require.NoError(t, err)
+		require.Equal(t, uint32(2), rating.Count)
+		require.Equal(t, 10.0, rating.Sum)
+
+		votes, err := store.UserVotes("user1")
+		require.NoError(t, err)
+		require.Len(t, votes, 1)
+		require.Equal(t, 6.0, votes[0].Score)
+		require.Empty(t, votes[0].ReviewID)
+	})
+
+	t.Run("user_votes", func(t *testing.T) {
+		t.Parallel()
+		store := newStore(t)
+
+		laptopIDs := []string{sample.NewLaptop().GetId(), sample.NewLaptop().GetId(), sample.NewLaptop().GetId()}
+		for i, laptopID := range laptopIDs {
+			_, err := store.Add(newVote(laptopID, "user1", float64(i)))
+			require.NoError(t, err)
+		}
+		_, err := store.Add(newVote(laptopIDs[1], "user1", 9))
+		require.NoError(t, err)
+		_, err = store.Add(newVote(laptopIDs[0], "user2", 5))
+		require.NoError(t, err)
+
+		votes, err := store.UserVotes("user1")
+		require.NoError(t, err)
+		require.Len(t, votes, 3)
+
+		scores := make(map[string]float64)
+		for i, vote := range votes {
+			if i > 0 {
+				require.Less(t, votes[i-1].LaptopID, vote.LaptopID)
+			}
+			require.Equal(t, "user1", vote.Username)
+			scores[vote.LaptopID] = vote.Score
+		}
+		require.Equal(t, map[string]float64{laptopIDs[0]: 0, laptopIDs[1]: 9, laptopIDs[2]: 2}, scores)
+
+		votes, err = store.UserVotes("unknown")
+		require.NoError(t, err)
+		require.Empty(t, votes)
+	})
+
+	t.Run("top_rated", func(t *testing.T) {
+		t.Parallel()
+		store := newStore(t)
+
+		// with the default prior, one 10 ranks below many 9s
+		single := sample.NewLaptop().GetId()
+		popular := sample.NewLaptop().GetId()
+		average := sample.NewLaptop().GetId()
+		bad := sample.NewLaptop().GetId()
+
+		_, err := store.Add(newVote(single, "user0", 10))
+		require.NoError(t, err)
+		for i := 0; i < 20; i++ {
+			_, err = store.Add(newVote(popular, fmt.Sprintf("user%d", i), 9))
+			require.NoError(t, err)
+			_, err = store.Add(newVote(average, fmt.Sprintf("user%d", i), 7))
+			require.NoError(t, err)
+		}
+		for i := 0; i < 5; i++ {
+			_, err = store.Add(newVote(bad, fmt.Sprintf("user%d", i), 1))
+			require.NoError(t, err)
+		}
+
+		topRated := func(minCount uint32) []string {
+			var laptopIDs []string
+			var previous *service.Rating
+			err := store.TopRated(minCount, time.Time{}, func(laptopID string, rating *service.Rating) error {
+				require.GreaterOrEqual(t, rating.Count, minCount)
+				if previous != nil {
+					require.LessOrEqual(t, rating.BayesianAverage, previous.BayesianAverage)
+				}
+				previous = rating
+
+				found, err := store.Find(laptopID)
+				require.NoError(t, err)
+				require.Equal(t, found, rating)
+
+				laptopIDs = append(laptopIDs, laptopID)
+				return nil
+			})
+			require.NoError(t, err)
+			return laptopIDs
+		}
+
+		require.Equal(t, []string{popular, average, single, bad}, topRated(0))
+		require.Equal(t, []string{popular, average, bad}, topRated(2))
+		require.Empty(t, topRated(100))
+
+		// the ranking follows the new and replaced votes
+		for i := 0; i < 20; i++ {
+			_, err = store.Add(newVote(average, fmt.Sprintf("user%d", i), 10))
+			require.NoError(t, err)
+		}
+		_, err = store.Add(newVote(bad, "user0", 10))
+		require.NoError(t, err)
+		require.Equal(t, []string{average, popular, single, bad}, topRated(0))
+
+		// the iteration stops at the first error
+		stop := errors.New("stop")
+		var laptopIDs []string
+		err = store.TopRated(0, time.Time{}, func(laptopID string, rating *service.Rating) error {
+			laptopIDs = append(laptopIDs, laptopID)
+			return stop
+		})
+		require.ErrorIs(t, err, stop)
+		require.Equal(t, []string{average}, laptopIDs)
+	})
+
+	t.Run("window", func(t *testing.T) {
+		t.Parallel()
+		store := newStore(t)
+
+		now := time.Now()
+		daysAgo := func(days int) time.Time {
+			return now.AddDate(0, 0, -days)
+		}
+
+		laptopID1 := sample.NewLaptop().GetId()
+		laptopID2 := sample.NewLaptop().GetId()
+		for _, vote := range []*service.Vote{
+			newTimedVote(laptopID1, "user1", 10, daysAgo(100)),
+			newTimedVote(laptopID1, "user2", 2, daysAgo(50)),
+			newTimedVote(laptopID1, "user3", 4, daysAgo(10)),
+			newTimedVote(laptopID2, "user1", 9, daysAgo(60)),
+			newTimedVote(laptopID2, "user2", 9, daysAgo(40)),
+		} {
+			_, err := store.Add(vote)
+			require.NoError(t, err)
+		}
+
+		rating, err := store.FindSince(laptopID1, daysAgo(30))
+		require.NoError(t, err)
+		require.Equal(t, uint32(1), rating.Count)
+		require.Equal(t, 4.0, rating.Median)
+
+		rating, err = store.FindSince(laptopID1, daysAgo(90))
+		require.NoError(t, err)
+		require.Equal(t, uint32(2), rating.Count)
+		require.Equal(t, 6.0, rating.Sum)
+
+		rating, err = store.FindSince(laptopID2, daysAgo(30))
+		require.NoError(t, err)
+		require.Zero(t, rating.Count)
+
+		rating, err = store.FindSince(sample.NewLaptop().GetId(), daysAgo(30))
+		require.NoError(t, err)
+		require.Nil(t, rating)
+
+		// the votes are ranked within the window
+		topRated := func(minCount uint32, since time.Time) []string {
+			var laptopIDs []string
+			err := store.TopRated(minCount, since, func(laptopID string, rating *service.Rating) error {
+				laptopIDs = append(laptopIDs, laptopID)
+				return nil
+			})
+			require.NoError(t, err)
+			return laptopIDs
+		}
+		require.Equal(t, []string{laptopID2, laptopID1}, topRated(0, time.Time{}))
+		require.Equal(t, []string{laptopID2, laptopID1}, topRated(1, daysAgo(90)))
+		require.Equal(t, []string{laptopID1}, topRated(1, daysAgo(30)))
+		require.Equal(t, []string{laptopID1}, topRated(0, daysAgo(30)))
+
+		// a replaced vote moves to the time of the new vote
+		_, err = store.Add(newTimedVote(laptopID1, "user1", 10, daysAgo(5)))
+		require.NoError(t, err)
+		rating, err = store.FindSince(laptopID1, daysAgo(30))
+		require.NoError(t, err)
+		require.Equal(t, uint32(2), rating.Count)
+		require.Equal(t, 14.0, rating.Sum)
+
+		votes, err := store.UserVotes("user1")
+		require.NoError(t, err)
+		for _, vote := range votes {
+			if vote.LaptopID == laptopID1 {
+				require.True(t, daysAgo(5).Equal(vote.Time))
+			}
+		}
+	})
+
+	t.Run("decay", func(t *testing.T) {
+		t.Parallel()
+		store := newStore(t)
+
+		now := time.Now()
+		laptopID := sample.NewLaptop().GetId()
+
+		// a score one half-life older than another one weighs half as much
+		_, err := store.Add(newTimedVote(laptopID, "user1", 10, now.Add(-service.DefaultRatingHalfLife)))
+		require.NoError(t, err)
+		rating, err := store.Add(newTimedVote(laptopID, "user2", 4, now))
+		require.NoError(t, err)
+		require.Equal(t, 7.0, rating.Average())
+		require.InDelta(t, (0.5*10+4)/1.5, rating.DecayedAverage, 1e-9)
+
+		_, err = store.Add(newTimedVote(laptopID, "user3", 1, now.Add(-2*service.DefaultRatingHalfLife)))
+		require.NoError(t, err)
+		rating, err = store.Find(laptopID)
+		require.NoError(t, err)
+		require.InDelta(t, (0.5*10+4+0.25*1)/1.75, rating.DecayedAverage, 1e-9)
+
+		// replacing a vote replaces its weight
+		rating, err = store.Add(newTimedVote(laptopID, "user1", 10, now))
+		require.NoError(t, err)
+		require.InDelta(t, (10+4+0.25*1)/2.25, rating.DecayedAverage, 1e-9)
+	})
+
+	t.Run("copy_isolation", func(t *testing.T) {
+		t.Parallel()
+		store := newStore(t)
+
+		laptopID := sample.NewLaptop().GetId()
+
+		vote := newVote(laptopID, "user1", 5)
+		rating, err := store.Add(vote)
+		require.NoError(t, err)
+
+		// mutating a returned rating or vote, or an added vote, must not change the stored one
+		rating.Count = 100
+		rating.Sum = 100
+		vote.Score = 100
+
+		votes, err := store.UserVotes("user1")
+		require.NoError(t, err)
+		require.Equal(t, 5.0, votes[0].Score)
+		votes[0].Score = 100
+
+		rating, err = store.Add(newVote(laptopID, "user2", 5))
+		require.NoError(t, err)
+		require.Equal(t, uint32(2), rating.Count)
+		require.Equal(t, 10.0, rating.Sum)
+	})
+
+	t.Run("concurrent_access", func(t *testing.T) {
+		t.Parallel()
+		store := newStore(t)
+
+		laptopID := sample.NewLaptop().GetId()
+
+		var wg sync.WaitGroup
+		for i := 0; i < concurrency; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+
+				username := fmt.Sprintf("user%d", i)
+				rating, err := store.Add(newVote(laptopID, username, 2))
+				assert.NoError(t, err)
+				assert.GreaterOrEqual(t, rating.Sum, 2*float64(rating.Count)-0.5)
+
+				// the same user votes again, which doesn't count twice
+				_, err = store.Add(newVote(laptopID, username, 2))
+				assert.NoError(t, err)
+			}()
+		}
+		wg.Wait()
+
+		rating, err := store.Add(newVote(laptopID, "last", 2))
+		require.NoError(t, err)
+		require.Equal(t, uint32(concurrency+1), rating.Count)
+		require.Equal(t, 2*float64(concurrency+1), rating.Sum)
+	})
+}
+
+func newTimedVote(laptopID string, username string, score float64, votedAt time.Time) *service.Vote {
+	vote := newVote(laptopID, username, score)
+	vote.Time = votedAt
+	return vote
+}
+
+func newVote(laptopID string, username string, score float64) *service.Vote {
+	return &service.Vote{
+		LaptopID: laptopID,
+		Username: username,
+		Score:    score,
+	}
+}
@@ -0,0 +1,264 @@
+package storetest
+
+import (
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gitlab.com/brucemig/pcbook/sample"
+	"gitlab.com/brucemig/pcbook/service"
+)
+
+// TestReviewStore runs the conformance suite against the review stores returned by newStore.
+// newStore must return a new empty store every time it is called.
+func TestReviewStore(t *testing.T, newStore func(t *testing.T) service.ReviewStore) {
+	t.Run("save_and_find", func(t *testing.T) {
+		t.Parallel()
+		store := newStore(t)
+
+		review := newReview(sample.NewLaptop().GetId(), "user1", 8, time.Unix(1000, 0))
+		require.NoError(t, store.Save(review))
+		require.ErrorIs(t, store.Save(review), service.ErrAlreadyExists)
+
+		found, err := store.Find(review.ID)
+		require.NoError(t, err)
+		requireSameReview(t, review, found)
+		require.Equal(t, service.ReviewPending, found.Status)
+
+		found, err = store.Find(uuid.New().String())
+		require.NoError(t, err)
+		require.Nil(t, found)
+	})
+
+	t.Run("moderate", func(t *testing.T) {
+		t.Parallel()
+		store := newStore(t)
+
+		laptopID := sample.NewLaptop().GetId()
+		review1 := newReview(laptopID, "user1", 8, time.Unix(1000, 0))
+		review2 := newReview(laptopID, "user2", 3, time.Unix(2000, 0))
+		require.NoError(t, store.Save(review1))
+		require.NoError(t, store.Save(review2))
+
+		moderatedAt := time.Unix(3000, 0)
+		moderated, err := store.Moderate(review1.ID, service.ReviewApproved, "admin1", moderatedAt)
+		require.NoError(t, err)
+		require.Equal(t, service.ReviewApproved, moderated.Status)
+		require.Equal(t, "admin1", moderated.Moderator)
+		require.True(t, moderatedAt.Equal(moderated.ModeratedAt))
+
+		moderated, err = store.Moderate(review2.ID, service.ReviewRejected, "admin2", moderatedAt)
+		require.NoError(t, err)
+		require.Equal(t, service.ReviewRejected, moderated.Status)
+
+		// a review is only moderated once
+		_, err = store.Moderate(review1.ID, service.ReviewRejected, "admin2", moderatedAt)
+		require.ErrorIs(t, err, service.ErrAlreadyModerated)
+		_, err = store.Moderate(uuid.New().String(), service.ReviewApproved, "admin1", moderatedAt)
+		require.ErrorIs(t, err, service.ErrNotFound)
+
+		found, err := store.Find(review1.ID)
+		require.NoError(t, err)
+		require.Equal(t, service.ReviewApproved, found.Status)
+		require.Equal(t, "admin1", found.Moderator)
+	})
+
+	t.Run("list", func(t *testing.T) {
+		t.Parallel()
+		store := newStore(t)
+
+		laptopID1 := sample.NewLaptop().GetId()
+		laptopID2 := sample.NewLaptop().GetId()
+
+		oldest := newReview(laptopID1, "user1", 5, time.Unix(1000, 0))
+		best := newReview(laptopID1, "user2", 9, time.Unix(2000, 0))
+		newest := newReview(laptopID1, "user3", 5, time.Unix(3000, 0))
+		worst := newReview(laptopID1, "user4", 2, time.Unix(1500, 0))
+		pending := newReview(laptopID1, "user5", 7, time.Unix(2500, 0))
+		other := newReview(laptopID2, "user1", 6, time.Unix(500, 0))
+
+		for _, review := range []*service.Review{oldest, best, newest, worst, pending, other} {
+			require.NoError(t, store.Save(review))
+		}
+		for _, review := range []*service.Review{oldest, best, newest, worst, other} {
+			_, err := store.Moderate(review.ID, service.ReviewApproved, "admin1", time.Unix(4000, 0))
+			require.NoError(t, err)
+		}
+
+		testCases := []struct {
+			order    service.ReviewOrder
+			expected []*service.Review
+		}{
+			{service.ReviewsNewestFirst, []*service.Review{newest, best, worst, oldest}},
+			{service.ReviewsOldestFirst, []*service.Review{oldest, worst, best, newest}},
+			{service.ReviewsHighestScoreFirst, []*service.Review{best, newest, oldest, worst}},
+			{service.ReviewsLowestScoreFirst, []*service.Review{worst, newest, oldest, best}},
+		}
+
+		for _, tc := range testCases {
+			reviews, err := store.List(service.ReviewQuery{LaptopID: laptopID1, Status: service.ReviewApproved, Order: tc.order})
+			require.NoError(t, err)
+			require.Equal(t, reviewIDs(tc.expected), reviewIDs(reviews), "order %d", tc.order)
+		}
+
+		reviews, err := store.List(service.ReviewQuery{LaptopID: laptopID1, Status: service.ReviewPending})
+		require.NoError(t, err)
+		require.Len(t, reviews, 1)
+		requireSameReview(t, pending, reviews[0])
+
+		// an empty laptop ID selects the reviews of all laptops
+		reviews, err = store.List(service.ReviewQuery{Status: service.ReviewApproved, Order: service.ReviewsOldestFirst})
+		require.NoError(t, err)
+		require.Equal(t, reviewIDs([]*service.Review{other, oldest, worst, best, newest}), reviewIDs(reviews))
+
+		reviews, err = store.List(service.ReviewQuery{LaptopID: sample.NewLaptop().GetId(), Status: service.ReviewApproved})
+		require.NoError(t, err)
+		require.Empty(t, reviews)
+	})
+
+	t.Run("pages", func(t *testing.T) {
+		t.Parallel()
+		store := newStore(t)
+
+		laptopID := sample.NewLaptop().GetId()
+
+		var expected []*service.Review
+		for i := 0; i < 7; i++ {
+			// reviews with the same creation time are ordered by ID
+			review := newReview(laptopID, fmt.Sprintf("user%d", i), 5, time.Unix(int64(1000+i/2), 0))
+			require.NoError(t, store.Save(review))
+			expected = append(expected, review)
+		}
+
+		query := service.ReviewQuery{LaptopID: laptopID, Status: service.ReviewPending, Order: service.ReviewsOldestFirst, Limit: 3}
+		var listed []*service.Review
+		for {
+			reviews, err := store.List(query)
+			require.NoError(t, err)
+			require.LessOrEqual(t, len(reviews), 3)
+			if len(reviews) == 0 {
+				break
+			}
+
+			listed = append(listed, reviews...)
+			query.After = reviews[len(reviews)-1].ID
+		}
+
+		all, err := store.List(service.ReviewQuery{LaptopID: laptopID, Status: service.ReviewPending, Order: service.ReviewsOldestFirst})
+		require.NoError(t, err)
+		require.Len(t, all, 7)
+		require.Equal(t, reviewIDs(all), reviewIDs(listed))
+		require.ElementsMatch(t, reviewIDs(expected), reviewIDs(listed))
+
+		// the next page doesn't change when the last review of the previous page is moderated
+		query = service.ReviewQuery{LaptopID: laptopID, Status: service.ReviewPending, Order: service.ReviewsOldestFirst, After: all[2].ID, Limit: 2}
+		_, err = store.Moderate(all[2].ID, service.ReviewApproved, "admin1", time.Unix(2000, 0))
+		require.NoError(t, err)
+		reviews, err := store.List(query)
+		require.NoError(t, err)
+		require.Equal(t, reviewIDs(all[3:5]), reviewIDs(reviews))
+
+		query.After = uuid.New().String()
+		_, err = store.List(query)
+		require.ErrorIs(t, err, service.ErrNotFound)
+	})
+
+	t.Run("copy_isolation", func(t *testing.T) {
+		t.Parallel()
+		store := newStore(t)
+
+		review := newReview(sample.NewLaptop().GetId(), "user1", 8, time.Unix(1000, 0))
+		require.NoError(t, store.Save(review))
+
+		// mutating a saved or returned review must not change the stored one
+		review.Title = "changed"
+		found, err := store.Find(review.ID)
+		require.NoError(t, err)
+		require.Equal(t, "title of user1", found.Title)
+		found.Score = 1
+
+		reviews, err := store.List(service.ReviewQuery{LaptopID: review.LaptopID, Status: service.ReviewPending})
+		require.NoError(t, err)
+		require.Equal(t, 8.0, reviews[0].Score)
+		reviews[0].Status = service.ReviewApproved
+
+		found, err = store.Find(review.ID)
+		require.NoError(t, err)
+		require.Equal(t, 8.0, found.Score)
+		require.Equal(t, service.ReviewPending, found.Status)
+	})
+
+	t.Run("concurrent_access", func(t *testing.T) {
+		t.Parallel()
+		store := newStore(t)
+
+		review := newReview(sample.NewLaptop().GetId(), "user1", 8, time.Unix(1000, 0))
+		require.NoError(t, store.Save(review))
+
+		var wg sync.WaitGroup
+		var mutex sync.Mutex
+		moderated := 0
+		for i := 0; i < concurrency; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+
+				other := newReview(review.LaptopID, fmt.Sprintf("user%d", i), 5, time.Unix(int64(1000+i), 0))
+				assert.NoError(t, store.Save(other))
+
+				// only one moderator wins the race to moderate the same review
+				_, err := store.Moderate(review.ID, service.ReviewApproved, fmt.Sprintf("admin%d", i), time.Unix(2000, 0))
+				if err == nil {
+					mutex.Lock()
+					moderated++
+					mutex.Unlock()
+				} else {
+					assert.ErrorIs(t, err, service.ErrAlreadyModerated)
+				}
+			}()
+		}
+		wg.Wait()
+
+		require.Equal(t, 1, moderated)
+		reviews, err := store.List(service.ReviewQuery{LaptopID: review.LaptopID, Status: service.ReviewPending})
+		require.NoError(t, err)
+		require.Len(t, reviews, concurrency)
+	})
+}
+
+func newReview(laptopID string, username string, score float64, createdAt time.Time) *service.Review {
+	return &service.Review{
+		ID:        uuid.New().String(),
+		LaptopID:  laptopID,
+		Username:  username,
+		Title:     "title of " + username,
+		Body:      "body of " + username,
+		Score:     score,
+		Status:    service.ReviewPending,
+		CreatedAt: createdAt,
+	}
+}
+
+// requireSameReview requires the reviews to be equal, with their times at the same instant
+func requireSameReview(t *testing.T, expected *service.Review, actual *service.Review) {
+	require.NotNil(t, actual)
+	require.True(t, expected.CreatedAt.Equal(actual.CreatedAt))
+	require.True(t, expected.ModeratedAt.Equal(actual.ModeratedAt))
+
+	other := *actual
+	other.CreatedAt = expected.CreatedAt
+	other.ModeratedAt = expected.ModeratedAt
+	require.Equal(t, expected, &other)
+}
+
+func reviewIDs(reviews []*service.Review) []string {
+	ids := make([]string, len(reviews))
+	for i, review := range reviews {
+		ids[i] = review.ID
+	}
+	return ids
+}
@@ -0,0 +1,102 @@
+package storetest
+
+import (
+	"fmt"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gitlab.com/brucemig/pcbook/service"
+)
+
+// TestUserStore runs the conformance suite against the user stores returned by newStore.
+// newStore must return a new empty store every time it is called.
+func TestUserStore(t *testing.T, newStore func(t *testing.T) service.UserStore) {
+	t.Run("save_already_exists", func(t *testing.T) {
+		t.Parallel()
+		store := newStore(t)
+
+		user := newTestUser(t, "alice", "admin")
+		require.NoError(t, store.Save(user))
+
+		err := store.Save(user)
+		require.ErrorIs(t, err, service.ErrAlreadyExists)
+	})
+
+	t.Run("find", func(t *testing.T) {
+		t.Parallel()
+		store := newStore(t)
+
+		user, err := store.Find("nobody")
+		require.NoError(t, err)
+		require.Nil(t, user)
+
+		require.NoError(t, store.Save(newTestUser(t, "bob", "user")))
+
+		user, err = store.Find("bob")
+		require.NoError(t, err)
+		require.NotNil(t, user)
+		require.Equal(t, "bob", user.Username)
+		require.Equal(t, "user", user.Role)
+		require.True(t, user.IsCorrectPassword("secret"))
+	})
+
+	t.Run("copy_isolation", func(t *testing.T) {
+		t.Parallel()
+		store := newStore(t)
+
+		user := newTestUser(t, "carol", "user")
+		require.NoError(t, store.Save(user))
+
+		// mutating the saved user must not change the stored one
+		user.Role = "admin"
+
+		found, err := store.Find("carol")
+		require.NoError(t, err)
+		require.Equal(t, "user", found.Role)
+
+		// mutating a found user must not change the stored one
+		found.Role = "admin"
+
+		found, err = store.Find("carol")
+		require.NoError(t, err)
+		require.Equal(t, "user", found.Role)
+	})
+
+	t.Run("concurrent_access", func(t *testing.T) {
+		t.Parallel()
+		store := newStore(t)
+
+		user := newTestUser(t, "user", "user")
+
+		var wg sync.WaitGroup
+		for i := 0; i < concurrency; i++ {
+			wg.Add(1)
+			go func(i int) {
+				defer wg.Done()
+
+				other := user.Clone()
+				other.Username = fmt.Sprintf("user%d", i)
+				assert.NoError(t, store.Save(other))
+
+				found, err := store.Find(other.Username)
+				assert.NoError(t, err)
+				assert.NotNil(t, found)
+			}(i)
+		}
+		wg.Wait()
+
+		for i := 0; i < concurrency; i++ {
+			found, err := store.Find(fmt.Sprintf("user%d", i))
+			require.NoError(t, err)
+			require.NotNil(t, found)
+		}
+	})
+}
+
+func newTestUser(t *testing.T, username string, role string) *service.User {
+	user, err := service.NewUser(username, "secret", role)
+	require.NoError(t, err)
+	return user
+}